@@ -15,11 +15,15 @@ import (
 
 	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/ship"
 	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/types"
+	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/utils"
 	"github.com/bsv-blockchain/go-overlay-services/pkg/core/engine"
 	"github.com/bsv-blockchain/go-sdk/chainhash"
+	"github.com/bsv-blockchain/go-sdk/overlay"
 	"github.com/bsv-blockchain/go-sdk/overlay/lookup"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
 	"github.com/bsv-blockchain/go-sdk/script"
 	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/bsv-blockchain/go-sdk/wallet"
 )
 
 //nolint:gochecknoglobals // logger is used across multiple example functions
@@ -67,10 +71,10 @@ func ExampleOutputAdmittedByTopic(ctx context.Context, lookupService *ship.Looku
 		Index: 0, // First output
 	}
 
-	// Create a valid PushDrop locking script for SHIP advertisement
-	lockingScript, err := createSampleSHIPScript()
+	// Create a signed SHIP advertisement token
+	token, err := createSampleSHIPToken(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create sample SHIP script: %w", err)
+		return fmt.Errorf("failed to create sample SHIP token: %w", err)
 	}
 
 	// Construct the OutputAdmittedByTopic payload
@@ -79,7 +83,7 @@ func ExampleOutputAdmittedByTopic(ctx context.Context, lookupService *ship.Looku
 		Topic:         ship.Topic, // "tm_ship"
 		Outpoint:      outpoint,
 		Satoshis:      1000, // Sample satoshi value
-		LockingScript: lockingScript,
+		LockingScript: token.LockingScript,
 		AtomicBEEF:    []byte("sample"), // Sample atomic BEEF data
 	}
 
@@ -92,64 +96,28 @@ func ExampleOutputAdmittedByTopic(ctx context.Context, lookupService *ship.Looku
 	logger.Info("Successfully processed SHIP advertisement",
 		slog.String("outpoint", sampleTxidHex),
 		slog.Int("index", int(outpoint.Index)),
-		slog.String("identityKey", "deadbeef01020304"),
-		slog.String("domain", "https://example.com"),
-		slog.String("topic", "tm_bridge"))
+		slog.String("identityKey", token.IdentityKey),
+		slog.String("domain", token.Domain),
+		slog.String("topic", token.TopicOrService))
 
 	return nil
 }
 
-// createSampleSHIPScript creates a valid PushDrop script containing SHIP advertisement data.
+// createSampleSHIPToken builds a signed SHIP advertisement token with utils.BuildAdvertisementToken.
 // This demonstrates the expected format for SHIP locking scripts.
-func createSampleSHIPScript() (*script.Script, error) {
-	// Create a valid public key (33 bytes) for the base script
-	pubKeyHex := "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
-	pubKeyBytes, err := hex.DecodeString(pubKeyHex)
+func createSampleSHIPToken(ctx context.Context) (*utils.AdvertisementToken, error) {
+	// An example identity, real advertisers use the wallet of their overlay service
+	privateKey, err := ec.PrivateKeyFromHex("0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode public key: %w", err)
-	}
-
-	// Create the script
-	s := &script.Script{}
-
-	// Add public key and OP_CHECKSIG (standard P2PK pattern)
-	if err := s.AppendPushData(pubKeyBytes); err != nil {
-		return nil, fmt.Errorf("failed to append public key: %w", err)
+		return nil, fmt.Errorf("failed to decode private key: %w", err)
 	}
-	if err := s.AppendOpcodes(script.OpCHECKSIG); err != nil {
-		return nil, fmt.Errorf("failed to append OpCHECKSIG: %w", err)
-	}
-
-	// Add SHIP advertisement fields using PushDrop format
-	fields := [][]byte{
-		[]byte("SHIP"), // Protocol identifier
-		{0xde, 0xad, 0xbe, 0xef, 0x01, 0x02, 0x03, 0x04}, // Identity key
-		[]byte("https://example.com"),                    // Domain where service is hosted
-		[]byte("tm_bridge"),                              // Topic/service supported
-	}
-
-	// Add fields to script
-	for _, field := range fields {
-		if err := s.AppendPushData(field); err != nil {
-			return nil, fmt.Errorf("failed to append field to script: %w", err)
-		}
-	}
-
-	// Add DROP operations to clean up stack (PushDrop pattern)
-	notYetDropped := len(fields)
-	for notYetDropped > 1 {
-		if err := s.AppendOpcodes(script.Op2DROP); err != nil {
-			return nil, fmt.Errorf("failed to append Op2DROP: %w", err)
-		}
-		notYetDropped -= 2
-	}
-	if notYetDropped != 0 {
-		if err := s.AppendOpcodes(script.OpDROP); err != nil {
-			return nil, fmt.Errorf("failed to append OpDROP: %w", err)
-		}
+	advertiserWallet, err := wallet.NewCompletedProtoWallet(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create wallet: %w", err)
 	}
 
-	return s, nil
+	// Sign the protocol, identity key, domain and topic, and lock them with the derived locking key
+	return utils.BuildAdvertisementToken(ctx, advertiserWallet, overlay.ProtocolSHIP, "https://example.com", "tm_bridge")
 }
 
 // ExampleOutputAdmittedByTopicDemo demonstrates the API structure for OutputAdmittedByTopic
@@ -169,12 +137,13 @@ func ExampleOutputAdmittedByTopicDemo() {
 		Index: 0,
 	}
 
-	// Create sample locking script
-	lockingScript, err := createSampleSHIPScript()
+	// Create sample advertisement token
+	token, err := createSampleSHIPToken(context.Background())
 	if err != nil {
-		log.Printf("Failed to create sample script: %v", err)
+		log.Printf("Failed to create sample token: %v", err)
 		return
 	}
+	lockingScript := token.LockingScript
 
 	// Show the structure that would be passed to OutputAdmittedByTopic
 	payload := &engine.OutputAdmittedByTopic{
@@ -192,10 +161,10 @@ func ExampleOutputAdmittedByTopicDemo() {
 		slog.Uint64("satoshis", payload.Satoshis),
 		slog.String("lockingScript", lockingScript.String()))
 	logger.Info("Expected SHIP fields in script",
-		slog.String("protocol", "SHIP"),
-		slog.String("identityKey", "deadbeef01020304"),
-		slog.String("domain", "https://example.com"),
-		slog.String("topic", "tm_bridge"))
+		slog.String("protocol", string(token.Protocol)),
+		slog.String("identityKey", token.IdentityKey),
+		slog.String("domain", token.Domain),
+		slog.String("topic", token.TopicOrService))
 	logger.Info("This payload would be created by the overlay engine automatically")
 }
 
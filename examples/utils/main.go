@@ -8,6 +8,9 @@ import (
 	"os"
 
 	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/utils"
+	"github.com/bsv-blockchain/go-sdk/overlay"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/wallet"
 )
 
 //nolint:gochecknoglobals // logger is used across multiple example functions
@@ -72,27 +75,32 @@ func main() {
 		logger.Info("Hex to Bytes conversion", "hex", hexString, "bytes", backToBytes)
 	}
 
-	// Example 4: Token signature validation (with mock wallet)
-	logger.Info("4. Token Signature Validation Example (Mock):")
+	// Example 4: Advertisement token encoding and signature validation
+	logger.Info("4. Advertisement Token Example:")
 
-	// Create mock token fields for demonstration
-	protocol := []byte("SHIP")
-	identityKey := []byte{0x01, 0x02, 0x03, 0x04}
-	extraData := []byte("example data")
-	signature := []byte{0xff, 0xee, 0xdd}
-
-	tokenFields := utils.TokenFields{
-		protocol,
-		identityKey,
-		extraData,
-		signature,
+	// An example identity, real advertisers use the wallet of their overlay service
+	privateKey, err := ec.PrivateKeyFromHex("0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+	if err != nil {
+		log.Fatalf("Failed to decode private key: %v", err)
+	}
+	advertiserWallet, err := wallet.NewCompletedProtoWallet(privateKey)
+	if err != nil {
+		log.Fatalf("Failed to create wallet: %v", err)
 	}
 
-	lockingPubKey := "03abc123def456"
+	token, err := utils.BuildAdvertisementToken(context.TODO(), advertiserWallet, overlay.ProtocolSHIP, "https://example.com", "tm_bridge")
+	if err != nil {
+		log.Fatalf("Failed to build advertisement token: %v", err)
+	}
+	logger.Info("Built SHIP advertisement token",
+		"identityKey", token.IdentityKey,
+		"domain", token.Domain,
+		"topic", token.TopicOrService,
+		"lockingScript", token.LockingScript.String())
 
-	isValid, err := utils.IsTokenSignatureCorrectlyLinked(context.TODO(), lockingPubKey, tokenFields)
+	isValid, err := utils.IsTokenSignatureCorrectlyLinked(context.TODO(), token.LockingPublicKey.ToDERHex(), token.Fields)
 	if err != nil {
-		logger.Info("Token validation error (expected with mock wallet)", "error", err)
+		logger.Info("Token validation error", "error", err)
 	} else {
 		status := "Invalid"
 		if isValid {
@@ -102,6 +110,4 @@ func main() {
 	}
 
 	logger.Info("=== Example Complete ===")
-	logger.Info("Note: Token signature validation requires a real BSV SDK wallet implementation.")
-	logger.Info("The MockWallet is provided for testing and will always return errors.")
 }
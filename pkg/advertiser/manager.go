@@ -0,0 +1,287 @@
+package advertiser
+
+// Background manager keeping the advertisements alive by reconciling them periodically.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	oa "github.com/bsv-blockchain/go-overlay-services/pkg/core/advertiser"
+	"github.com/bsv-blockchain/go-sdk/overlay"
+)
+
+// Static error variables for err113 compliance
+var (
+	errReconcilerRequired     = errors.New("reconciler is required")
+	errBroadcasterRequired    = errors.New("broadcaster is required")
+	errManagerIntervalInvalid = errors.New("reconciliation interval must be positive")
+	errManagerAlreadyStarted  = errors.New("advertisement manager is already started")
+)
+
+// Reconciler reconciles the advertisements on the overlay with the desired ones.
+// WalletAdvertiser implements it.
+type Reconciler interface {
+	ReconcileContext(ctx context.Context, desired []*oa.AdvertisementData) (*ReconciliationResult, error)
+}
+
+// Broadcaster submits tagged BEEF to the overlay network
+type Broadcaster interface {
+	Broadcast(ctx context.Context, taggedBEEF overlay.TaggedBEEF) error
+}
+
+// Compile-time verification that WalletAdvertiser implements Reconciler
+var _ Reconciler = (*WalletAdvertiser)(nil)
+
+// ManagerStatus reports the state of an advertisement manager
+type ManagerStatus struct {
+	// Running tells whether the manager is reconciling periodically
+	Running bool
+	// Runs is the number of completed reconciliation runs
+	Runs int
+	// ConsecutiveFailures is the number of failed runs since the last successful one
+	ConsecutiveFailures int
+	// LastRun is when the last run completed
+	LastRun time.Time
+	// LastSuccess is when the last successful run completed
+	LastSuccess time.Time
+	// LastError is the error of the last run, nil if it succeeded
+	LastError error
+	// LastPlan is the reconciliation plan of the last run, if it got that far
+	LastPlan *ReconciliationPlan
+	// Advertisements are the advertisements found on the overlay and kept by the last successful run
+	Advertisements []*oa.Advertisement
+	// Created are the advertisements whose creation was broadcast by the last successful run
+	Created []*oa.AdvertisementData
+	// Pending is the tagged BEEF built by earlier runs whose broadcast failed, retried by the next run
+	Pending []overlay.TaggedBEEF
+}
+
+// pendingBroadcast is tagged BEEF built by a reconciliation that has not been broadcast yet
+type pendingBroadcast struct {
+	taggedBEEF overlay.TaggedBEEF
+	// created are the advertisements the BEEF creates, nil for revocations
+	created    []*oa.AdvertisementData
+	revocation bool
+}
+
+// Manager keeps the advertisements of a node alive in the background. On every interval it reconciles
+// the advertisements on the overlay with the desired ones and broadcasts the resulting creations and
+// revocations, so advertisements lost to spends or left stale by a domain change are replaced.
+type Manager struct {
+	reconciler  Reconciler
+	broadcaster Broadcaster
+	interval    time.Duration
+
+	// runMu serializes the runs, so that the background loop and direct RunOnce calls never create
+	// the same advertisements twice
+	runMu sync.Mutex
+
+	mu      sync.Mutex
+	desired []*oa.AdvertisementData
+	pending []pendingBroadcast
+	status  ManagerStatus
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewManager creates an advertisement manager reconciling the desired advertisements every interval.
+func NewManager(reconciler Reconciler, broadcaster Broadcaster, desired []*oa.AdvertisementData, interval time.Duration) (*Manager, error) {
+	if reconciler == nil {
+		return nil, errReconcilerRequired
+	}
+	if broadcaster == nil {
+		return nil, errBroadcasterRequired
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("%w: %s", errManagerIntervalInvalid, interval)
+	}
+
+	return &Manager{
+		reconciler:  reconciler,
+		broadcaster: broadcaster,
+		interval:    interval,
+		desired:     slices.Clone(desired),
+	}, nil
+}
+
+// SetDesired replaces the desired advertisements; the change is applied by the next run.
+func (m *Manager) SetDesired(desired []*oa.AdvertisementData) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.desired = slices.Clone(desired)
+}
+
+// Start runs a reconciliation immediately and then on every interval in the background, until Stop
+// is called or ctx is done. Failed runs are recorded in the status and retried on the next interval.
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.done != nil {
+		return errManagerAlreadyStarted
+	}
+
+	ctx, m.cancel = context.WithCancel(ctx)
+	m.done = make(chan struct{})
+	m.status.Running = true
+
+	go m.loop(ctx, m.done)
+	return nil
+}
+
+// Stop stops the background reconciliation and waits for a run in progress to finish.
+// It is a no-op if the manager is not running.
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	cancel, done := m.cancel, m.done
+	m.mu.Unlock()
+
+	if done == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// Status returns a snapshot of the manager status
+func (m *Manager) Status() ManagerStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := m.status
+	status.Advertisements = slices.Clone(m.status.Advertisements)
+	status.Created = slices.Clone(m.status.Created)
+	for _, p := range m.pending {
+		status.Pending = append(status.Pending, p.taggedBEEF)
+	}
+	return status
+}
+
+// RunOnce reconciles the advertisements and broadcasts the resulting creations and revocations.
+// It is what the background loop runs on every interval, and may also be called directly, in which
+// case it waits for a run in progress to finish first.
+//
+// Tagged BEEF whose broadcast failed is kept and retried by the next run instead of reconciling, as
+// its transactions are already signed and funded while the overlay does not know about them yet, so
+// reconciling again would create and fund the same advertisements a second time.
+func (m *Manager) RunOnce(ctx context.Context) error {
+	m.runMu.Lock()
+	defer m.runMu.Unlock()
+
+	m.mu.Lock()
+	desired, retry := m.desired, len(m.pending) > 0
+	m.mu.Unlock()
+
+	if retry {
+		created, err := m.broadcastPending(ctx)
+		return m.recordRun(nil, created, err)
+	}
+
+	result, err := m.reconciler.ReconcileContext(ctx, desired)
+	if result != nil {
+		// A failed reconciliation may still return creations built before the failure
+		m.queueResult(result)
+	}
+	created, broadcastErr := m.broadcastPending(ctx)
+	if err == nil {
+		err = broadcastErr
+	}
+	return m.recordRun(result, created, err)
+}
+
+// queueResult queues the creations and revocations of a reconciliation for broadcasting
+func (m *Manager) queueResult(result *ReconciliationResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if result.Creations != nil {
+		m.pending = append(m.pending, pendingBroadcast{taggedBEEF: *result.Creations, created: result.Plan.Create})
+	}
+	if result.Revocations != nil {
+		m.pending = append(m.pending, pendingBroadcast{taggedBEEF: *result.Revocations, revocation: true})
+	}
+}
+
+// broadcastPending broadcasts the queued tagged BEEF in order, stopping at the first failure so that
+// the rest stays queued. It returns the advertisements created by the BEEF that was broadcast.
+func (m *Manager) broadcastPending(ctx context.Context) ([]*oa.AdvertisementData, error) {
+	var created []*oa.AdvertisementData
+	for {
+		m.mu.Lock()
+		if len(m.pending) == 0 {
+			m.mu.Unlock()
+			return created, nil
+		}
+		next := m.pending[0]
+		m.mu.Unlock()
+
+		if err := m.broadcaster.Broadcast(ctx, next.taggedBEEF); err != nil {
+			if next.revocation {
+				return created, fmt.Errorf("failed to broadcast advertisement revocations: %w", err)
+			}
+			return created, fmt.Errorf("failed to broadcast advertisement creations: %w", err)
+		}
+
+		m.mu.Lock()
+		m.pending = m.pending[1:]
+		m.mu.Unlock()
+		created = append(created, next.created...)
+	}
+}
+
+// recordRun records the outcome of a run in the status. result is nil for runs that only retried
+// the pending broadcasts.
+func (m *Manager) recordRun(result *ReconciliationResult, created []*oa.AdvertisementData, err error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.status.Runs++
+	m.status.LastRun = time.Now()
+	m.status.LastError = err
+	m.status.LastPlan = nil
+	if result != nil {
+		m.status.LastPlan = result.Plan
+	}
+	if err != nil {
+		m.status.ConsecutiveFailures++
+		return err
+	}
+	m.status.ConsecutiveFailures = 0
+	m.status.LastSuccess = m.status.LastRun
+	if result != nil {
+		m.status.Advertisements = result.Plan.Keep
+	}
+	m.status.Created = created
+	return nil
+}
+
+// loop runs the reconciliation on every interval until ctx is done
+func (m *Manager) loop(ctx context.Context, done chan struct{}) {
+	defer func() {
+		m.mu.Lock()
+		m.status.Running = false
+		m.cancel, m.done = nil, nil
+		m.mu.Unlock()
+		close(done)
+	}()
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			slog.Warn("Advertisement reconciliation failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,353 @@
+package advertiser
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/types"
+	oa "github.com/bsv-blockchain/go-overlay-services/pkg/core/advertiser"
+	"github.com/bsv-blockchain/go-sdk/overlay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	errTestReconcile = errors.New("reconcile failed")
+	errTestBroadcast = errors.New("broadcast failed")
+)
+
+// mockReconciler returns a fixed reconciliation result and records the desired advertisements
+type mockReconciler struct {
+	mu      sync.Mutex
+	result  *ReconciliationResult
+	err     error
+	desired [][]*oa.AdvertisementData
+}
+
+func (r *mockReconciler) ReconcileContext(_ context.Context, desired []*oa.AdvertisementData) (*ReconciliationResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.desired = append(r.desired, desired)
+	return r.result, r.err
+}
+
+// overlapReconciler reconciles slowly and records whether two reconciliations ever overlapped
+type overlapReconciler struct {
+	active   atomic.Int32
+	calls    atomic.Int32
+	overlaps atomic.Bool
+}
+
+func (r *overlapReconciler) ReconcileContext(context.Context, []*oa.AdvertisementData) (*ReconciliationResult, error) {
+	if r.active.Add(1) > 1 {
+		r.overlaps.Store(true)
+	}
+	defer r.active.Add(-1)
+	r.calls.Add(1)
+	time.Sleep(2 * time.Millisecond)
+	return newTestReconciliationResult(), nil
+}
+
+func (r *mockReconciler) calls() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.desired)
+}
+
+// mockBroadcaster records the broadcast tagged BEEF. It fails with err, and with errTestBroadcast for
+// the first failures[beef] broadcasts of a BEEF.
+type mockBroadcaster struct {
+	mu        sync.Mutex
+	err       error
+	failures  map[string]int
+	broadcast []overlay.TaggedBEEF
+}
+
+func (b *mockBroadcaster) Broadcast(_ context.Context, taggedBEEF overlay.TaggedBEEF) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.broadcast = append(b.broadcast, taggedBEEF)
+	if b.failures[string(taggedBEEF.Beef)] > 0 {
+		b.failures[string(taggedBEEF.Beef)]--
+		return errTestBroadcast
+	}
+	return b.err
+}
+
+func newTestReconciliationResult() *ReconciliationResult {
+	return &ReconciliationResult{
+		Plan: &ReconciliationPlan{
+			Create: []*oa.AdvertisementData{{Protocol: overlay.ProtocolSHIP, TopicOrServiceName: "tm_payments"}},
+			Revoke: []PlannedRevocation{{Advertisement: &oa.Advertisement{Protocol: overlay.ProtocolSHIP, TopicOrService: "tm_retired"}, Reason: RevocationReasonUndesired}},
+			Keep:   []*oa.Advertisement{{Protocol: overlay.ProtocolSLAP, TopicOrService: "ls_payments"}},
+		},
+		Creations:   &overlay.TaggedBEEF{Beef: []byte("creations"), Topics: []string{"tm_ship"}},
+		Revocations: &overlay.TaggedBEEF{Beef: []byte("revocations"), Topics: []string{"tm_ship"}},
+	}
+}
+
+func TestNewManager(t *testing.T) {
+	tests := []struct {
+		name        string
+		reconciler  Reconciler
+		broadcaster Broadcaster
+		interval    time.Duration
+		expectedErr error
+	}{
+		{name: "Valid manager", reconciler: &mockReconciler{}, broadcaster: &mockBroadcaster{}, interval: time.Minute},
+		{name: "Missing reconciler", broadcaster: &mockBroadcaster{}, interval: time.Minute, expectedErr: errReconcilerRequired},
+		{name: "Missing broadcaster", reconciler: &mockReconciler{}, interval: time.Minute, expectedErr: errBroadcasterRequired},
+		{name: "Invalid interval", reconciler: &mockReconciler{}, broadcaster: &mockBroadcaster{}, expectedErr: errManagerIntervalInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, err := NewManager(tt.reconciler, tt.broadcaster, nil, tt.interval)
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+				assert.Nil(t, manager)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, manager)
+		})
+	}
+}
+
+func TestManager_RunOnce(t *testing.T) {
+	desired := []*oa.AdvertisementData{{Protocol: overlay.ProtocolSHIP, TopicOrServiceName: "tm_payments"}}
+
+	t.Run("broadcasts creations and revocations", func(t *testing.T) {
+		result := newTestReconciliationResult()
+		reconciler := &mockReconciler{result: result}
+		broadcaster := &mockBroadcaster{}
+		manager, err := NewManager(reconciler, broadcaster, desired, time.Minute)
+		require.NoError(t, err)
+
+		require.NoError(t, manager.RunOnce(context.Background()))
+		assert.Equal(t, [][]*oa.AdvertisementData{desired}, reconciler.desired)
+		assert.Equal(t, []overlay.TaggedBEEF{*result.Creations, *result.Revocations}, broadcaster.broadcast)
+
+		status := manager.Status()
+		assert.False(t, status.Running)
+		assert.Equal(t, 1, status.Runs)
+		assert.Zero(t, status.ConsecutiveFailures)
+		require.NoError(t, status.LastError)
+		assert.False(t, status.LastRun.IsZero())
+		assert.Equal(t, status.LastRun, status.LastSuccess)
+		assert.Same(t, result.Plan, status.LastPlan)
+		assert.Equal(t, result.Plan.Keep, status.Advertisements)
+		assert.Equal(t, result.Plan.Create, status.Created)
+	})
+
+	t.Run("skips broadcasting when there is nothing to do", func(t *testing.T) {
+		broadcaster := &mockBroadcaster{}
+		manager, err := NewManager(&mockReconciler{result: &ReconciliationResult{Plan: &ReconciliationPlan{}}}, broadcaster, desired, time.Minute)
+		require.NoError(t, err)
+
+		require.NoError(t, manager.RunOnce(context.Background()))
+		assert.Empty(t, broadcaster.broadcast)
+	})
+
+	t.Run("records reconciliation errors", func(t *testing.T) {
+		manager, err := NewManager(&mockReconciler{err: errTestReconcile}, &mockBroadcaster{}, desired, time.Minute)
+		require.NoError(t, err)
+
+		require.ErrorIs(t, manager.RunOnce(context.Background()), errTestReconcile)
+		require.ErrorIs(t, manager.RunOnce(context.Background()), errTestReconcile)
+
+		status := manager.Status()
+		assert.Equal(t, 2, status.Runs)
+		assert.Equal(t, 2, status.ConsecutiveFailures)
+		require.ErrorIs(t, status.LastError, errTestReconcile)
+		assert.True(t, status.LastSuccess.IsZero())
+		assert.Nil(t, status.LastPlan)
+	})
+
+	t.Run("records broadcast errors and recovers", func(t *testing.T) {
+		result := newTestReconciliationResult()
+		broadcaster := &mockBroadcaster{err: errTestReconcile}
+		manager, err := NewManager(&mockReconciler{result: result}, broadcaster, desired, time.Minute)
+		require.NoError(t, err)
+
+		err = manager.RunOnce(context.Background())
+		require.ErrorIs(t, err, errTestReconcile)
+		assert.Contains(t, err.Error(), "failed to broadcast advertisement creations")
+		assert.Equal(t, 1, manager.Status().ConsecutiveFailures)
+		assert.Same(t, result.Plan, manager.Status().LastPlan)
+
+		broadcaster.err = nil
+		require.NoError(t, manager.RunOnce(context.Background()))
+		status := manager.Status()
+		assert.Zero(t, status.ConsecutiveFailures)
+		require.NoError(t, status.LastError)
+	})
+
+	t.Run("retries failed broadcasts instead of creating the advertisements again", func(t *testing.T) {
+		result := newTestReconciliationResult()
+		reconciler := &mockReconciler{result: result}
+		broadcaster := &mockBroadcaster{failures: map[string]int{"creations": 1}}
+		manager, err := NewManager(reconciler, broadcaster, desired, time.Minute)
+		require.NoError(t, err)
+
+		require.ErrorIs(t, manager.RunOnce(context.Background()), errTestBroadcast)
+		status := manager.Status()
+		assert.Equal(t, []overlay.TaggedBEEF{*result.Creations, *result.Revocations}, status.Pending)
+		assert.Empty(t, status.Created)
+
+		// The next run only broadcasts the pending BEEF, without reconciling again
+		require.NoError(t, manager.RunOnce(context.Background()))
+		assert.Equal(t, 1, reconciler.calls())
+		assert.Equal(t, []overlay.TaggedBEEF{*result.Creations, *result.Creations, *result.Revocations}, broadcaster.broadcast)
+		status = manager.Status()
+		assert.Empty(t, status.Pending)
+		assert.Equal(t, result.Plan.Create, status.Created)
+		assert.Nil(t, status.LastPlan)
+
+		require.NoError(t, manager.RunOnce(context.Background()))
+		assert.Equal(t, 2, reconciler.calls())
+	})
+
+	t.Run("keeps the revocations when only they fail to broadcast", func(t *testing.T) {
+		result := newTestReconciliationResult()
+		reconciler := &mockReconciler{result: result}
+		broadcaster := &mockBroadcaster{failures: map[string]int{"revocations": 1}}
+		manager, err := NewManager(reconciler, broadcaster, desired, time.Minute)
+		require.NoError(t, err)
+
+		err = manager.RunOnce(context.Background())
+		require.ErrorIs(t, err, errTestBroadcast)
+		assert.Contains(t, err.Error(), "failed to broadcast advertisement revocations")
+		assert.Equal(t, []overlay.TaggedBEEF{*result.Revocations}, manager.Status().Pending)
+
+		require.NoError(t, manager.RunOnce(context.Background()))
+		assert.Equal(t, 1, reconciler.calls())
+		assert.Equal(t, []overlay.TaggedBEEF{*result.Creations, *result.Revocations, *result.Revocations}, broadcaster.broadcast)
+		assert.Empty(t, manager.Status().Pending)
+		assert.Empty(t, manager.Status().Created)
+	})
+
+	t.Run("broadcasts the creations of a failed reconciliation", func(t *testing.T) {
+		result := newTestReconciliationResult()
+		result.Revocations = nil
+		broadcaster := &mockBroadcaster{}
+		manager, err := NewManager(&mockReconciler{result: result, err: errTestReconcile}, broadcaster, desired, time.Minute)
+		require.NoError(t, err)
+
+		require.ErrorIs(t, manager.RunOnce(context.Background()), errTestReconcile)
+		assert.Equal(t, []overlay.TaggedBEEF{*result.Creations}, broadcaster.broadcast)
+		assert.Empty(t, manager.Status().Pending)
+	})
+
+	t.Run("only reports the advertisements whose creation was broadcast", func(t *testing.T) {
+		result := newTestReconciliationResult()
+		result.Creations = nil
+		manager, err := NewManager(&mockReconciler{result: result}, &mockBroadcaster{}, desired, time.Minute)
+		require.NoError(t, err)
+
+		require.NoError(t, manager.RunOnce(context.Background()))
+		assert.Empty(t, manager.Status().Created)
+	})
+
+	t.Run("records advertisement lookup failures without broadcasting", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+		advertiser, err := NewWalletAdvertiserWithOptions(context.Background(), "main", "",
+			"https://storage.example.com", testAdvertisableURI, WithWallet(newTestAdvertiserWallet(t)),
+			WithLookupResolverConfig(&types.LookupResolverConfig{HTTPSEndpoint: stringPtr(server.URL)}))
+		require.NoError(t, err)
+		advertiser.SetSkipStorageValidation(true)
+		require.NoError(t, advertiser.Init())
+
+		broadcaster := &mockBroadcaster{}
+		manager, err := NewManager(advertiser, broadcaster, desired, time.Minute)
+		require.NoError(t, err)
+
+		require.ErrorIs(t, manager.RunOnce(context.Background()), errAdvertisementLookupFailed)
+		require.ErrorIs(t, manager.RunOnce(context.Background()), errAdvertisementLookupFailed)
+		assert.Empty(t, broadcaster.broadcast)
+		assert.Equal(t, 2, manager.Status().ConsecutiveFailures)
+	})
+
+	t.Run("uses the latest desired advertisements", func(t *testing.T) {
+		reconciler := &mockReconciler{result: &ReconciliationResult{Plan: &ReconciliationPlan{}}}
+		manager, err := NewManager(reconciler, &mockBroadcaster{}, desired, time.Minute)
+		require.NoError(t, err)
+
+		updated := []*oa.AdvertisementData{{Protocol: overlay.ProtocolSLAP, TopicOrServiceName: "ls_payments"}}
+		manager.SetDesired(updated)
+		require.NoError(t, manager.RunOnce(context.Background()))
+		assert.Equal(t, [][]*oa.AdvertisementData{updated}, reconciler.desired)
+	})
+}
+
+func TestManager_Lifecycle(t *testing.T) {
+	t.Run("reconciles periodically until stopped", func(t *testing.T) {
+		reconciler := &mockReconciler{result: &ReconciliationResult{Plan: &ReconciliationPlan{}}}
+		manager, err := NewManager(reconciler, &mockBroadcaster{}, nil, 10*time.Millisecond)
+		require.NoError(t, err)
+
+		require.NoError(t, manager.Start(context.Background()))
+		require.ErrorIs(t, manager.Start(context.Background()), errManagerAlreadyStarted)
+		assert.True(t, manager.Status().Running)
+
+		require.Eventually(t, func() bool { return reconciler.calls() >= 3 }, 5*time.Second, 5*time.Millisecond)
+
+		manager.Stop()
+		assert.False(t, manager.Status().Running)
+		calls := reconciler.calls()
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, calls, reconciler.calls())
+
+		// Stopping again is a no-op, and the manager can be restarted
+		manager.Stop()
+		require.NoError(t, manager.Start(context.Background()))
+		manager.Stop()
+	})
+
+	t.Run("stops when the context is done", func(t *testing.T) {
+		reconciler := &mockReconciler{err: errTestReconcile}
+		manager, err := NewManager(reconciler, &mockBroadcaster{}, nil, time.Hour)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		require.NoError(t, manager.Start(ctx))
+		require.Eventually(t, func() bool { return reconciler.calls() == 1 }, 5*time.Second, 5*time.Millisecond)
+
+		cancel()
+		require.Eventually(t, func() bool { return !manager.Status().Running }, 5*time.Second, 5*time.Millisecond)
+		require.ErrorIs(t, manager.Status().LastError, errTestReconcile)
+		manager.Stop()
+	})
+
+	t.Run("serializes direct runs with the background loop", func(t *testing.T) {
+		reconciler := &overlapReconciler{}
+		manager, err := NewManager(reconciler, &mockBroadcaster{}, nil, time.Millisecond)
+		require.NoError(t, err)
+
+		require.NoError(t, manager.Start(context.Background()))
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 5 {
+					assert.NoError(t, manager.RunOnce(context.Background()))
+				}
+			}()
+		}
+		wg.Wait()
+		manager.Stop()
+
+		assert.GreaterOrEqual(t, reconciler.calls.Load(), int32(20))
+		assert.False(t, reconciler.overlaps.Load(), "reconciliations overlapped")
+	})
+}
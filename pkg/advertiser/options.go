@@ -0,0 +1,70 @@
+package advertiser
+
+// Functional options for NewWalletAdvertiserWithOptions.
+
+import (
+	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/types"
+	authhttp "github.com/bsv-blockchain/go-sdk/auth/clients/authhttp"
+	"github.com/bsv-blockchain/go-sdk/wallet"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/defs"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/storage"
+)
+
+// Option configures a WalletAdvertiser created with NewWalletAdvertiserWithOptions.
+type Option func(*advertiserOptions)
+
+// advertiserOptions holds the dependencies a WalletAdvertiser is built with. Unset dependencies
+// are created from the go-wallet-toolbox defaults.
+type advertiserOptions struct {
+	// wallet replaces the toolbox wallet; its identity key is the advertiser's identity key
+	wallet wallet.Interface
+	// authFetch replaces the AuthFetch client built on the wallet
+	authFetch *authhttp.AuthFetch
+	// storageProvider replaces the GORM storage provider of the toolbox wallet
+	storageProvider *storage.Provider
+	// dbConfig replaces the database configuration of the default GORM storage provider
+	dbConfig *defs.Database
+	// lookupResolverConfig configures advertisement lookups
+	lookupResolverConfig *types.LookupResolverConfig
+}
+
+// WithWallet makes the advertiser create and sign its transactions through an existing wallet,
+// such as one backed by remote storage, instead of a toolbox wallet with local storage. The wallet
+// identity key is the advertiser's identity key, so the private key may be left empty; a private key
+// of another identity makes Init fail. It cannot be combined with WithStorageProvider or WithDBConfig.
+func WithWallet(w wallet.Interface) Option {
+	return func(o *advertiserOptions) {
+		o.wallet = w
+	}
+}
+
+// WithAuthFetch sets the AuthFetch client used for authenticated storage requests.
+// By default, one is created on top of the advertiser's wallet.
+func WithAuthFetch(authFetch *authhttp.AuthFetch) Option {
+	return func(o *advertiserOptions) {
+		o.authFetch = authFetch
+	}
+}
+
+// WithStorageProvider sets the storage provider of the toolbox wallet created for the advertiser.
+// The provider is migrated when the advertiser is created.
+func WithStorageProvider(provider *storage.Provider) Option {
+	return func(o *advertiserOptions) {
+		o.storageProvider = provider
+	}
+}
+
+// WithDBConfig sets the database configuration, such as the DB path, of the default GORM storage
+// provider. It cannot be combined with WithStorageProvider.
+func WithDBConfig(dbConfig defs.Database) Option {
+	return func(o *advertiserOptions) {
+		o.dbConfig = &dbConfig
+	}
+}
+
+// WithLookupResolverConfig sets the configuration of advertisement lookups.
+func WithLookupResolverConfig(config *types.LookupResolverConfig) Option {
+	return func(o *advertiserOptions) {
+		o.lookupResolverConfig = config
+	}
+}
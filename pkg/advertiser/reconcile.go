@@ -0,0 +1,190 @@
+package advertiser
+
+// Reconciliation of the advertisements found on the overlay against the desired ones.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	oa "github.com/bsv-blockchain/go-overlay-services/pkg/core/advertiser"
+	"github.com/bsv-blockchain/go-sdk/overlay"
+)
+
+// Static error variables for err113 compliance
+var (
+	errNotInitializedForReconcile = errors.New("WalletAdvertiser must be initialized before reconciling advertisements")
+)
+
+// RevocationReason explains why reconciliation revokes an advertisement
+type RevocationReason string
+
+// Reasons for revoking an advertisement during reconciliation
+const (
+	// RevocationReasonUndesired marks an advertisement for a topic or service that is no longer desired
+	RevocationReasonUndesired RevocationReason = "undesired"
+	// RevocationReasonStale marks an advertisement of a previous advertisable URI, e.g. after a domain change
+	RevocationReasonStale RevocationReason = "stale"
+	// RevocationReasonDuplicate marks an extra advertisement for a topic or service that is already advertised
+	RevocationReasonDuplicate RevocationReason = "duplicate"
+)
+
+// PlannedRevocation is an advertisement reconciliation revokes, with the reason why
+type PlannedRevocation struct {
+	Advertisement *oa.Advertisement `json:"advertisement"`
+	Reason        RevocationReason  `json:"reason"`
+}
+
+// ReconciliationPlan describes the changes bringing the advertisements on the overlay in line with
+// the desired ones: advertisements to create for missing topics or services, advertisements to revoke,
+// and advertisements that are kept as they are.
+type ReconciliationPlan struct {
+	Create []*oa.AdvertisementData `json:"create"`
+	Revoke []PlannedRevocation     `json:"revoke"`
+	Keep   []*oa.Advertisement     `json:"keep"`
+}
+
+// IsEmpty reports whether the plan has nothing to create or revoke
+func (p *ReconciliationPlan) IsEmpty() bool {
+	return len(p.Create) == 0 && len(p.Revoke) == 0
+}
+
+// ReconciliationResult holds an applied reconciliation plan along with the tagged BEEF of the
+// advertisement creations and revocations, which are nil when there is nothing to create or revoke.
+type ReconciliationResult struct {
+	Plan        *ReconciliationPlan
+	Creations   *overlay.TaggedBEEF
+	Revocations *overlay.TaggedBEEF
+}
+
+// PlanReconciliation is a dry run of Reconcile: it computes the reconciliation plan without creating
+// or revoking any advertisement.
+func (w *WalletAdvertiser) PlanReconciliation(desired []*oa.AdvertisementData) (*ReconciliationPlan, error) {
+	return w.PlanReconciliationContext(context.Background(), desired)
+}
+
+// PlanReconciliationContext is PlanReconciliation with a context propagated to the lookup resolver.
+func (w *WalletAdvertiser) PlanReconciliationContext(ctx context.Context, desired []*oa.AdvertisementData) (*ReconciliationPlan, error) {
+	if !w.initialized {
+		return nil, errNotInitializedForReconcile
+	}
+
+	// Bare names are prefixed, so that they match the names of the advertisements found on the overlay
+	normalized := make([]*oa.AdvertisementData, len(desired))
+	for i, adData := range desired {
+		var err error
+		if normalized[i], err = w.normalizeAdvertisementData(adData); err != nil {
+			return nil, fmt.Errorf("invalid advertisement data at index %d: %w", i, err)
+		}
+	}
+	desired = normalized
+
+	var existing []*oa.Advertisement
+	for _, protocol := range []overlay.Protocol{overlay.ProtocolSHIP, overlay.ProtocolSLAP} {
+		ads, err := w.FindAllAdvertisementsContext(ctx, protocol)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find %s advertisements: %w", protocol, err)
+		}
+		existing = append(existing, ads...)
+	}
+
+	return planReconciliation(desired, existing, w.identityKey, w.advertisableURI), nil
+}
+
+// Reconcile syncs the advertisements on the overlay with the desired ones. Desired topics and services
+// without an advertisement of the current advertisable URI are advertised, while advertisements that
+// are no longer desired, stale or duplicated are revoked. The returned tagged BEEF must be submitted
+// to the overlay for the changes to take effect.
+//
+// If the revocations fail after the creations were built, the result is returned along with the
+// error, so that the already funded creations can still be submitted instead of being created again.
+func (w *WalletAdvertiser) Reconcile(desired []*oa.AdvertisementData) (*ReconciliationResult, error) {
+	return w.ReconcileContext(context.Background(), desired)
+}
+
+// ReconcileContext is Reconcile with a context propagated to the lookup resolver and the wallet.
+func (w *WalletAdvertiser) ReconcileContext(ctx context.Context, desired []*oa.AdvertisementData) (*ReconciliationResult, error) {
+	plan, err := w.PlanReconciliationContext(ctx, desired)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReconciliationResult{Plan: plan}
+
+	// Advertisements are created first, so a failure leaves the previous advertisements in place
+	if len(plan.Create) > 0 {
+		creations, err := w.CreateAdvertisementsContext(ctx, plan.Create)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create advertisements: %w", err)
+		}
+		result.Creations = &creations
+	}
+
+	if len(plan.Revoke) > 0 {
+		advertisements := make([]*oa.Advertisement, len(plan.Revoke))
+		for i, revocation := range plan.Revoke {
+			advertisements[i] = revocation.Advertisement
+		}
+		revocations, err := w.RevokeAdvertisementsContext(ctx, advertisements)
+		if err != nil {
+			return result, fmt.Errorf("failed to revoke advertisements: %w", err)
+		}
+		result.Revocations = &revocations
+	}
+
+	return result, nil
+}
+
+// planReconciliation compares the existing advertisements of an identity with the desired ones.
+// Advertisements of other identities are left out of the plan since they cannot be revoked.
+func planReconciliation(desired []*oa.AdvertisementData, existing []*oa.Advertisement, identityKey, advertisableURI string) *ReconciliationPlan {
+	type adKey struct {
+		protocol       overlay.Protocol
+		topicOrService string
+	}
+
+	wanted := make(map[adKey]*oa.AdvertisementData, len(desired))
+	var wantedOrder []adKey
+	for _, adData := range desired {
+		key := adKey{adData.Protocol, adData.TopicOrServiceName}
+		if _, ok := wanted[key]; !ok {
+			wanted[key] = adData
+			wantedOrder = append(wantedOrder, key)
+		}
+	}
+
+	plan := &ReconciliationPlan{}
+	kept := make(map[adKey]bool, len(wanted))
+	for _, ad := range existing {
+		if ad.IdentityKey != "" && ad.IdentityKey != identityKey {
+			continue
+		}
+
+		key := adKey{ad.Protocol, ad.TopicOrService}
+		switch {
+		case wanted[key] == nil:
+			plan.Revoke = append(plan.Revoke, PlannedRevocation{Advertisement: ad, Reason: RevocationReasonUndesired})
+		case !sameAdvertisedURI(ad.Domain, advertisableURI):
+			plan.Revoke = append(plan.Revoke, PlannedRevocation{Advertisement: ad, Reason: RevocationReasonStale})
+		case kept[key]:
+			plan.Revoke = append(plan.Revoke, PlannedRevocation{Advertisement: ad, Reason: RevocationReasonDuplicate})
+		default:
+			kept[key] = true
+			plan.Keep = append(plan.Keep, ad)
+		}
+	}
+
+	for _, key := range wantedOrder {
+		if !kept[key] {
+			plan.Create = append(plan.Create, wanted[key])
+		}
+	}
+
+	return plan
+}
+
+// sameAdvertisedURI reports whether two advertised URIs are the same, ignoring a trailing slash
+func sameAdvertisedURI(a, b string) bool {
+	return strings.TrimSuffix(a, "/") == strings.TrimSuffix(b, "/")
+}
@@ -0,0 +1,245 @@
+package advertiser
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/types"
+	oa "github.com/bsv-blockchain/go-overlay-services/pkg/core/advertiser"
+	"github.com/bsv-blockchain/go-sdk/overlay"
+	"github.com/bsv-blockchain/go-sdk/wallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testIdentityKey     = "02fe8d1eb1bcb3432b1db5833ff5f2226d9cb5e65cee430558c18ed3a3c86ce1af"
+	testAdvertisableURI = "https://service.example.com/"
+)
+
+// reconcileFinder serves fixed advertisements and records the advertisements created through it
+type reconcileFinder struct {
+	advertisements []*oa.Advertisement
+	created        []*oa.AdvertisementData
+}
+
+func (f *reconcileFinder) Advertisements(protocol overlay.Protocol) ([]*oa.Advertisement, error) {
+	var ads []*oa.Advertisement
+	for _, ad := range f.advertisements {
+		if ad.Protocol == protocol {
+			ads = append(ads, ad)
+		}
+	}
+	return ads, nil
+}
+
+func (f *reconcileFinder) CreateAdvertisements(adsData []*oa.AdvertisementData, _, _ string) (overlay.TaggedBEEF, error) {
+	f.created = append(f.created, adsData...)
+	return overlay.TaggedBEEF{Beef: []byte("created"), Topics: []string{"tm_ship"}}, nil
+}
+
+func TestPlanReconciliation(t *testing.T) {
+	shipPayments := &oa.AdvertisementData{Protocol: overlay.ProtocolSHIP, TopicOrServiceName: "tm_payments"}
+	slapPayments := &oa.AdvertisementData{Protocol: overlay.ProtocolSLAP, TopicOrServiceName: "ls_payments"}
+	ad := func(protocol overlay.Protocol, topicOrService, domain string) *oa.Advertisement {
+		return &oa.Advertisement{Protocol: protocol, IdentityKey: testIdentityKey, Domain: domain, TopicOrService: topicOrService}
+	}
+
+	current := ad(overlay.ProtocolSHIP, "tm_payments", "https://service.example.com")
+	duplicate := ad(overlay.ProtocolSHIP, "tm_payments", testAdvertisableURI)
+	stale := ad(overlay.ProtocolSHIP, "tm_payments", "https://old.example.com/")
+	undesired := ad(overlay.ProtocolSLAP, "ls_retired", testAdvertisableURI)
+	foreign := &oa.Advertisement{Protocol: overlay.ProtocolSHIP, IdentityKey: "03aa", Domain: "https://other.example.com/", TopicOrService: "tm_other"}
+
+	tests := []struct {
+		name     string
+		desired  []*oa.AdvertisementData
+		existing []*oa.Advertisement
+		expected *ReconciliationPlan
+	}{
+		{
+			name:     "Creates missing advertisements",
+			desired:  []*oa.AdvertisementData{shipPayments, slapPayments},
+			expected: &ReconciliationPlan{Create: []*oa.AdvertisementData{shipPayments, slapPayments}},
+		},
+		{
+			name:     "Keeps current advertisements",
+			desired:  []*oa.AdvertisementData{shipPayments},
+			existing: []*oa.Advertisement{current},
+			expected: &ReconciliationPlan{Keep: []*oa.Advertisement{current}},
+		},
+		{
+			name:     "Replaces stale advertisements",
+			desired:  []*oa.AdvertisementData{shipPayments},
+			existing: []*oa.Advertisement{stale},
+			expected: &ReconciliationPlan{
+				Create: []*oa.AdvertisementData{shipPayments},
+				Revoke: []PlannedRevocation{{Advertisement: stale, Reason: RevocationReasonStale}},
+			},
+		},
+		{
+			name:     "Revokes duplicates",
+			desired:  []*oa.AdvertisementData{shipPayments},
+			existing: []*oa.Advertisement{stale, current, duplicate},
+			expected: &ReconciliationPlan{
+				Revoke: []PlannedRevocation{
+					{Advertisement: stale, Reason: RevocationReasonStale},
+					{Advertisement: duplicate, Reason: RevocationReasonDuplicate},
+				},
+				Keep: []*oa.Advertisement{current},
+			},
+		},
+		{
+			name:     "Revokes undesired advertisements",
+			desired:  []*oa.AdvertisementData{},
+			existing: []*oa.Advertisement{undesired},
+			expected: &ReconciliationPlan{Revoke: []PlannedRevocation{{Advertisement: undesired, Reason: RevocationReasonUndesired}}},
+		},
+		{
+			name:     "Ignores duplicate desired entries and foreign advertisements",
+			desired:  []*oa.AdvertisementData{shipPayments, shipPayments},
+			existing: []*oa.Advertisement{foreign},
+			expected: &ReconciliationPlan{Create: []*oa.AdvertisementData{shipPayments}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := planReconciliation(tt.desired, tt.existing, testIdentityKey, testAdvertisableURI)
+			assert.Equal(t, tt.expected, plan)
+			assert.Equal(t, len(tt.expected.Create) == 0 && len(tt.expected.Revoke) == 0, plan.IsEmpty())
+		})
+	}
+}
+
+func TestWalletAdvertiser_Reconcile(t *testing.T) {
+	testWallet := newTestAdvertiserWallet(t)
+	mockRevocationActions(t, testWallet)
+	advertiser := setupAdvertiserWithWallet(t, testWallet)
+
+	staleBEEF, _ := createTestAdvertisementBEEF(t, testWallet, overlay.ProtocolSHIP, "tm_payments", false)
+	undesiredBEEF, _ := createTestAdvertisementBEEF(t, testWallet, overlay.ProtocolSLAP, "ls_retired", false)
+	current := &oa.Advertisement{Protocol: overlay.ProtocolSLAP, IdentityKey: advertiser.identityKey, Domain: testAdvertisableURI, TopicOrService: "ls_payments"}
+	stale := &oa.Advertisement{Protocol: overlay.ProtocolSHIP, IdentityKey: advertiser.identityKey, Domain: "https://old.example.com/", TopicOrService: "tm_payments", Beef: staleBEEF}
+	undesired := &oa.Advertisement{Protocol: overlay.ProtocolSLAP, IdentityKey: advertiser.identityKey, Domain: testAdvertisableURI, TopicOrService: "ls_retired", Beef: undesiredBEEF}
+	finder := &reconcileFinder{advertisements: []*oa.Advertisement{current, stale, undesired}}
+	advertiser.Finder = finder
+
+	desired := []*oa.AdvertisementData{
+		{Protocol: overlay.ProtocolSHIP, TopicOrServiceName: "tm_payments"},
+		{Protocol: overlay.ProtocolSLAP, TopicOrServiceName: "ls_payments"},
+	}
+
+	t.Run("dry run only plans", func(t *testing.T) {
+		plan, err := advertiser.PlanReconciliation(desired)
+		require.NoError(t, err)
+		assert.Equal(t, []*oa.AdvertisementData{desired[0]}, plan.Create)
+		assert.Equal(t, []PlannedRevocation{
+			{Advertisement: stale, Reason: RevocationReasonStale},
+			{Advertisement: undesired, Reason: RevocationReasonUndesired},
+		}, plan.Revoke)
+		assert.Equal(t, []*oa.Advertisement{current}, plan.Keep)
+		assert.Empty(t, finder.created)
+	})
+
+	t.Run("applies the plan", func(t *testing.T) {
+		result, err := advertiser.Reconcile(desired)
+		require.NoError(t, err)
+		assert.Equal(t, []*oa.AdvertisementData{desired[0]}, finder.created)
+
+		require.NotNil(t, result.Creations)
+		assert.Equal(t, []byte("created"), result.Creations.Beef)
+
+		require.NotNil(t, result.Revocations)
+		assert.Equal(t, []string{"tm_ship", "tm_slap"}, result.Revocations.Topics)
+		assert.Len(t, result.Plan.Revoke, 2)
+	})
+}
+
+func TestWalletAdvertiser_Reconcile_NothingToDo(t *testing.T) {
+	testWallet := newTestAdvertiserWallet(t)
+	testWallet.OnCreateAction().Do(func(context.Context, wallet.CreateActionArgs, string) (*wallet.CreateActionResult, error) {
+		t.Fatal("reconciliation with nothing to do must not create an action")
+		return nil, nil
+	})
+	advertiser := setupAdvertiserWithWallet(t, testWallet)
+	advertiser.Finder = &reconcileFinder{advertisements: []*oa.Advertisement{
+		{Protocol: overlay.ProtocolSHIP, IdentityKey: advertiser.identityKey, Domain: testAdvertisableURI, TopicOrService: "tm_payments"},
+	}}
+
+	result, err := advertiser.Reconcile([]*oa.AdvertisementData{{Protocol: overlay.ProtocolSHIP, TopicOrServiceName: "tm_payments"}})
+	require.NoError(t, err)
+	assert.True(t, result.Plan.IsEmpty())
+	assert.Nil(t, result.Creations)
+	assert.Nil(t, result.Revocations)
+}
+
+func TestWalletAdvertiser_Reconcile_Errors(t *testing.T) {
+	t.Run("requires initialization", func(t *testing.T) {
+		advertiser, err := NewWalletAdvertiserWithOptions(context.Background(), "main",
+			"0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+			"https://storage.example.com", testAdvertisableURI, WithWallet(newTestAdvertiserWallet(t)))
+		require.NoError(t, err)
+
+		_, err = advertiser.Reconcile(nil)
+		require.ErrorIs(t, err, errNotInitializedForReconcile)
+	})
+
+	t.Run("validates desired advertisements", func(t *testing.T) {
+		advertiser := setupInitializedAdvertiser(t)
+		advertiser.Finder = &reconcileFinder{}
+
+		_, err := advertiser.PlanReconciliation([]*oa.AdvertisementData{{Protocol: "INVALID", TopicOrServiceName: "tm_payments"}})
+		require.ErrorIs(t, err, errUnsupportedProtocol)
+	})
+
+	t.Run("aborts when the advertisement lookup fails", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		testWallet := newTestAdvertiserWallet(t)
+		testWallet.OnCreateAction().Do(func(context.Context, wallet.CreateActionArgs, string) (*wallet.CreateActionResult, error) {
+			t.Fatal("reconciliation must not create advertisements when the lookup fails")
+			return nil, nil
+		})
+		advertiser, err := NewWalletAdvertiserWithOptions(context.Background(), "main", "",
+			"https://storage.example.com", testAdvertisableURI, WithWallet(testWallet),
+			WithLookupResolverConfig(&types.LookupResolverConfig{HTTPSEndpoint: stringPtr(server.URL)}))
+		require.NoError(t, err)
+		advertiser.SetSkipStorageValidation(true)
+		require.NoError(t, advertiser.Init())
+		desired := []*oa.AdvertisementData{{Protocol: overlay.ProtocolSHIP, TopicOrServiceName: "tm_payments"}}
+
+		plan, err := advertiser.PlanReconciliation(desired)
+		require.ErrorIs(t, err, errAdvertisementLookupFailed)
+		assert.Nil(t, plan)
+
+		result, err := advertiser.Reconcile(desired)
+		require.ErrorIs(t, err, errAdvertisementLookupFailed)
+		assert.Nil(t, result)
+	})
+
+	t.Run("keeps the creations when the revocations fail", func(t *testing.T) {
+		testWallet := newTestAdvertiserWallet(t)
+		testWallet.OnCreateAction().ReturnError(errTestWallet)
+		advertiser := setupAdvertiserWithWallet(t, testWallet)
+
+		staleBEEF, _ := createTestAdvertisementBEEF(t, testWallet, overlay.ProtocolSHIP, "tm_payments", false)
+		finder := &reconcileFinder{advertisements: []*oa.Advertisement{
+			{Protocol: overlay.ProtocolSHIP, IdentityKey: advertiser.identityKey, Domain: "https://old.example.com/", TopicOrService: "tm_payments", Beef: staleBEEF},
+		}}
+		advertiser.Finder = finder
+
+		result, err := advertiser.Reconcile([]*oa.AdvertisementData{{Protocol: overlay.ProtocolSHIP, TopicOrServiceName: "tm_payments"}})
+		require.ErrorIs(t, err, errTestWallet)
+		require.NotNil(t, result)
+		assert.Len(t, finder.created, 1)
+		require.NotNil(t, result.Creations)
+		assert.Equal(t, []byte("created"), result.Creations.Beef)
+		assert.Nil(t, result.Revocations)
+	})
+}
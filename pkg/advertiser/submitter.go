@@ -0,0 +1,246 @@
+package advertiser
+
+// Submission of tagged BEEF to the overlay hosts interested in its topics.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/utils"
+	"github.com/bsv-blockchain/go-sdk/overlay"
+	"github.com/bsv-blockchain/go-sdk/overlay/lookup"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/bsv-blockchain/go-sdk/util"
+)
+
+// Static error variables for err113 compliance
+var (
+	errNoSubmissionTopics   = errors.New("tagged BEEF must have at least one topic to submit")
+	errNoInterestedHosts    = errors.New("no hosts are interested in the topics")
+	errHostSubmissionFailed = errors.New("host rejected the submission")
+	errAllHostsRejected     = errors.New("submission failed on every interested host")
+)
+
+// SubmitFacilitator sends tagged BEEF to an overlay host and returns its STEAK
+// (Submitted Transaction Execution AcKnowledgment).
+type SubmitFacilitator interface {
+	Submit(ctx context.Context, host string, taggedBEEF overlay.TaggedBEEF) (overlay.Steak, error)
+}
+
+// HTTPSubmitFacilitator submits tagged BEEF with a POST to the /submit endpoint of overlay hosts,
+// carrying the topics in the X-Topics header.
+type HTTPSubmitFacilitator struct {
+	Client util.HTTPClient
+}
+
+// Submit sends tagged BEEF to a host and decodes the STEAK it answers with
+func (f *HTTPSubmitFacilitator) Submit(ctx context.Context, host string, taggedBEEF overlay.TaggedBEEF) (overlay.Steak, error) {
+	topics, err := json.Marshal(taggedBEEF.Topics)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal topics: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(host, "/")+"/submit", bytes.NewReader(taggedBEEF.Beef))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create submit request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("X-Topics", string(topics))
+
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit to host: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("%w: %d %s", errHostSubmissionFailed, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var steak overlay.Steak
+	if err := json.NewDecoder(resp.Body).Decode(&steak); err != nil {
+		return nil, fmt.Errorf("failed to decode STEAK: %w", err)
+	}
+	return steak, nil
+}
+
+// HostSubmission is the outcome of submitting tagged BEEF to one overlay host
+type HostSubmission struct {
+	// Host is the URL of the overlay host
+	Host string
+	// Topics are the topics of the submission the host advertises through SHIP
+	Topics []string
+	// Steak is the acknowledgment of the host, nil if the submission failed
+	Steak overlay.Steak
+	// Err is the error of the submission, nil if it succeeded
+	Err error
+}
+
+// AdmittedOutputs returns the outputs the host admitted into a topic
+func (h *HostSubmission) AdmittedOutputs(topic string) []uint32 {
+	if admittance, ok := h.Steak[topic]; ok && admittance != nil {
+		return admittance.OutputsToAdmit
+	}
+	return nil
+}
+
+// SubmissionResult reports the outcome of a submission for each interested host
+type SubmissionResult struct {
+	Hosts []*HostSubmission
+}
+
+// AdmittedOutputs returns, by host and topic, the outputs admitted by the hosts that accepted the
+// submission. Hosts that admitted nothing are left out.
+func (r *SubmissionResult) AdmittedOutputs() map[string]map[string][]uint32 {
+	admitted := make(map[string]map[string][]uint32)
+	for _, host := range r.Hosts {
+		for topic := range host.Steak {
+			outputs := host.AdmittedOutputs(topic)
+			if len(outputs) == 0 {
+				continue
+			}
+			if admitted[host.Host] == nil {
+				admitted[host.Host] = make(map[string][]uint32)
+			}
+			admitted[host.Host][topic] = outputs
+		}
+	}
+	return admitted
+}
+
+// Failed returns the submissions that failed
+func (r *SubmissionResult) Failed() []*HostSubmission {
+	var failed []*HostSubmission
+	for _, host := range r.Hosts {
+		if host.Err != nil {
+			failed = append(failed, host)
+		}
+	}
+	return failed
+}
+
+// Submitter delivers tagged BEEF, such as advertisement creations and revocations, to the overlay
+// hosts advertising its topics through SHIP. It implements Broadcaster.
+type Submitter struct {
+	resolver    *lookup.LookupResolver
+	facilitator SubmitFacilitator
+}
+
+// Compile-time verification that Submitter implements Broadcaster
+var _ Broadcaster = (*Submitter)(nil)
+
+// NewSubmitter creates a Submitter finding hosts with the lookup resolver and sending tagged BEEF
+// through the facilitator. A nil facilitator defaults to an HTTPSubmitFacilitator using the default
+// HTTP client.
+func NewSubmitter(resolver *lookup.LookupResolver, facilitator SubmitFacilitator) *Submitter {
+	if facilitator == nil {
+		facilitator = &HTTPSubmitFacilitator{Client: http.DefaultClient}
+	}
+	return &Submitter{
+		resolver:    resolver,
+		facilitator: facilitator,
+	}
+}
+
+// NewSubmitter creates a Submitter finding hosts with the lookup resolver configuration of the
+// advertiser, and sending tagged BEEF through the facilitator, or over plain HTTP if nil.
+func (w *WalletAdvertiser) NewSubmitter(facilitator SubmitFacilitator) *Submitter {
+	return NewSubmitter(w.newLookupResolver(), facilitator)
+}
+
+// Submit sends tagged BEEF to every host advertising at least one of its topics, and collects the
+// STEAK of each host. It fails if no host is interested in the topics or if every host fails, in
+// which case the result still reports the error of each host.
+func (s *Submitter) Submit(ctx context.Context, taggedBEEF overlay.TaggedBEEF) (*SubmissionResult, error) {
+	if len(taggedBEEF.Topics) == 0 {
+		return nil, errNoSubmissionTopics
+	}
+
+	hosts, err := s.findInterestedHosts(ctx, taggedBEEF.Topics)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find interested hosts: %w", err)
+	}
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("%w: %s", errNoInterestedHosts, strings.Join(taggedBEEF.Topics, ", "))
+	}
+
+	result := &SubmissionResult{Hosts: make([]*HostSubmission, 0, len(hosts))}
+	hostNames := make([]string, 0, len(hosts))
+	for host := range hosts {
+		hostNames = append(hostNames, host)
+	}
+	slices.Sort(hostNames)
+	for _, host := range hostNames {
+		result.Hosts = append(result.Hosts, &HostSubmission{Host: host, Topics: hosts[host]})
+	}
+
+	var wg sync.WaitGroup
+	for _, submission := range result.Hosts {
+		wg.Add(1)
+		go func(submission *HostSubmission) {
+			defer wg.Done()
+			submission.Steak, submission.Err = s.facilitator.Submit(ctx, submission.Host, taggedBEEF)
+		}(submission)
+	}
+	wg.Wait()
+
+	if failed := result.Failed(); len(failed) == len(result.Hosts) {
+		return result, fmt.Errorf("%w: %w", errAllHostsRejected, failed[0].Err)
+	}
+	return result, nil
+}
+
+// Broadcast submits tagged BEEF to the interested hosts, succeeding if at least one host accepts it
+func (s *Submitter) Broadcast(ctx context.Context, taggedBEEF overlay.TaggedBEEF) error {
+	_, err := s.Submit(ctx, taggedBEEF)
+	return err
+}
+
+// findInterestedHosts looks up the SHIP advertisements of the topics and returns the advertised hosts
+// along with the topics each of them advertises. Advertisements that are not valid SHIP tokens, such as
+// unsigned ones or ones advertising a URI that is not advertisable, are ignored.
+func (s *Submitter) findInterestedHosts(ctx context.Context, topics []string) (map[string][]string, error) {
+	query, err := json.Marshal(map[string][]string{"topics": topics})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal query data: %w", err)
+	}
+
+	answer, err := s.resolver.Query(ctx, &lookup.LookupQuestion{Service: "ls_ship", Query: query})
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make(map[string][]string)
+	if answer.Type != lookup.AnswerTypeOutputList {
+		return hosts, nil
+	}
+	for _, output := range answer.Outputs {
+		tx, err := transaction.NewTransactionFromBEEF(output.Beef)
+		if err != nil || int(output.OutputIndex) >= len(tx.Outputs) {
+			continue
+		}
+		ad, err := utils.ParseAdvertisementToken(ctx, tx.Outputs[output.OutputIndex].LockingScript, overlay.ProtocolSHIP)
+		if err != nil || !slices.Contains(topics, ad.TopicOrService) {
+			continue
+		}
+		if !slices.Contains(hosts[ad.Domain], ad.TopicOrService) {
+			hosts[ad.Domain] = append(hosts[ad.Domain], ad.TopicOrService)
+		}
+	}
+	return hosts, nil
+}
@@ -0,0 +1,222 @@
+package advertiser
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/types"
+	"github.com/bsv-blockchain/go-sdk/overlay"
+	admintoken "github.com/bsv-blockchain/go-sdk/overlay/admin-token"
+	"github.com/bsv-blockchain/go-sdk/overlay/lookup"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/bsv-blockchain/go-sdk/transaction/template/pushdrop"
+	"github.com/bsv-blockchain/go-sdk/wallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSubmitHost starts an overlay host stand-in answering submissions with the STEAK, or with an
+// internal server error if it is nil, and records the submitted tagged BEEF.
+func newSubmitHost(t *testing.T, steak overlay.Steak) (*httptest.Server, *[]overlay.TaggedBEEF) {
+	var submitted []overlay.TaggedBEEF
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/submit", r.URL.Path)
+		assert.Equal(t, "application/octet-stream", r.Header.Get("Content-Type"))
+
+		var topics []string
+		assert.NoError(t, json.Unmarshal([]byte(r.Header.Get("X-Topics")), &topics))
+		beef, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		submitted = append(submitted, overlay.TaggedBEEF{Beef: beef, Topics: topics})
+
+		if steak == nil {
+			http.Error(w, "topic manager unavailable", http.StatusInternalServerError)
+			return
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(steak))
+	}))
+	t.Cleanup(server.Close)
+	return server, &submitted
+}
+
+// newSHIPLookupServer starts a lookup service stand-in answering ls_ship queries with the advertisements
+func newSHIPLookupServer(t *testing.T, ads ...[]byte) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/lookup", r.URL.Path)
+		var question lookup.LookupQuestion
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&question))
+		assert.Equal(t, "ls_ship", question.Service)
+
+		outputs := make([]*lookup.OutputListItem, len(ads))
+		for i, ad := range ads {
+			outputs[i] = &lookup.OutputListItem{Beef: ad, OutputIndex: 0}
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(lookup.LookupAnswer{Type: lookup.AnswerTypeOutputList, Outputs: outputs}))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// routingFacilitator submits over HTTP to the test servers standing in for the advertised hosts
+type routingFacilitator struct {
+	routes map[string]string
+	http   HTTPSubmitFacilitator
+}
+
+func (f *routingFacilitator) Submit(ctx context.Context, host string, taggedBEEF overlay.TaggedBEEF) (overlay.Steak, error) {
+	return f.http.Submit(ctx, f.routes[host], taggedBEEF)
+}
+
+// createUnsignedSHIPAdvertisementBEEF creates a SHIP advertisement carrying no signature field, which
+// decodes as an admin token but is not a valid advertisement token
+func createUnsignedSHIPAdvertisementBEEF(t *testing.T, w wallet.Interface, domain, topic string) []byte {
+	t.Helper()
+
+	identityKey, err := w.GetPublicKey(context.Background(), wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+	walletProtocol, err := advertisementProtocol(overlay.ProtocolSHIP)
+	require.NoError(t, err)
+
+	pd := pushdrop.PushDrop{Wallet: w}
+	lockingScript, err := pd.Lock(
+		context.Background(),
+		[][]byte{[]byte(overlay.ProtocolSHIP), identityKey.PublicKey.Compressed(), []byte(domain), []byte(topic)},
+		walletProtocol,
+		adTokenKeyID,
+		wallet.Counterparty{Type: wallet.CounterpartyTypeAnyone},
+		true,
+		false,
+		pushdrop.LockBefore,
+	)
+	require.NoError(t, err)
+	require.NotNil(t, admintoken.Decode(lockingScript))
+
+	funding := transaction.NewTransaction()
+	funding.AddOutput(&transaction.TransactionOutput{Satoshis: 1000, LockingScript: testChangeScript(t)})
+	adTx := transaction.NewTransaction()
+	adTx.AddInput(&transaction.TransactionInput{
+		SourceTXID:        funding.TxID(),
+		SourceTransaction: funding,
+		SequenceNumber:    transaction.DefaultSequenceNumber,
+		UnlockingScript:   &script.Script{},
+	})
+	adTx.AddOutput(&transaction.TransactionOutput{Satoshis: AdTokenValue, LockingScript: lockingScript})
+	beef, err := adTx.BEEF()
+	require.NoError(t, err)
+	return beef
+}
+
+func TestSubmitter_Submit(t *testing.T) {
+	testWallet := newTestAdvertiserWallet(t)
+	taggedBEEF := overlay.TaggedBEEF{Beef: []byte("advertisements"), Topics: []string{"tm_ship", "tm_slap"}}
+
+	admitting, admittingSubmissions := newSubmitHost(t, overlay.Steak{
+		"tm_ship": {OutputsToAdmit: []uint32{0}},
+		"tm_slap": {OutputsToAdmit: []uint32{}},
+	})
+	failing, failingSubmissions := newSubmitHost(t, nil)
+	uninterested, uninterestedSubmissions := newSubmitHost(t, overlay.Steak{})
+	invalid, invalidSubmissions := newSubmitHost(t, overlay.Steak{})
+
+	const (
+		admittingDomain    = "https://admitting.example.com"
+		failingDomain      = "https://failing.example.com"
+		uninterestedDomain = "https://uninterested.example.com"
+		unsignedDomain     = "https://unsigned.example.com"
+	)
+	facilitator := &routingFacilitator{routes: map[string]string{
+		admittingDomain:    admitting.URL,
+		failingDomain:      failing.URL,
+		uninterestedDomain: uninterested.URL,
+		unsignedDomain:     invalid.URL,
+		invalid.URL:        invalid.URL,
+	}}
+
+	shipAd, _ := createTestAdvertisementBEEFForDomain(t, testWallet, overlay.ProtocolSHIP, admittingDomain, "tm_ship", false)
+	failingAd, _ := createTestAdvertisementBEEFForDomain(t, testWallet, overlay.ProtocolSHIP, failingDomain, "tm_slap", false)
+	otherTopicAd, _ := createTestAdvertisementBEEFForDomain(t, testWallet, overlay.ProtocolSHIP, uninterestedDomain, "tm_payments", false)
+	slapProtocolAd, _ := createTestAdvertisementBEEFForDomain(t, testWallet, overlay.ProtocolSLAP, uninterestedDomain, "ls_ship", false)
+	unadvertisableAd, _ := createTestAdvertisementBEEFForDomain(t, testWallet, overlay.ProtocolSHIP, invalid.URL, "tm_ship", false)
+	unsignedAd := createUnsignedSHIPAdvertisementBEEF(t, testWallet, unsignedDomain, "tm_ship")
+	lookupServer := newSHIPLookupServer(t, shipAd, failingAd, otherTopicAd, slapProtocolAd, unadvertisableAd, unsignedAd, []byte("not a BEEF"))
+
+	advertiser, err := NewWalletAdvertiser(
+		"main",
+		"0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+		"https://storage.example.com",
+		testAdvertisableURI,
+		&types.LookupResolverConfig{HTTPSEndpoint: stringPtr(lookupServer.URL)},
+	)
+	require.NoError(t, err)
+	submitter := advertiser.NewSubmitter(facilitator)
+
+	t.Run("submits to the interested hosts", func(t *testing.T) {
+		result, err := submitter.Submit(context.Background(), taggedBEEF)
+		require.NoError(t, err)
+
+		require.Len(t, result.Hosts, 2)
+		assert.Equal(t, admittingDomain, result.Hosts[0].Host)
+		assert.Equal(t, failingDomain, result.Hosts[1].Host)
+
+		assert.Equal(t, map[string]map[string][]uint32{admittingDomain: {"tm_ship": {0}}}, result.AdmittedOutputs())
+		failed := result.Failed()
+		require.Len(t, failed, 1)
+		assert.Equal(t, failingDomain, failed[0].Host)
+		assert.Equal(t, []string{"tm_slap"}, failed[0].Topics)
+		require.ErrorIs(t, failed[0].Err, errHostSubmissionFailed)
+		assert.Contains(t, failed[0].Err.Error(), "topic manager unavailable")
+
+		assert.Equal(t, []overlay.TaggedBEEF{taggedBEEF}, *admittingSubmissions)
+		assert.Equal(t, []overlay.TaggedBEEF{taggedBEEF}, *failingSubmissions)
+		assert.Empty(t, *uninterestedSubmissions)
+		assert.Empty(t, *invalidSubmissions, "hosts of invalid advertisement tokens must be skipped")
+	})
+
+	t.Run("fails when every host fails", func(t *testing.T) {
+		result, err := submitter.Submit(context.Background(), overlay.TaggedBEEF{Beef: []byte("revocations"), Topics: []string{"tm_slap"}})
+		require.ErrorIs(t, err, errAllHostsRejected)
+		require.ErrorIs(t, err, errHostSubmissionFailed)
+		require.NotNil(t, result)
+		require.Len(t, result.Hosts, 1)
+		assert.Equal(t, failingDomain, result.Hosts[0].Host)
+		assert.Empty(t, result.AdmittedOutputs())
+	})
+
+	t.Run("fails without interested hosts", func(t *testing.T) {
+		_, err := submitter.Submit(context.Background(), overlay.TaggedBEEF{Beef: []byte("ads"), Topics: []string{"tm_unknown"}})
+		require.ErrorIs(t, err, errNoInterestedHosts)
+	})
+
+	t.Run("requires topics", func(t *testing.T) {
+		_, err := submitter.Submit(context.Background(), overlay.TaggedBEEF{Beef: []byte("ads")})
+		require.ErrorIs(t, err, errNoSubmissionTopics)
+	})
+
+	t.Run("broadcasts for the manager", func(t *testing.T) {
+		require.NoError(t, submitter.Broadcast(context.Background(), taggedBEEF))
+		require.ErrorIs(t, submitter.Broadcast(context.Background(), overlay.TaggedBEEF{Beef: []byte("ads"), Topics: []string{"tm_unknown"}}), errNoInterestedHosts)
+	})
+}
+
+func TestWalletAdvertiser_NewSubmitter(t *testing.T) {
+	advertiser, err := NewWalletAdvertiserWithOptions(context.Background(), "main", "",
+		"https://storage.example.com", testAdvertisableURI, WithWallet(newTestAdvertiserWallet(t)))
+	require.NoError(t, err)
+
+	t.Run("defaults to plain HTTP carrying the topics in X-Topics", func(t *testing.T) {
+		facilitator, ok := advertiser.NewSubmitter(nil).facilitator.(*HTTPSubmitFacilitator)
+		require.True(t, ok)
+		assert.Same(t, http.DefaultClient, facilitator.Client)
+	})
+
+	t.Run("keeps the given facilitator", func(t *testing.T) {
+		facilitator := &HTTPSubmitFacilitator{}
+		assert.Same(t, facilitator, advertiser.NewSubmitter(facilitator).facilitator)
+	})
+}
@@ -12,6 +12,7 @@ import (
 	"log/slog"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -19,6 +20,7 @@ import (
 	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/utils"
 	oa "github.com/bsv-blockchain/go-overlay-services/pkg/core/advertiser"
 	authhttp "github.com/bsv-blockchain/go-sdk/auth/clients/authhttp"
+	"github.com/bsv-blockchain/go-sdk/chainhash"
 	"github.com/bsv-blockchain/go-sdk/overlay"
 	"github.com/bsv-blockchain/go-sdk/overlay/lookup"
 	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
@@ -37,6 +39,25 @@ import (
 // AdTokenValue is the default token value used for advertisements.
 const AdTokenValue = 1
 
+// Constants for the PushDrop tokens carrying advertisements
+const (
+	// adTokenKeyID is the key ID the advertisement tokens are locked with
+	adTokenKeyID = utils.AdvertisementTokenKeyID
+	// adTokenUnlockingScriptLength is the length of a PushDrop unlocking script: a DER signature
+	// of at most 72 bytes including the sighash flag, preceded by its push opcode
+	adTokenUnlockingScriptLength = 73
+)
+
+// Defaults for advertisement lookups, used when the LookupResolverConfig does not set them
+const (
+	// defaultLookupTimeout bounds each lookup attempt
+	defaultLookupTimeout = 30 * time.Second
+	// defaultLookupRetryBackoff is the delay before the first retry; it doubles on each further retry
+	defaultLookupRetryBackoff = 250 * time.Millisecond
+	// maxLookupRetryBackoff caps the delay between retries
+	maxLookupRetryBackoff = 5 * time.Second
+)
+
 // Static error variables for err113 compliance
 var (
 	errChainRequired                 = errors.New("chain parameter is required and cannot be empty")
@@ -55,18 +76,24 @@ var (
 	errInvalidTopicOrServiceName     = errors.New("invalid topic or service name")
 	errUnsupportedProtocol           = errors.New("unsupported protocol: must be 'SHIP' or 'SLAP'")
 	errMissingBeefData               = errors.New("is missing BEEF data required for revocation")
-	errMissingOutputIndex            = errors.New("is missing output index required for revocation")
+	errAdvertisementOutputMissing    = errors.New("does not contain the advertised output")
+	errInvalidAdvertisementBEEF      = errors.New("invalid advertisement BEEF")
+	errAdvertisementNotCreated       = errors.New("wallet did not return the advertisement transaction")
+	errRevocationNotSignable         = errors.New("wallet did not return a signable revocation transaction")
+	errRevocationInputMissing        = errors.New("revocation transaction does not spend the advertisement")
 	errOutputScriptEmpty             = errors.New("output script cannot be empty")
-	errInvalidPushDropScript         = errors.New("failed to decode PushDrop script")
-	errInvalidPushDropFields         = errors.New("invalid PushDrop result: expected at least 4 fields")
-	errUnsupportedProtocolID         = errors.New("unsupported protocol identifier")
 	errPrivateKeyAllZeros            = errors.New("private key cannot be all zeros")
 	errPrivateKeyInsufficientLength  = errors.New("private key must be exactly 32 bytes (64 hex characters)")
 	errPrivateKeyInsufficientEntropy = errors.New("private key appears to have insufficient entropy")
-	errBEEFTooShort                  = errors.New("BEEF data too short")
-	errTransactionTooShort           = errors.New("transaction data too short")
 	errTopicNameEmpty                = errors.New("topicOrServiceName cannot be empty")
 	errStorageServerError            = errors.New("storage service returned server error")
+	errWalletStorageConflict         = errors.New("wallet option cannot be combined with storage provider or DB config options")
+	errStorageDBConfigConflict       = errors.New("storage provider option cannot be combined with DB config option")
+	errWalletIdentityKeyMismatch     = errors.New("privateKey does not match the identity key of the wallet")
+	errLookupEndpointInvalid         = errors.New("lookup resolver httpsEndpoint must be a valid HTTP or HTTPS URL")
+	errLookupMaxRetriesInvalid       = errors.New("lookup resolver maxRetries cannot be negative")
+	errLookupTimeoutInvalid          = errors.New("lookup resolver timeoutMS must be positive")
+	errAdvertisementLookupFailed     = errors.New("advertisement lookup failed")
 )
 
 // Finder defines the interface for finding and creating advertisements.
@@ -81,7 +108,8 @@ type Finder interface {
 type WalletAdvertiser struct {
 	// chain specifies the blockchain network (e.g., "main", "test")
 	chain string
-	// privateKey is the private key used for signing advertisements (hex format)
+	// privateKey is the private key used for signing advertisements (hex format), empty if the
+	// advertiser uses an injected wallet without one
 	privateKey string
 	// storageURL is the URL for storing advertisement data
 	storageURL string
@@ -89,6 +117,8 @@ type WalletAdvertiser struct {
 	advertisableURI string
 	// lookupResolverConfig contains configuration for lookup resolution
 	lookupResolverConfig *types.LookupResolverConfig
+	// lookupRetryBackoff is the delay before the first lookup retry
+	lookupRetryBackoff time.Duration
 	// initialized tracks whether the advertiser has been initialized
 	initialized bool
 	// skipStorageValidation allows skipping storage connectivity validation (for testing)
@@ -99,22 +129,55 @@ type WalletAdvertiser struct {
 	authFetch *authhttp.AuthFetch
 	// wallet provides the wallet interface for authentication
 	wallet wallet.Interface
-	// identityKey is the hex-encoded identity key derived from the private key
+	// identityKey is the hex-encoded identity key of the wallet
 	identityKey string
 	// Finder allows mocking
 	Finder Finder
 }
 
-// Compile-time verification that WalletAdvertiser implements oa.Advertiser
-var _ oa.Advertiser = (*WalletAdvertiser)(nil)
+// ContextAdvertiser is the context-first counterpart of oa.Advertiser. Cancelling the context aborts
+// the wallet, storage and lookup calls made on behalf of an operation.
+type ContextAdvertiser interface {
+	InitContext(ctx context.Context) error
+	CreateAdvertisementsContext(ctx context.Context, adsData []*oa.AdvertisementData) (overlay.TaggedBEEF, error)
+	FindAllAdvertisementsContext(ctx context.Context, protocol overlay.Protocol) ([]*oa.Advertisement, error)
+	RevokeAdvertisementsContext(ctx context.Context, advertisements []*oa.Advertisement) (overlay.TaggedBEEF, error)
+	ParseAdvertisement(outputScript *script.Script) (*oa.Advertisement, error)
+}
+
+// Compile-time verification that WalletAdvertiser implements oa.Advertiser and ContextAdvertiser
+var (
+	_ oa.Advertiser     = (*WalletAdvertiser)(nil)
+	_ ContextAdvertiser = (*WalletAdvertiser)(nil)
+)
 
 // NewWalletAdvertiser creates a new WalletAdvertiser instance.
+// It is equivalent to NewWalletAdvertiserContext with a background context.
 func NewWalletAdvertiser(chain, privateKey, storageURL, advertisableURI string, lookupResolverConfig *types.LookupResolverConfig) (*WalletAdvertiser, error) {
+	return NewWalletAdvertiserContext(context.Background(), chain, privateKey, storageURL, advertisableURI, lookupResolverConfig)
+}
+
+// NewWalletAdvertiserContext creates a new WalletAdvertiser instance backed by a toolbox wallet with
+// the default storage. The context bounds the migration of the wallet storage.
+func NewWalletAdvertiserContext(ctx context.Context, chain, privateKey, storageURL, advertisableURI string, lookupResolverConfig *types.LookupResolverConfig) (*WalletAdvertiser, error) {
+	return NewWalletAdvertiserWithOptions(ctx, chain, privateKey, storageURL, advertisableURI, WithLookupResolverConfig(lookupResolverConfig))
+}
+
+// NewWalletAdvertiserWithOptions creates a new WalletAdvertiser instance with the dependencies set by
+// the options. Unless WithWallet is given, a toolbox wallet is created on the configured storage
+// provider, or on a GORM provider built from the go-wallet-toolbox defaults, and the context bounds
+// the migration of that storage. The private key is optional with WithWallet.
+func NewWalletAdvertiserWithOptions(ctx context.Context, chain, privateKey, storageURL, advertisableURI string, opts ...Option) (*WalletAdvertiser, error) {
+	options := &advertiserOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
 	// Validate required parameters
 	if strings.TrimSpace(chain) == "" {
 		return nil, errChainRequired
 	}
-	if strings.TrimSpace(privateKey) == "" {
+	if strings.TrimSpace(privateKey) == "" && options.wallet == nil {
 		return nil, errPrivateKeyRequired
 	}
 	if strings.TrimSpace(storageURL) == "" {
@@ -139,6 +202,49 @@ func NewWalletAdvertiser(chain, privateKey, storageURL, advertisableURI string,
 		return nil, fmt.Errorf("%w: %s", errStorageURLInvalid, storageURL)
 	}
 
+	// Validate lookup resolver configuration
+	if err := validateLookupResolverConfig(options.lookupResolverConfig); err != nil {
+		return nil, err
+	}
+
+	// Validate that the options do not configure the wallet storage twice
+	if options.wallet != nil && (options.storageProvider != nil || options.dbConfig != nil) {
+		return nil, errWalletStorageConflict
+	}
+	if options.storageProvider != nil && options.dbConfig != nil {
+		return nil, errStorageDBConfigConflict
+	}
+
+	wlt := options.wallet
+	if wlt == nil {
+		var err error
+		if wlt, err = newToolboxWallet(ctx, chain, privateKey, options); err != nil {
+			return nil, err
+		}
+	}
+
+	// Create AuthFetch client
+	authClient := options.authFetch
+	if authClient == nil {
+		authClient = authhttp.New(wlt)
+	}
+
+	return &WalletAdvertiser{
+		chain:                chain,
+		privateKey:           privateKey,
+		storageURL:           storageURL,
+		advertisableURI:      advertisableURI,
+		lookupResolverConfig: options.lookupResolverConfig,
+		lookupRetryBackoff:   defaultLookupRetryBackoff,
+		initialized:          false,
+		authFetch:            authClient,
+		wallet:               wlt,
+	}, nil
+}
+
+// newToolboxWallet creates a toolbox wallet for the private key on the storage provider of the
+// options, or on a GORM provider built from the defaults, and migrates the storage.
+func newToolboxWallet(ctx context.Context, chain, privateKey string, options *advertiserOptions) (*toolboxWallet.Wallet, error) {
 	// Create private key object for wallet initialization
 	privKey, err := ec.PrivateKeyFromHex(privateKey)
 	if err != nil {
@@ -150,17 +256,25 @@ func NewWalletAdvertiser(chain, privateKey, storageURL, advertisableURI string,
 	cfg.ServerPrivateKey = privateKey
 	activeServices := services.New(slog.Default(), cfg.Services)
 
-	// Create storage manager for the wallet
-	storageManager, errStorage := storage.NewGORMProvider(
-		cfg.BSVNetwork,
-		activeServices,
-		storage.WithDBConfig(cfg.DBConfig),
-		storage.WithFeeModel(cfg.FeeModel),
-		storage.WithCommission(cfg.Commission),
-		storage.WithSynchronizeTxStatuses(cfg.SynchronizeTxStatuses),
-	)
-	if errStorage != nil {
-		return nil, fmt.Errorf("failed to create storage manager: %w", errStorage)
+	if options.dbConfig != nil {
+		cfg.DBConfig = *options.dbConfig
+	}
+
+	// Create storage manager for the wallet, unless one was provided
+	storageManager := options.storageProvider
+	if storageManager == nil {
+		var errStorage error
+		storageManager, errStorage = storage.NewGORMProvider(
+			cfg.BSVNetwork,
+			activeServices,
+			storage.WithDBConfig(cfg.DBConfig),
+			storage.WithFeeModel(cfg.FeeModel),
+			storage.WithCommission(cfg.Commission),
+			storage.WithSynchronizeTxStatuses(cfg.SynchronizeTxStatuses),
+		)
+		if errStorage != nil {
+			return nil, fmt.Errorf("failed to create storage manager: %w", errStorage)
+		}
 	}
 
 	// Get storage identity key
@@ -170,7 +284,7 @@ func NewWalletAdvertiser(chain, privateKey, storageURL, advertisableURI string,
 	}
 
 	// Migrate storage
-	if _, errMigrate := storageManager.Migrate(context.TODO(), "wallet-advertiser", storageIdentityKey); errMigrate != nil {
+	if _, errMigrate := storageManager.Migrate(ctx, "wallet-advertiser", storageIdentityKey); errMigrate != nil {
 		return nil, fmt.Errorf("failed to migrate storage: %w", errMigrate)
 	}
 
@@ -188,19 +302,7 @@ func NewWalletAdvertiser(chain, privateKey, storageURL, advertisableURI string,
 		return nil, fmt.Errorf("failed to create wallet: %w", err)
 	}
 
-	// Create AuthFetch client
-	authClient := authhttp.New(wlt)
-
-	return &WalletAdvertiser{
-		chain:                chain,
-		privateKey:           privateKey,
-		storageURL:           storageURL,
-		advertisableURI:      advertisableURI,
-		lookupResolverConfig: lookupResolverConfig,
-		initialized:          false,
-		authFetch:            authClient,
-		wallet:               wlt,
-	}, nil
+	return wlt, nil
 }
 
 // SetSkipStorageValidation allows skipping storage connectivity validation.
@@ -218,18 +320,25 @@ func (w *WalletAdvertiser) SetTestMode(testMode bool) {
 // Init initializes the advertiser service and sets up any required resources.
 // This method must be called before using any other advertiser functionality.
 func (w *WalletAdvertiser) Init() error {
+	return w.InitContext(context.Background())
+}
+
+// InitContext is Init with a context bounding the storage connectivity check.
+func (w *WalletAdvertiser) InitContext(ctx context.Context) error {
 	if w.initialized {
 		return errAlreadyInitialized
 	}
 
-	// Initialize wallet connection and verify private key
-	if err := w.validateAndInitializePrivateKey(); err != nil {
-		return fmt.Errorf("private key validation failed: %w", err)
+	// Verify the private key, if the wallet was not injected without one
+	if w.privateKey != "" {
+		if err := w.validateAndInitializePrivateKey(); err != nil {
+			return fmt.Errorf("private key validation failed: %w", err)
+		}
 	}
 
 	// Validate storage URL connectivity (unless skipped for testing)
 	if !w.skipStorageValidation {
-		if err := w.validateStorageConnectivity(); err != nil {
+		if err := w.validateStorageConnectivity(ctx); err != nil {
 			return fmt.Errorf("storage connectivity validation failed: %w", err)
 		}
 	}
@@ -239,8 +348,8 @@ func (w *WalletAdvertiser) Init() error {
 		return fmt.Errorf("cryptographic context setup failed: %w", err)
 	}
 
-	// Derive and set the identity key
-	identityKey, err := w.deriveIdentityKey()
+	// Take the identity key from the wallet signing the advertisements
+	identityKey, err := w.walletIdentityKey(ctx)
 	if err != nil {
 		return fmt.Errorf("identity key derivation failed: %w", err)
 	}
@@ -253,6 +362,11 @@ func (w *WalletAdvertiser) Init() error {
 // CreateAdvertisements creates new advertisements and returns them as a tagged BEEF.
 // This method supports both SHIP and SLAP protocol advertisements.
 func (w *WalletAdvertiser) CreateAdvertisements(adsData []*oa.AdvertisementData) (overlay.TaggedBEEF, error) {
+	return w.CreateAdvertisementsContext(context.Background(), adsData)
+}
+
+// CreateAdvertisementsContext is CreateAdvertisements with a context propagated to the wallet.
+func (w *WalletAdvertiser) CreateAdvertisementsContext(ctx context.Context, adsData []*oa.AdvertisementData) (overlay.TaggedBEEF, error) {
 	if !w.initialized {
 		return overlay.TaggedBEEF{}, errNotInitializedForAds
 	}
@@ -261,125 +375,41 @@ func (w *WalletAdvertiser) CreateAdvertisements(adsData []*oa.AdvertisementData)
 		return overlay.TaggedBEEF{}, errNoAdvertisementData
 	}
 
-	// Validate all advertisement data entries
+	// Validate all advertisement data entries, prefixing bare names without modifying the caller's data
+	normalized := make([]*oa.AdvertisementData, len(adsData))
 	for i, adData := range adsData {
-		if err := w.validateAdvertisementData(adData); err != nil {
+		var err error
+		if normalized[i], err = w.normalizeAdvertisementData(adData); err != nil {
 			return overlay.TaggedBEEF{}, fmt.Errorf("invalid advertisement data at index %d: %w", i, err)
 		}
 	}
+	adsData = normalized
 
 	// Use Finder for testing if available
 	if w.Finder != nil {
 		return w.Finder.CreateAdvertisements(adsData, w.identityKey, w.advertisableURI)
 	}
 
-	// Collect topics for the TaggedBEEF
+	// Collect the topics tracking the advertisements for the TaggedBEEF
 	var topics []string
 	for _, adData := range adsData {
-		switch adData.Protocol {
-		case overlay.ProtocolSHIP, overlay.ProtocolSLAP:
-			topics = append(topics, "tm_"+adData.TopicOrServiceName)
-		}
-	}
-
-	privKey, err := ec.PrivateKeyFromHex(w.privateKey)
-	if err != nil {
-		return overlay.TaggedBEEF{}, fmt.Errorf("failed to create private key from hex: %w", err)
-	}
-	logger := slog.Default()
-	cfg := infra.Defaults()
-	cfg.ServerPrivateKey = w.privateKey
-	activeServices := services.New(logger, cfg.Services)
-
-	storageManager, errStorage := storage.NewGORMProvider(
-		cfg.BSVNetwork,
-		activeServices,
-		storage.WithDBConfig(cfg.DBConfig),
-		storage.WithFeeModel(cfg.FeeModel),
-		storage.WithCommission(cfg.Commission),
-		storage.WithSynchronizeTxStatuses(cfg.SynchronizeTxStatuses),
-	)
-	if errStorage != nil {
-		return overlay.TaggedBEEF{}, fmt.Errorf("failed to create storage provider: %w", errStorage)
-	}
-
-	storageIdentityKey, errKey := wdk.IdentityKey(cfg.ServerPrivateKey)
-	if errKey != nil {
-		return overlay.TaggedBEEF{}, fmt.Errorf("failed to create storage identity key: %w", errKey)
-	}
-
-	if _, errMigrate := storageManager.Migrate(context.TODO(), cfg.Name, storageIdentityKey); errMigrate != nil {
-		return overlay.TaggedBEEF{}, fmt.Errorf("failed to migrate storage: %w", errMigrate)
-	}
-
-	wlt, errWallet := toolboxWallet.New(defs.NetworkMainnet, privKey, storageManager)
-	if errWallet != nil {
-		return overlay.TaggedBEEF{}, fmt.Errorf("failed to create wallet: %w", errWallet)
-	}
-	keyDeriver := wallet.NewKeyDeriver(privKey)
-
-	pd := pushdrop.PushDrop{
-		Wallet: wlt,
-	}
-
-	outputs := make([]wallet.CreateActionOutput, 0, len(adsData))
-	for _, ad := range adsData {
-		if !utils.IsValidTopicOrServiceName(ad.TopicOrServiceName) {
-			return overlay.TaggedBEEF{}, fmt.Errorf("%w: %s", errInvalidTopicOrServiceName, ad.TopicOrServiceName)
-		}
-		protocol := wallet.Protocol{SecurityLevel: wallet.SecurityLevelEveryAppAndCounterparty}
-		if protocol.Protocol = string(ad.Protocol.ID()); protocol.Protocol == "" {
-			return overlay.TaggedBEEF{}, fmt.Errorf("%w: %s", errUnsupportedProtocol, ad.Protocol)
+		topic, err := advertisementTopic(adData.Protocol)
+		if err != nil {
+			return overlay.TaggedBEEF{}, err
 		}
-		lockingScript, errLock := pd.Lock(
-			context.TODO(),
-			[][]byte{
-				[]byte(ad.Protocol),
-				keyDeriver.IdentityKey().ToDER(),
-				[]byte(w.advertisableURI),
-				[]byte(ad.TopicOrServiceName),
-			},
-			protocol,
-			"1",
-			wallet.Counterparty{Type: wallet.CounterpartyTypeAnyone},
-			true, // forSelf
-			true, // includeSignature
-			pushdrop.LockBefore,
-		)
-		if errLock != nil {
-			return overlay.TaggedBEEF{}, fmt.Errorf("failed to create locking script: %w", errLock)
+		if !slices.Contains(topics, topic) {
+			topics = append(topics, topic)
 		}
-		outputs = append(outputs, wallet.CreateActionOutput{
-			OutputDescription: fmt.Sprintf("%s advertisement of %s", ad.Protocol, ad.TopicOrServiceName),
-			Satoshis:          AdTokenValue,
-			LockingScript:     lockingScript.Bytes(),
-		})
-	}
-
-	createActionResult, err := wlt.CreateAction(context.TODO(), wallet.CreateActionArgs{
-		Outputs:     outputs,
-		Description: "SHIP/SLAP Advertisement Issuance",
-	}, "")
-	if err != nil {
-		return overlay.TaggedBEEF{}, fmt.Errorf("failed to create action for advertisements: %w", err)
 	}
 
-	tx, err := transaction.NewTransactionFromBytes(createActionResult.Tx)
+	// Create the wallet-funded transaction carrying the advertisement tokens
+	beef, err := w.createAdvertisementTransaction(ctx, adsData)
 	if err != nil {
-		return overlay.TaggedBEEF{}, fmt.Errorf("failed to create transaction from tx: %w", err)
-	}
-
-	beef, err := transaction.NewBeefFromTransaction(tx)
-	if err != nil {
-		return overlay.TaggedBEEF{}, fmt.Errorf("failed to create BEEF from transaction: %w", err)
-	}
-	beefBytes, err := beef.Bytes()
-	if err != nil {
-		return overlay.TaggedBEEF{}, fmt.Errorf("failed to encode BEEF: %w", err)
+		return overlay.TaggedBEEF{}, fmt.Errorf("failed to create advertisement transaction: %w", err)
 	}
 
 	return overlay.TaggedBEEF{
-		Beef:   beefBytes,
+		Beef:   beef,
 		Topics: topics,
 	}, nil
 }
@@ -387,6 +417,13 @@ func (w *WalletAdvertiser) CreateAdvertisements(adsData []*oa.AdvertisementData)
 // FindAllAdvertisements finds all advertisements for a given protocol.
 // This method queries the overlay network using LookupResolver to retrieve existing advertisements.
 func (w *WalletAdvertiser) FindAllAdvertisements(protocol overlay.Protocol) ([]*oa.Advertisement, error) {
+	return w.FindAllAdvertisementsContext(context.Background(), protocol)
+}
+
+// FindAllAdvertisementsContext is FindAllAdvertisements with a context propagated to the lookup resolver.
+// A failed lookup is reported as an error rather than as no advertisements, so callers such as
+// reconciliation never mistake an unreachable resolver for advertisements that are missing.
+func (w *WalletAdvertiser) FindAllAdvertisementsContext(ctx context.Context, protocol overlay.Protocol) ([]*oa.Advertisement, error) {
 	if !w.initialized {
 		return nil, errNotInitializedForFind
 	}
@@ -402,17 +439,24 @@ func (w *WalletAdvertiser) FindAllAdvertisements(protocol overlay.Protocol) ([]*
 	}
 
 	// Query the storage for advertisements matching the protocol
-	advertisements, err := w.queryStorageForAdvertisements(protocol)
+	advertisements, err := w.queryStorageForAdvertisements(ctx, protocol)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal query data: %w", err)
+		return nil, fmt.Errorf("failed to query advertisements: %w", err)
 	}
 
 	return advertisements, nil
 }
 
 // RevokeAdvertisements revokes existing advertisements and returns the revocation as a tagged BEEF.
-// This method creates spending transactions to invalidate the specified advertisements.
+// A single transaction, funded and signed through the wallet, spends the outputs of all advertisements;
+// each PushDrop token is unlocked with a signature of the advertiser's key. The BEEF is tagged with the
+// tm_ship and/or tm_slap topics tracking the advertisements, so submitting it to the overlay removes them.
 func (w *WalletAdvertiser) RevokeAdvertisements(advertisements []*oa.Advertisement) (overlay.TaggedBEEF, error) {
+	return w.RevokeAdvertisementsContext(context.Background(), advertisements)
+}
+
+// RevokeAdvertisementsContext is RevokeAdvertisements with a context propagated to the wallet.
+func (w *WalletAdvertiser) RevokeAdvertisementsContext(ctx context.Context, advertisements []*oa.Advertisement) (overlay.TaggedBEEF, error) {
 	if !w.initialized {
 		return overlay.TaggedBEEF{}, errNotInitializedForRevoke
 	}
@@ -427,34 +471,32 @@ func (w *WalletAdvertiser) RevokeAdvertisements(advertisements []*oa.Advertiseme
 		if len(ad.Beef) == 0 {
 			return overlay.TaggedBEEF{}, fmt.Errorf("advertisement at index %d %w", i, errMissingBeefData)
 		}
-		if ad.OutputIndex == 0 {
-			return overlay.TaggedBEEF{}, fmt.Errorf("advertisement at index %d %w", i, errMissingOutputIndex)
-		}
 
-		// Collect topics for the TaggedBEEF
-		switch ad.Protocol {
-		case overlay.ProtocolSHIP, overlay.ProtocolSLAP:
-			topics = append(topics, "tm_"+ad.TopicOrService)
+		// Collect the topics tracking the advertisements for the TaggedBEEF
+		topic, err := advertisementTopic(ad.Protocol)
+		if err != nil {
+			return overlay.TaggedBEEF{}, fmt.Errorf("advertisement at index %d: %w", i, err)
+		}
+		if !slices.Contains(topics, topic) {
+			topics = append(topics, topic)
 		}
 	}
 
-	// Create spending transactions that consume the advertisement UTXOs
-	revocationTransactions, err := w.createNewRevocationTransactions(advertisements)
+	// Create the transaction spending the advertisement outputs
+	beef, err := w.createRevocationTransaction(ctx, advertisements)
 	if err != nil {
-		return overlay.TaggedBEEF{}, fmt.Errorf("failed to create revocation transactions: %w", err)
+		return overlay.TaggedBEEF{}, fmt.Errorf("failed to create revocation transaction: %w", err)
 	}
 
-	// Encode the revocation transactions as BEEF format
-	beefData := w.encodeTransactionsAsBEEF(revocationTransactions)
-
 	return overlay.TaggedBEEF{
-		Beef:   beefData,
+		Beef:   beef,
 		Topics: topics,
 	}, nil
 }
 
 // ParseAdvertisement parses an output script to extract advertisement information.
-// This method decodes PushDrop locking scripts to reconstruct advertisement data.
+// The PushDrop token is decoded and validated with utils.ParseAdvertisementToken, so only
+// advertisements the SHIP and SLAP topic managers would admit are returned.
 func (w *WalletAdvertiser) ParseAdvertisement(outputScript *script.Script) (*oa.Advertisement, error) {
 	if !w.initialized {
 		return nil, errNotInitializedForParse
@@ -464,86 +506,52 @@ func (w *WalletAdvertiser) ParseAdvertisement(outputScript *script.Script) (*oa.
 		return nil, errOutputScriptEmpty
 	}
 
-	// Convert script to hex string for PushDrop decoder
-	scriptHex := hex.EncodeToString(*outputScript)
-
-	// Decode the PushDrop locking script
-	s, err := script.NewFromHex(scriptHex)
+	token, err := utils.ParseAdvertisementToken(context.Background(), outputScript, "")
 	if err != nil {
-		return nil, fmt.Errorf("failed to create script from hex: %w", err)
-	}
-
-	result := pushdrop.Decode(s)
-	if result == nil {
-		return nil, fmt.Errorf("%w: %s", errInvalidPushDropScript, scriptHex)
-	}
-
-	// Validate that we have the expected number of fields
-	if len(result.Fields) < 4 {
-		return nil, fmt.Errorf("%w, got %d", errInvalidPushDropFields, len(result.Fields))
-	}
-
-	// Extract and validate protocol identifier
-	protocolIdentifier := string(result.Fields[0])
-	protocol := overlay.Protocol(protocolIdentifier)
-	switch protocol {
-	case overlay.ProtocolSHIP, overlay.ProtocolSLAP:
-	default:
-		return nil, fmt.Errorf("%w: %s", errUnsupportedProtocolID, protocolIdentifier)
-	}
-
-	// Extract other fields
-	identityKey := hex.EncodeToString(result.Fields[1])
-	domain := string(result.Fields[2])
-	topicOrService := string(result.Fields[3])
-
-	// Validate topic or service name
-	var fullTopicOrService string
-	if protocol == overlay.ProtocolSHIP {
-		fullTopicOrService = "tm_" + topicOrService
-	} else {
-		fullTopicOrService = "ls_" + topicOrService
-	}
-
-	if !utils.IsValidTopicOrServiceName(fullTopicOrService) {
-		return nil, fmt.Errorf("%w: %s", errInvalidTopicOrServiceName, fullTopicOrService)
+		return nil, err
 	}
 
 	return &oa.Advertisement{
-		Protocol:       protocol,
-		IdentityKey:    identityKey,
-		Domain:         domain,
-		TopicOrService: topicOrService,
+		Protocol:       token.Protocol,
+		IdentityKey:    token.IdentityKey,
+		Domain:         token.Domain,
+		TopicOrService: token.TopicOrService,
 		// BEEF and OutputIndex would be populated when available from context
 	}, nil
 }
 
-// validateAdvertisementData validates a single advertisement data entry
-func (w *WalletAdvertiser) validateAdvertisementData(adData *oa.AdvertisementData) error {
+// normalizeAdvertisementData validates a single advertisement data entry and returns a copy of it whose
+// topic or service name carries the prefix of the protocol, "tm_" for SHIP and "ls_" for SLAP, as
+// utils.BuildAdvertisementToken requires. Bare names are prefixed, while names carrying the prefix of
+// the other protocol are rejected.
+func (w *WalletAdvertiser) normalizeAdvertisementData(adData *oa.AdvertisementData) (*oa.AdvertisementData, error) {
 	// Validate protocol
 	if adData.Protocol != overlay.ProtocolSHIP && adData.Protocol != overlay.ProtocolSLAP {
-		return fmt.Errorf("%w: %s", errUnsupportedProtocol, adData.Protocol)
+		return nil, fmt.Errorf("%w: %s", errUnsupportedProtocol, adData.Protocol)
 	}
 
 	// Validate topic or service name
 	if strings.TrimSpace(adData.TopicOrServiceName) == "" {
-		return errTopicNameEmpty
+		return nil, errTopicNameEmpty
 	}
 
-	// Construct full name with appropriate prefix
-	var fullName string
-	if adData.Protocol == overlay.ProtocolSHIP {
-		fullName = "tm_" + adData.TopicOrServiceName
-	} else {
-		fullName = "ls_" + adData.TopicOrServiceName
+	prefix, otherPrefix := "tm_", "ls_"
+	if adData.Protocol == overlay.ProtocolSLAP {
+		prefix, otherPrefix = otherPrefix, prefix
+	}
+	if strings.HasPrefix(adData.TopicOrServiceName, otherPrefix) {
+		return nil, fmt.Errorf("%w: %q must start with %q for %s", errInvalidTopicOrServiceName, adData.TopicOrServiceName, prefix, adData.Protocol)
 	}
 
-	// Validate using utils function
-	if !utils.IsValidTopicOrServiceName(fullName) {
-		return fmt.Errorf("%w: %s", errInvalidTopicOrServiceName, fullName)
+	normalized := *adData
+	if !strings.HasPrefix(normalized.TopicOrServiceName, prefix) {
+		normalized.TopicOrServiceName = prefix + normalized.TopicOrServiceName
+	}
+	if !utils.IsValidTopicOrServiceName(normalized.TopicOrServiceName) {
+		return nil, fmt.Errorf("%w: %s", errInvalidTopicOrServiceName, normalized.TopicOrServiceName)
 	}
 
-	return nil
+	return &normalized, nil
 }
 
 // GetChain returns the blockchain network identifier
@@ -561,33 +569,6 @@ func (w *WalletAdvertiser) GetAdvertisableURI() string {
 	return w.advertisableURI
 }
 
-// Transaction represents a simplified BSV transaction structure
-type Transaction struct {
-	Version  uint32
-	Inputs   []TransactionInput
-	Outputs  []TransactionOutput
-	LockTime uint32
-}
-
-// TransactionInput represents a transaction input
-type TransactionInput struct {
-	PreviousOutput OutPoint
-	ScriptSig      []byte
-	Sequence       uint32
-}
-
-// TransactionOutput represents a transaction output
-type TransactionOutput struct {
-	Value         uint64
-	LockingScript []byte
-}
-
-// OutPoint represents a reference to a previous transaction output
-type OutPoint struct {
-	Hash  [32]byte
-	Index uint32
-}
-
 // IsInitialized returns whether the advertiser has been initialized
 func (w *WalletAdvertiser) IsInitialized() bool {
 	return w.initialized
@@ -631,7 +612,7 @@ func (w *WalletAdvertiser) validateAndInitializePrivateKey() error {
 }
 
 // validateStorageConnectivity validates that the storage URL is reachable
-func (w *WalletAdvertiser) validateStorageConnectivity() error {
+func (w *WalletAdvertiser) validateStorageConnectivity(ctx context.Context) error {
 	// Parse the storage URL to ensure it's valid
 	storageURL, err := url.Parse(w.storageURL)
 	if err != nil {
@@ -643,8 +624,8 @@ func (w *WalletAdvertiser) validateStorageConnectivity() error {
 		Timeout: 10 * time.Second,
 	}
 
-	// Create a context with a 10-second timeout for the request
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// Bound the request with a 10-second timeout
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	// Construct a basic health check endpoint
@@ -700,19 +681,26 @@ func (w *WalletAdvertiser) setupCryptographicContexts() error {
 	return nil
 }
 
-// deriveIdentityKey derives an identity key from the private key
-func (w *WalletAdvertiser) deriveIdentityKey() (string, error) {
-	// Create private key from hex
-	privateKey, err := ec.PrivateKeyFromHex(w.privateKey)
+// walletIdentityKey returns the identity key of the wallet, which signs the advertisement tokens.
+// If the advertiser has a private key, it must be the key of that identity.
+func (w *WalletAdvertiser) walletIdentityKey(ctx context.Context) (string, error) {
+	result, err := w.wallet.GetPublicKey(ctx, wallet.GetPublicKeyArgs{IdentityKey: true}, "")
 	if err != nil {
-		return "", fmt.Errorf("failed to create private key: %w", err)
+		return "", fmt.Errorf("failed to get wallet identity key: %w", err)
 	}
+	identityKey := hex.EncodeToString(result.PublicKey.Compressed())
 
-	// Derive the public key (identity key)
-	publicKey := privateKey.PubKey()
+	if w.privateKey != "" {
+		privateKey, err := ec.PrivateKeyFromHex(w.privateKey)
+		if err != nil {
+			return "", fmt.Errorf("failed to create private key: %w", err)
+		}
+		if hex.EncodeToString(privateKey.PubKey().Compressed()) != identityKey {
+			return "", errWalletIdentityKeyMismatch
+		}
+	}
 
-	// Return compressed public key as hex string
-	return hex.EncodeToString(publicKey.Compressed()), nil
+	return identityKey, nil
 }
 
 // getOverlayNetwork returns the overlay network based on the chain configuration
@@ -723,117 +711,9 @@ func (w *WalletAdvertiser) getOverlayNetwork() overlay.Network {
 	return overlay.NetworkMainnet
 }
 
-// encodeTransactionsAsBEEF encodes transactions in BEEF (Binary Extensible Exchange Format)
-func (w *WalletAdvertiser) encodeTransactionsAsBEEF(transactions []*Transaction) []byte {
-	var beefData []byte
-
-	// BEEF format header (simplified version)
-	beefData = append(beefData, []byte("BEEF")...)      // Magic bytes
-	beefData = append(beefData, 0x01, 0x00, 0x00, 0x00) // Version
-
-	// Encode number of transactions
-	beefData = append(beefData, w.encodeVarInt(uint64(len(transactions)))...)
-
-	// Encode each transaction
-	for _, tx := range transactions {
-		txBytes := w.encodeTransaction(tx)
-		beefData = append(beefData, txBytes...)
-	}
-
-	return beefData
-}
-
-// encodeTransaction encodes a transaction in Bitcoin format
-func (w *WalletAdvertiser) encodeTransaction(tx *Transaction) []byte {
-	var txBytes []byte
-
-	// Version (4 bytes, little endian)
-	txBytes = append(txBytes, w.encodeUint32(tx.Version)...)
-
-	// Input count
-	txBytes = append(txBytes, w.encodeVarInt(uint64(len(tx.Inputs)))...)
-
-	// Inputs
-	for _, input := range tx.Inputs {
-		// Previous output hash (32 bytes)
-		txBytes = append(txBytes, input.PreviousOutput.Hash[:]...)
-		// Previous output index (4 bytes, little endian)
-		txBytes = append(txBytes, w.encodeUint32(input.PreviousOutput.Index)...)
-		// Script length
-		txBytes = append(txBytes, w.encodeVarInt(uint64(len(input.ScriptSig)))...)
-		// Script
-		txBytes = append(txBytes, input.ScriptSig...)
-		// Sequence (4 bytes, little endian)
-		txBytes = append(txBytes, w.encodeUint32(input.Sequence)...)
-	}
-
-	// Output count
-	txBytes = append(txBytes, w.encodeVarInt(uint64(len(tx.Outputs)))...)
-
-	// Outputs
-	for _, output := range tx.Outputs {
-		// Value (8 bytes, little endian)
-		txBytes = append(txBytes, w.encodeUint64(output.Value)...)
-		// Script length
-		txBytes = append(txBytes, w.encodeVarInt(uint64(len(output.LockingScript)))...)
-		// Script
-		txBytes = append(txBytes, output.LockingScript...)
-	}
-
-	// Lock time (4 bytes, little endian)
-	txBytes = append(txBytes, w.encodeUint32(tx.LockTime)...)
-
-	return txBytes
-}
-
-// encodeVarInt encodes a variable-length integer
-func (w *WalletAdvertiser) encodeVarInt(value uint64) []byte {
-	if value < 0xfd {
-		return []byte{byte(value)}
-	}
-	if value <= 0xffff {
-		return []byte{0xfd, byte(value), byte(value >> 8)}
-	}
-	if value <= 0xffffffff {
-		return []byte{0xfe, byte(value), byte(value >> 8), byte(value >> 16), byte(value >> 24)}
-	}
-	return []byte{
-		0xff, byte(value), byte(value >> 8), byte(value >> 16), byte(value >> 24),
-		byte(value >> 32), byte(value >> 40), byte(value >> 48), byte(value >> 56),
-	}
-}
-
-// encodeUint32 encodes a 32-bit unsigned integer in little endian format
-func (w *WalletAdvertiser) encodeUint32(value uint32) []byte {
-	return []byte{byte(value), byte(value >> 8), byte(value >> 16), byte(value >> 24)}
-}
-
-// encodeUint64 encodes a 64-bit unsigned integer in little endian format
-func (w *WalletAdvertiser) encodeUint64(value uint64) []byte {
-	return []byte{
-		byte(value), byte(value >> 8), byte(value >> 16), byte(value >> 24),
-		byte(value >> 32), byte(value >> 40), byte(value >> 48), byte(value >> 56),
-	}
-}
-
 // queryStorageForAdvertisements queries the storage service for advertisements of a specific protocol
-func (w *WalletAdvertiser) queryStorageForAdvertisements(protocol overlay.Protocol) ([]*oa.Advertisement, error) {
-	// Create LookupResolver based on configuration
-	var resolver *lookup.LookupResolver
-	if w.lookupResolverConfig != nil {
-		// Convert our config to LookupResolver config
-		resolverConfig := &lookup.LookupResolver{
-			NetworkPreset: w.getOverlayNetwork(),
-		}
-		// Add any additional configuration from lookupResolverConfig if needed
-		resolver = lookup.NewLookupResolver(resolverConfig)
-	} else {
-		// Use default network preset based on chain
-		resolverConfig := &lookup.LookupResolver{
-			NetworkPreset: w.getOverlayNetwork(),
-		}
-		resolver = lookup.NewLookupResolver(resolverConfig)
-	}
+func (w *WalletAdvertiser) queryStorageForAdvertisements(ctx context.Context, protocol overlay.Protocol) ([]*oa.Advertisement, error) {
+	resolver := w.newLookupResolver()
 
 	// Determine the service name based on protocol
 	var serviceName string
@@ -857,16 +737,13 @@ func (w *WalletAdvertiser) queryStorageForAdvertisements(protocol overlay.Protoc
 		Query:   json.RawMessage(queryJSON),
 	}
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
 	// Execute the lookup query
-	lookupAnswer, err := resolver.Query(ctx, question)
+	lookupAnswer, err := w.queryWithRetries(ctx, resolver, question)
 	if err != nil {
-		// Log warning but return empty array, matching TypeScript behavior
-		slog.Warn("Error finding advertisements", "protocol", protocol, "error", err)
-		return []*oa.Advertisement{}, nil
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("%w for %s: %w", errAdvertisementLookupFailed, protocol, err)
 	}
 
 	// Process the lookup answer
@@ -914,6 +791,87 @@ func (w *WalletAdvertiser) queryStorageForAdvertisements(protocol overlay.Protoc
 	return advertisements, nil
 }
 
+// validateLookupResolverConfig checks the optional lookup resolver configuration
+func validateLookupResolverConfig(config *types.LookupResolverConfig) error {
+	if config == nil {
+		return nil
+	}
+	if config.HTTPSEndpoint != nil {
+		endpoint, err := url.Parse(*config.HTTPSEndpoint)
+		if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			return fmt.Errorf("%w: %s", errLookupEndpointInvalid, *config.HTTPSEndpoint)
+		}
+	}
+	if config.MaxRetries != nil && *config.MaxRetries < 0 {
+		return fmt.Errorf("%w: %d", errLookupMaxRetriesInvalid, *config.MaxRetries)
+	}
+	if config.TimeoutMS != nil && *config.TimeoutMS <= 0 {
+		return fmt.Errorf("%w: %d", errLookupTimeoutInvalid, *config.TimeoutMS)
+	}
+	return nil
+}
+
+// newLookupResolver creates the LookupResolver used to find advertisements. When the configuration
+// names an HTTPS endpoint, it replaces the network's SLAP trackers and answers both ls_ship and
+// ls_slap directly; otherwise the network preset of the chain is used.
+func (w *WalletAdvertiser) newLookupResolver() *lookup.LookupResolver {
+	resolverConfig := &lookup.LookupResolver{
+		NetworkPreset: w.getOverlayNetwork(),
+	}
+	if w.lookupResolverConfig != nil && w.lookupResolverConfig.HTTPSEndpoint != nil {
+		endpoint := strings.TrimSuffix(*w.lookupResolverConfig.HTTPSEndpoint, "/")
+		resolverConfig.SLAPTrackers = []string{endpoint}
+		resolverConfig.HostOverrides = map[string][]string{
+			"ls_ship": {endpoint},
+			"ls_slap": {endpoint},
+		}
+	}
+	return lookup.NewLookupResolver(resolverConfig)
+}
+
+// lookupTimeout returns the timeout of a single lookup attempt
+func (w *WalletAdvertiser) lookupTimeout() time.Duration {
+	if w.lookupResolverConfig != nil && w.lookupResolverConfig.TimeoutMS != nil {
+		return time.Duration(*w.lookupResolverConfig.TimeoutMS) * time.Millisecond
+	}
+	return defaultLookupTimeout
+}
+
+// lookupMaxRetries returns how many times a failed lookup is retried
+func (w *WalletAdvertiser) lookupMaxRetries() int {
+	if w.lookupResolverConfig != nil && w.lookupResolverConfig.MaxRetries != nil {
+		return *w.lookupResolverConfig.MaxRetries
+	}
+	return 0
+}
+
+// queryWithRetries runs a lookup query, bounding each attempt by the lookup timeout and retrying
+// failed attempts with exponential backoff up to the configured number of retries.
+func (w *WalletAdvertiser) queryWithRetries(ctx context.Context, resolver *lookup.LookupResolver, question *lookup.LookupQuestion) (*lookup.LookupAnswer, error) {
+	maxRetries := w.lookupMaxRetries()
+	backoff := w.lookupRetryBackoff
+
+	for attempt := 0; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, w.lookupTimeout())
+		answer, err := resolver.Query(attemptCtx, question)
+		cancel()
+		if err == nil {
+			return answer, nil
+		}
+		if attempt >= maxRetries {
+			return nil, fmt.Errorf("lookup failed after %d attempts: %w", attempt+1, err)
+		}
+
+		slog.Debug("Retrying advertisement lookup", "service", question.Service, "attempt", attempt+1, "backoff", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxLookupRetryBackoff)
+	}
+}
+
 // StorageAdvertisement represents the format used by the storage service
 type StorageAdvertisement struct {
 	ID             string    `json:"id"`
@@ -929,170 +887,252 @@ type StorageAdvertisement struct {
 	UpdatedAt      time.Time `json:"updatedAt"`
 }
 
-// createNewRevocationTransactions creates spending transactions to revoke advertisements (new types)
-func (w *WalletAdvertiser) createNewRevocationTransactions(advertisements []*oa.Advertisement) ([]*Transaction, error) {
-	transactions := make([]*Transaction, 0, len(advertisements))
+// createAdvertisementTransaction builds one advertisement token per advertisement with
+// utils.BuildAdvertisementToken and asks the wallet to fund and sign a transaction carrying them, in the
+// order of adsData. Each token holds the protocol, the wallet identity key, the advertisable URI, the
+// topic or service name and a BRC-48 signature over those fields, so it can be linked to the identity
+// key by the overlay topic managers.
+func (w *WalletAdvertiser) createAdvertisementTransaction(ctx context.Context, adsData []*oa.AdvertisementData) ([]byte, error) {
+	outputs := make([]wallet.CreateActionOutput, 0, len(adsData))
+	for _, ad := range adsData {
+		if !utils.IsValidTopicOrServiceName(ad.TopicOrServiceName) {
+			return nil, fmt.Errorf("%w: %s", errInvalidTopicOrServiceName, ad.TopicOrServiceName)
+		}
+		if _, err := advertisementProtocol(ad.Protocol); err != nil {
+			return nil, err
+		}
 
-	for i, ad := range advertisements {
-		tx, err := w.createSingleNewRevocationTransaction(ad)
+		token, err := utils.BuildAdvertisementToken(ctx, w.wallet, ad.Protocol, w.advertisableURI, ad.TopicOrServiceName)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create revocation transaction for advertisement %d: %w", i, err)
+			return nil, fmt.Errorf("failed to build %s advertisement token: %w", ad.Protocol, err)
 		}
-		transactions = append(transactions, tx)
+		outputs = append(outputs, wallet.CreateActionOutput{
+			OutputDescription: fmt.Sprintf("%s advertisement of %s", ad.Protocol, ad.TopicOrServiceName),
+			Satoshis:          AdTokenValue,
+			LockingScript:     token.LockingScript.Bytes(),
+		})
 	}
 
-	return transactions, nil
-}
+	// Keep the outputs in order so the advertisements can be found at the index of their data
+	randomizeOutputs := false
+	result, err := w.wallet.CreateAction(ctx, wallet.CreateActionArgs{
+		Description: "SHIP/SLAP Advertisement Issuance",
+		Outputs:     outputs,
+		Options:     &wallet.CreateActionOptions{RandomizeOutputs: &randomizeOutputs},
+	}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create action for advertisements: %w", err)
+	}
+	if len(result.Tx) == 0 {
+		return nil, errAdvertisementNotCreated
+	}
+
+	tx, err := transaction.NewTransactionFromBEEF(result.Tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse advertisement transaction: %w", err)
+	}
+	if len(tx.Outputs) < len(outputs) {
+		return nil, errAdvertisementNotCreated
+	}
 
-// createSingleNewRevocationTransaction creates a single revocation transaction (new types)
-func (w *WalletAdvertiser) createSingleNewRevocationTransaction(ad *oa.Advertisement) (*Transaction, error) {
-	// Parse the BEEF data to extract the original transaction
-	originalTx, err := w.parseTransactionFromBEEF(ad.Beef)
+	beef, err := tx.AtomicBEEF(false)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse original transaction from BEEF: %w", err)
-	}
-
-	// Create the spending transaction
-	revocationTx := &Transaction{
-		Version: 1,
-		Inputs: []TransactionInput{
-			{
-				PreviousOutput: OutPoint{
-					Hash:  w.calculateTransactionHash(originalTx),
-					Index: ad.OutputIndex,
-				},
-				ScriptSig: w.createRevocationScriptSig(),
-				Sequence:  0xffffffff,
-			},
-		},
-		Outputs: []TransactionOutput{
-			{
-				Value:         1, // Minimal output to make transaction valid
-				LockingScript: w.createSimpleLockingScript(),
-			},
-		},
-		LockTime: 0,
-	}
-
-	return revocationTx, nil
+		return nil, fmt.Errorf("failed to encode advertisement transaction: %w", err)
+	}
+	return beef, nil
 }
 
-// parseTransactionFromBEEF extracts transaction data from BEEF format
-func (w *WalletAdvertiser) parseTransactionFromBEEF(beefData []byte) (*Transaction, error) {
-	if len(beefData) < 8 {
-		return nil, errBEEFTooShort
+// createRevocationTransaction creates and signs a transaction spending the outputs of the advertisements
+// through the wallet, and returns it as atomic BEEF. The wallet funds the transaction fee and keeps the
+// released satoshis as change.
+func (w *WalletAdvertiser) createRevocationTransaction(ctx context.Context, advertisements []*oa.Advertisement) ([]byte, error) {
+	// Collect the advertisement outputs and the BEEF proving them
+	inputBEEF := transaction.NewBeefV2()
+	inputs := make([]wallet.CreateActionInput, 0, len(advertisements))
+	sourceTxs := make([]*transaction.Transaction, 0, len(advertisements))
+	for i, ad := range advertisements {
+		adBEEF, adTx, err := parseAdvertisementBEEF(ad.Beef)
+		if err != nil {
+			return nil, fmt.Errorf("advertisement at index %d: %w", i, err)
+		}
+		if int(ad.OutputIndex) >= len(adTx.Outputs) {
+			return nil, fmt.Errorf("advertisement at index %d %w: output index %d", i, errAdvertisementOutputMissing, ad.OutputIndex)
+		}
+		if err := inputBEEF.MergeBeef(adBEEF); err != nil {
+			return nil, fmt.Errorf("failed to merge BEEF of advertisement at index %d: %w", i, err)
+		}
+
+		inputs = append(inputs, wallet.CreateActionInput{
+			Outpoint:              transaction.Outpoint{Txid: *adTx.TxID(), Index: ad.OutputIndex},
+			InputDescription:      fmt.Sprintf("Revoke %s advertisement of %s", ad.Protocol, ad.TopicOrService),
+			UnlockingScriptLength: adTokenUnlockingScriptLength,
+		})
+		sourceTxs = append(sourceTxs, adTx)
 	}
 
-	// Skip BEEF header (simplified parsing)
-	offset := 8 // Skip "BEEF" + version
+	inputBEEFBytes, err := inputBEEF.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode input BEEF: %w", err)
+	}
 
-	// Skip transaction count
-	_, varIntSize := w.parseVarInt(beefData[offset:])
-	offset += varIntSize
+	createActionResult, err := w.wallet.CreateAction(ctx, wallet.CreateActionArgs{
+		Description: "SHIP/SLAP Advertisement Revocation",
+		InputBEEF:   inputBEEFBytes,
+		Inputs:      inputs,
+	}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create action for revocation: %w", err)
+	}
+	if createActionResult.SignableTransaction == nil {
+		return nil, errRevocationNotSignable
+	}
+	reference := createActionResult.SignableTransaction.Reference
 
-	// Parse the first transaction (simplified - assumes single transaction)
-	tx, err := w.parseTransaction(beefData[offset:])
+	spends, err := w.signRevocationInputs(ctx, createActionResult.SignableTransaction.Tx, advertisements, inputs, sourceTxs)
+	if err != nil {
+		w.abortAction(ctx, reference)
+		return nil, err
+	}
+
+	signActionResult, err := w.wallet.SignAction(ctx, wallet.SignActionArgs{
+		Reference: reference,
+		Spends:    spends,
+	}, "")
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse transaction from BEEF: %w", err)
+		w.abortAction(ctx, reference)
+		return nil, fmt.Errorf("failed to sign action for revocation: %w", err)
 	}
 
-	return tx, nil
+	return signActionResult.Tx, nil
 }
 
-// parseTransaction parses a transaction from binary data
-func (w *WalletAdvertiser) parseTransaction(data []byte) (*Transaction, error) {
-	if len(data) < 10 {
-		return nil, errTransactionTooShort
+// signRevocationInputs signs the inputs of the signable revocation transaction spending the
+// advertisement tokens, and returns their unlocking scripts keyed by input index.
+func (w *WalletAdvertiser) signRevocationInputs(ctx context.Context, signableTx []byte, advertisements []*oa.Advertisement, inputs []wallet.CreateActionInput, sourceTxs []*transaction.Transaction) (map[uint32]wallet.SignActionSpend, error) {
+	tx, err := transaction.NewTransactionFromBEEF(signableTx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signable revocation transaction: %w", err)
+	}
+	if tx == nil {
+		return nil, errRevocationNotSignable
 	}
 
-	offset := 0
+	pd := pushdrop.PushDrop{
+		Wallet: w.wallet,
+	}
 
-	// Parse version
-	version := w.parseUint32(data[offset:])
-	offset += 4
+	spends := make(map[uint32]wallet.SignActionSpend, len(advertisements))
+	for i, ad := range advertisements {
+		inputIndex := slices.IndexFunc(tx.Inputs, func(input *transaction.TransactionInput) bool {
+			return input.SourceTXID != nil && input.SourceTXID.IsEqual(&inputs[i].Outpoint.Txid) &&
+				input.SourceTxOutIndex == inputs[i].Outpoint.Index
+		})
+		if inputIndex < 0 {
+			return nil, fmt.Errorf("%w: %s", errRevocationInputMissing, inputs[i].Outpoint.String())
+		}
 
-	// Parse input count
-	inputCount, varIntSize := w.parseVarInt(data[offset:])
-	_ = varIntSize // varIntSize not used in simplified parsing
+		// The signature hash commits to the spent output, which the wallet may not have linked
+		input := tx.Inputs[inputIndex]
+		if input.SourceTransaction == nil {
+			input.SourceTransaction = sourceTxs[i]
+		}
 
-	// Create transaction with dummy data (simplified parsing)
-	tx := &Transaction{
-		Version:  version,
-		Inputs:   make([]TransactionInput, inputCount),
-		Outputs:  []TransactionOutput{}, // Simplified - not parsing outputs for revocation
-		LockTime: 0,
+		protocol, err := advertisementProtocol(ad.Protocol)
+		if err != nil {
+			return nil, err
+		}
+		unlocker := pd.Unlock(ctx, protocol, adTokenKeyID, wallet.Counterparty{Type: wallet.CounterpartyTypeAnyone}, wallet.SignOutputsAll, false)
+		unlockingScript, err := unlocker.Sign(tx, inputIndex)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign revocation of advertisement at index %d: %w", i, err)
+		}
+
+		spends[uint32(inputIndex)] = wallet.SignActionSpend{ //nolint:gosec // input indexes fit in uint32
+			UnlockingScript: unlockingScript.Bytes(),
+		}
 	}
 
-	return tx, nil
+	return spends, nil
 }
 
-// parseVarInt parses a variable-length integer and returns the value and byte size
-func (w *WalletAdvertiser) parseVarInt(data []byte) (uint64, int) {
-	if len(data) == 0 {
-		return 0, 0
+// abortAction releases the inputs of an action that could not be signed, so the wallet does not keep
+// them locked. Failures are only logged since the original error is more useful to the caller.
+// The abort outlives the cancellation of ctx, which is a common reason for signing to fail.
+func (w *WalletAdvertiser) abortAction(ctx context.Context, reference []byte) {
+	if _, err := w.wallet.AbortAction(context.WithoutCancel(ctx), wallet.AbortActionArgs{Reference: reference}, ""); err != nil {
+		slog.Warn("Failed to abort revocation action", "error", err)
 	}
+}
 
-	first := data[0]
-	if first < 0xfd {
-		return uint64(first), 1
-	} else if first == 0xfd && len(data) >= 3 {
-		return uint64(data[1]) | uint64(data[2])<<8, 3
-	} else if first == 0xfe && len(data) >= 5 {
-		return uint64(data[1]) | uint64(data[2])<<8 | uint64(data[3])<<16 | uint64(data[4])<<24, 5
-	} else if first == 0xff && len(data) >= 9 {
-		return uint64(data[1]) | uint64(data[2])<<8 | uint64(data[3])<<16 | uint64(data[4])<<24 |
-			uint64(data[5])<<32 | uint64(data[6])<<40 | uint64(data[7])<<48 | uint64(data[8])<<56, 9
+// parseAdvertisementBEEF parses the BEEF of an advertisement and returns it along with the
+// transaction holding the advertisement output.
+func parseAdvertisementBEEF(beefBytes []byte) (*transaction.Beef, *transaction.Transaction, error) {
+	beef, tx, _, err := transaction.ParseBeef(beefBytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", errInvalidAdvertisementBEEF, err)
 	}
-	return 0, 0
-}
 
-// parseUint32 parses a 32-bit unsigned integer from little-endian bytes
-func (w *WalletAdvertiser) parseUint32(data []byte) uint32 {
-	if len(data) < 4 {
-		return 0
+	// BEEF V2 does not name its subject transaction, which is the one no other transaction spends
+	if tx == nil {
+		tx = beefSubjectTransaction(beef)
+	}
+	if tx == nil {
+		return nil, nil, fmt.Errorf("%w: no subject transaction", errInvalidAdvertisementBEEF)
 	}
-	return uint32(data[0]) | uint32(data[1])<<8 | uint32(data[2])<<16 | uint32(data[3])<<24
-}
 
-// calculateTransactionHash calculates the hash of a transaction (simplified)
-func (w *WalletAdvertiser) calculateTransactionHash(tx *Transaction) [32]byte {
-	// In a real implementation, this would serialize the transaction and hash it
-	// For now, create a deterministic hash based on transaction data
-	var hash [32]byte
+	return beef, tx, nil
+}
 
-	// Simple deterministic hash based on version and input/output counts
-	hashData := []byte{
-		byte(tx.Version), byte(tx.Version >> 8), byte(tx.Version >> 16), byte(tx.Version >> 24),
-		byte(len(tx.Inputs)), byte(len(tx.Outputs)),
+// beefSubjectTransaction returns the only transaction of the BEEF that is not spent by another of its
+// transactions, or nil if there is no such transaction or more than one.
+func beefSubjectTransaction(beef *transaction.Beef) *transaction.Transaction {
+	spent := make(map[chainhash.Hash]bool)
+	for _, beefTx := range beef.Transactions {
+		if beefTx.Transaction == nil {
+			continue
+		}
+		for _, input := range beefTx.Transaction.Inputs {
+			if input.SourceTXID != nil {
+				spent[*input.SourceTXID] = true
+			}
+		}
 	}
 
-	// Pad to 32 bytes
-	copy(hash[:], hashData)
-	for i := len(hashData); i < 32; i++ {
-		hash[i] = byte(i % 256)
+	var subject *transaction.Transaction
+	for txid, beefTx := range beef.Transactions {
+		if beefTx.Transaction == nil || spent[txid] {
+			continue
+		}
+		if subject != nil {
+			return nil
+		}
+		subject = beefTx.Transaction
 	}
 
-	return hash
+	return subject
 }
 
-// createRevocationScriptSig creates a script signature for spending the advertisement output
-func (w *WalletAdvertiser) createRevocationScriptSig() []byte {
-	// In a real implementation, this would create a proper signature
-	// For now, return a placeholder script sig
-	return []byte{0x47, 0x30, 0x44, 0x02, 0x20} // Placeholder signature prefix
-}
-
-// createSimpleLockingScript creates a simple locking script for the revocation output
-func (w *WalletAdvertiser) createSimpleLockingScript() []byte {
-	// Simple P2PKH-style script (placeholder)
-	script := []byte{0x76, 0xa9, 0x14} // OP_DUP OP_HASH160 <20 bytes>
-
-	// Add 20-byte hash (placeholder)
-	for i := 0; i < 20; i++ {
-		script = append(script, byte(i))
+// advertisementProtocol returns the wallet protocol the advertisement tokens of a protocol are locked with.
+func advertisementProtocol(protocol overlay.Protocol) (wallet.Protocol, error) {
+	id := protocol.ID()
+	if id == "" {
+		return wallet.Protocol{}, fmt.Errorf("%w: %s", errUnsupportedProtocol, protocol)
 	}
 
-	script = append(script, 0x88, 0xac) // OP_EQUALVERIFY OP_CHECKSIG
+	return wallet.Protocol{
+		SecurityLevel: wallet.SecurityLevelEveryAppAndCounterparty,
+		Protocol:      string(id),
+	}, nil
+}
 
-	return script
+// advertisementTopic returns the overlay topic tracking the advertisements of a protocol.
+func advertisementTopic(protocol overlay.Protocol) (string, error) {
+	switch protocol {
+	case overlay.ProtocolSHIP:
+		return "tm_ship", nil
+	case overlay.ProtocolSLAP:
+		return "tm_slap", nil
+	default:
+		return "", fmt.Errorf("%w: %s", errUnsupportedProtocol, protocol)
+	}
 }
@@ -2,15 +2,34 @@
 package advertiser
 
 import (
+	"context"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/bsv-blockchain/go-sdk/transaction"
 
+	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/ship"
+	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/slap"
 	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/types"
+	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/utils"
 	oa "github.com/bsv-blockchain/go-overlay-services/pkg/core/advertiser"
+	authhttp "github.com/bsv-blockchain/go-sdk/auth/clients/authhttp"
 	"github.com/bsv-blockchain/go-sdk/overlay"
+	"github.com/bsv-blockchain/go-sdk/overlay/lookup"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
 	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/script/interpreter"
+	"github.com/bsv-blockchain/go-sdk/transaction/template/pushdrop"
+	"github.com/bsv-blockchain/go-sdk/wallet"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/defs"
+	"github.com/bsv-blockchain/go-wallet-toolbox/pkg/storage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -104,6 +123,33 @@ func TestNewWalletAdvertiser(t *testing.T) {
 			advertisableURI: "invalid-uri",
 			expectedError:   "advertisableURI is not valid according to BRC-101 specification",
 		},
+		{
+			name:            "Invalid lookup endpoint",
+			chain:           "main",
+			privateKey:      "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+			storageURL:      "https://storage.example.com",
+			advertisableURI: "https://service.example.com/",
+			lookupConfig:    &types.LookupResolverConfig{HTTPSEndpoint: stringPtr("resolver.example.com")},
+			expectedError:   "lookup resolver httpsEndpoint must be a valid HTTP or HTTPS URL",
+		},
+		{
+			name:            "Negative lookup retries",
+			chain:           "main",
+			privateKey:      "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+			storageURL:      "https://storage.example.com",
+			advertisableURI: "https://service.example.com/",
+			lookupConfig:    &types.LookupResolverConfig{MaxRetries: intPtr(-1)},
+			expectedError:   "lookup resolver maxRetries cannot be negative",
+		},
+		{
+			name:            "Zero lookup timeout",
+			chain:           "main",
+			privateKey:      "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+			storageURL:      "https://storage.example.com",
+			advertisableURI: "https://service.example.com/",
+			lookupConfig:    &types.LookupResolverConfig{TimeoutMS: intPtr(0)},
+			expectedError:   "lookup resolver timeoutMS must be positive",
+		},
 	}
 
 	for _, tt := range tests {
@@ -126,6 +172,84 @@ func TestNewWalletAdvertiser(t *testing.T) {
 	}
 }
 
+func TestNewWalletAdvertiserWithOptions(t *testing.T) {
+	newAdvertiser := func(opts ...Option) (*WalletAdvertiser, error) {
+		return NewWalletAdvertiserWithOptions(
+			context.Background(),
+			"main",
+			"0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+			"https://storage.example.com",
+			"https://service.example.com/",
+			opts...,
+		)
+	}
+
+	t.Run("uses the provided wallet", func(t *testing.T) {
+		testWallet := newTestAdvertiserWallet(t)
+		advertiser, err := newAdvertiser(WithWallet(testWallet))
+		require.NoError(t, err)
+		assert.Same(t, testWallet, advertiser.wallet)
+		assert.NotNil(t, advertiser.authFetch)
+	})
+
+	t.Run("uses the provided AuthFetch and lookup config", func(t *testing.T) {
+		testWallet := newTestAdvertiserWallet(t)
+		authFetch := authhttp.New(testWallet)
+		lookupConfig := &types.LookupResolverConfig{MaxRetries: intPtr(2)}
+
+		advertiser, err := newAdvertiser(WithWallet(testWallet), WithAuthFetch(authFetch), WithLookupResolverConfig(lookupConfig))
+		require.NoError(t, err)
+		assert.Same(t, authFetch, advertiser.authFetch)
+		assert.Same(t, lookupConfig, advertiser.lookupResolverConfig)
+	})
+
+	t.Run("validates the lookup config", func(t *testing.T) {
+		_, err := newAdvertiser(WithWallet(newTestAdvertiserWallet(t)), WithLookupResolverConfig(&types.LookupResolverConfig{TimeoutMS: intPtr(-1)}))
+		require.ErrorIs(t, err, errLookupTimeoutInvalid)
+	})
+
+	t.Run("takes the identity key from the wallet without a private key", func(t *testing.T) {
+		testWallet := newTestAdvertiserWallet(t)
+		advertiser, err := NewWalletAdvertiserWithOptions(context.Background(), "main", "", "https://storage.example.com", "https://service.example.com/", WithWallet(testWallet))
+		require.NoError(t, err)
+		advertiser.SetSkipStorageValidation(true)
+
+		require.NoError(t, advertiser.Init())
+		identityKey, err := testWallet.GetPublicKey(context.Background(), wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+		require.NoError(t, err)
+		assert.Equal(t, identityKey.PublicKey.ToDERHex(), advertiser.identityKey)
+	})
+
+	t.Run("rejects a private key of another identity than the wallet", func(t *testing.T) {
+		otherKey, err := ec.PrivateKeyFromHex("fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210")
+		require.NoError(t, err)
+		advertiser, err := newAdvertiser(WithWallet(wallet.NewTestWallet(t, otherKey)))
+		require.NoError(t, err)
+		advertiser.SetSkipStorageValidation(true)
+
+		require.ErrorIs(t, advertiser.Init(), errWalletIdentityKeyMismatch)
+		assert.False(t, advertiser.IsInitialized())
+	})
+
+	t.Run("requires a private key without a wallet", func(t *testing.T) {
+		_, err := NewWalletAdvertiserWithOptions(context.Background(), "main", "", "https://storage.example.com", "https://service.example.com/")
+		require.ErrorIs(t, err, errPrivateKeyRequired)
+	})
+
+	t.Run("rejects a wallet combined with storage options", func(t *testing.T) {
+		_, err := newAdvertiser(WithWallet(newTestAdvertiserWallet(t)), WithDBConfig(defs.Database{}))
+		require.ErrorIs(t, err, errWalletStorageConflict)
+
+		_, err = newAdvertiser(WithWallet(newTestAdvertiserWallet(t)), WithStorageProvider(&storage.Provider{}))
+		require.ErrorIs(t, err, errWalletStorageConflict)
+	})
+
+	t.Run("rejects a storage provider combined with a DB config", func(t *testing.T) {
+		_, err := newAdvertiser(WithStorageProvider(&storage.Provider{}), WithDBConfig(defs.Database{}))
+		require.ErrorIs(t, err, errStorageDBConfigConflict)
+	})
+}
+
 func TestWalletAdvertiser_Init(t *testing.T) {
 	advertiser, err := NewWalletAdvertiser(
 		"main",
@@ -173,7 +297,7 @@ func TestWalletAdvertiser_CreateAdvertisements(t *testing.T) {
 			adsData: []*oa.AdvertisementData{
 				{
 					Protocol:           overlay.ProtocolSLAP,
-					TopicOrServiceName: "tm_meter",
+					TopicOrServiceName: "ls_meter",
 				},
 			},
 			shouldFail: false, // Implementation is now complete
@@ -222,6 +346,108 @@ func TestWalletAdvertiser_CreateAdvertisements(t *testing.T) {
 	}
 }
 
+func TestWalletAdvertiser_CreateAdvertisements_AdmittedByTopicManagers(t *testing.T) {
+	testWallet := newTestAdvertiserWallet(t)
+	advertiser := setupAdvertiserWithWallet(t, testWallet)
+	mockIssuanceAction(t, testWallet)
+
+	result, err := advertiser.CreateAdvertisements([]*oa.AdvertisementData{
+		{Protocol: overlay.ProtocolSHIP, TopicOrServiceName: "tm_payments"},
+		{Protocol: overlay.ProtocolSLAP, TopicOrServiceName: "ls_payments"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tm_ship", "tm_slap"}, result.Topics)
+
+	tx, err := transaction.NewTransactionFromBEEF(result.Beef)
+	require.NoError(t, err)
+	require.Len(t, tx.Outputs, 3)
+
+	shipAdmittance, err := ship.NewTopicManager(ship.NewMemoryStorage(), nil).IdentifyAdmissibleOutputs(context.Background(), result.Beef, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{0}, shipAdmittance.OutputsToAdmit)
+
+	slapAdmittance, err := slap.NewTopicManager(slap.NewMemoryStorage(), nil).IdentifyAdmissibleOutputs(context.Background(), result.Beef, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{1}, slapAdmittance.OutputsToAdmit)
+
+	// The tokens round-trip through ParseAdvertisement
+	parsedAd, err := advertiser.ParseAdvertisement(tx.Outputs[1].LockingScript)
+	require.NoError(t, err)
+	assert.Equal(t, overlay.ProtocolSLAP, parsedAd.Protocol)
+	assert.Equal(t, "ls_payments", parsedAd.TopicOrService)
+	assert.Equal(t, advertiser.identityKey, parsedAd.IdentityKey)
+}
+
+func TestWalletAdvertiser_CreateAdvertisements_TopicOrServicePrefix(t *testing.T) {
+	t.Run("prefixes bare names", func(t *testing.T) {
+		testWallet := newTestAdvertiserWallet(t)
+		advertiser := setupAdvertiserWithWallet(t, testWallet)
+		mockIssuanceAction(t, testWallet)
+
+		adsData := []*oa.AdvertisementData{
+			{Protocol: overlay.ProtocolSHIP, TopicOrServiceName: "meter"},
+			{Protocol: overlay.ProtocolSLAP, TopicOrServiceName: "meter"},
+		}
+		result, err := advertiser.CreateAdvertisements(adsData)
+		require.NoError(t, err)
+
+		tx, err := transaction.NewTransactionFromBEEF(result.Beef)
+		require.NoError(t, err)
+		for i, expected := range []string{"tm_meter", "ls_meter"} {
+			parsedAd, err := advertiser.ParseAdvertisement(tx.Outputs[i].LockingScript)
+			require.NoError(t, err)
+			assert.Equal(t, expected, parsedAd.TopicOrService)
+		}
+		// The caller's data is left as given
+		assert.Equal(t, "meter", adsData[0].TopicOrServiceName)
+
+		// Bare names match the prefixed advertisements found on the overlay
+		advertiser.Finder = &reconcileFinder{advertisements: []*oa.Advertisement{
+			{Protocol: overlay.ProtocolSHIP, IdentityKey: advertiser.identityKey, Domain: testAdvertisableURI, TopicOrService: "tm_meter"},
+		}}
+		plan, err := advertiser.PlanReconciliation(adsData)
+		require.NoError(t, err)
+		assert.Equal(t, []*oa.AdvertisementData{{Protocol: overlay.ProtocolSLAP, TopicOrServiceName: "ls_meter"}}, plan.Create)
+		assert.Len(t, plan.Keep, 1)
+	})
+
+	t.Run("rejects the prefix of the other protocol", func(t *testing.T) {
+		testWallet := newTestAdvertiserWallet(t)
+		advertiser := setupAdvertiserWithWallet(t, testWallet)
+		testWallet.OnCreateAction().ReturnError(errTestWallet)
+
+		tests := []struct {
+			name     string
+			adData   *oa.AdvertisementData
+			expected string
+		}{
+			{"Service prefix for SHIP", &oa.AdvertisementData{Protocol: overlay.ProtocolSHIP, TopicOrServiceName: "ls_meter"}, `must start with "tm_"`},
+			{"Topic prefix for SLAP", &oa.AdvertisementData{Protocol: overlay.ProtocolSLAP, TopicOrServiceName: "tm_meter"}, `must start with "ls_"`},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// Rejected before the wallet is asked to fund the advertisement
+				_, err := advertiser.CreateAdvertisements([]*oa.AdvertisementData{tt.adData})
+				require.ErrorIs(t, err, errInvalidTopicOrServiceName)
+				assert.Contains(t, err.Error(), tt.expected)
+
+				_, err = advertiser.PlanReconciliation([]*oa.AdvertisementData{tt.adData})
+				require.ErrorIs(t, err, errInvalidTopicOrServiceName)
+			})
+		}
+	})
+}
+
+func TestWalletAdvertiser_CreateAdvertisements_WalletErrors(t *testing.T) {
+	testWallet := newTestAdvertiserWallet(t)
+	advertiser := setupAdvertiserWithWallet(t, testWallet)
+	testWallet.OnCreateAction().ReturnError(errTestWallet)
+
+	_, err := advertiser.CreateAdvertisements([]*oa.AdvertisementData{{Protocol: overlay.ProtocolSHIP, TopicOrServiceName: "tm_payments"}})
+	require.ErrorIs(t, err, errTestWallet)
+}
+
 func TestWalletAdvertiser_FindAllAdvertisements(t *testing.T) {
 	advertiser := setupInitializedAdvertiser(t)
 	advertiser.Finder = &MockFinder{} // Use mock finder to avoid real network calls
@@ -266,8 +492,99 @@ func TestWalletAdvertiser_FindAllAdvertisements(t *testing.T) {
 	}
 }
 
+func TestWalletAdvertiser_FindAllAdvertisements_LookupResolverConfig(t *testing.T) {
+	adBEEF, _ := createTestAdvertisementBEEF(t, newTestAdvertiserWallet(t), overlay.ProtocolSHIP, "tm_payments", false)
+
+	// newResolver serves ls_ship lookups, failing the first failures requests and delaying every answer
+	newResolver := func(t *testing.T, failures int32, delay time.Duration) (*httptest.Server, *atomic.Int32) {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/lookup", r.URL.Path)
+			var question lookup.LookupQuestion
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&question))
+			assert.Equal(t, "ls_ship", question.Service)
+
+			if requests.Add(1) <= failures {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(delay):
+			}
+			assert.NoError(t, json.NewEncoder(w).Encode(lookup.LookupAnswer{
+				Type:    lookup.AnswerTypeOutputList,
+				Outputs: []*lookup.OutputListItem{{Beef: adBEEF, OutputIndex: 0}},
+			}))
+		}))
+		t.Cleanup(server.Close)
+		return server, &requests
+	}
+
+	newAdvertiser := func(t *testing.T, config *types.LookupResolverConfig) *WalletAdvertiser {
+		advertiser, err := NewWalletAdvertiser(
+			"main",
+			"0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+			"https://storage.example.com",
+			"https://service.example.com/",
+			config,
+		)
+		require.NoError(t, err)
+		advertiser.SetSkipStorageValidation(true)
+		advertiser.lookupRetryBackoff = time.Millisecond
+		require.NoError(t, advertiser.Init())
+		return advertiser
+	}
+
+	t.Run("queries the configured endpoint", func(t *testing.T) {
+		server, requests := newResolver(t, 0, 0)
+		advertiser := newAdvertiser(t, &types.LookupResolverConfig{HTTPSEndpoint: stringPtr(server.URL + "/")})
+
+		ads, err := advertiser.FindAllAdvertisements(overlay.ProtocolSHIP)
+		require.NoError(t, err)
+		require.Len(t, ads, 1)
+		assert.Equal(t, "tm_payments", ads[0].TopicOrService)
+		assert.Equal(t, adBEEF, ads[0].Beef)
+		assert.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("retries failed lookups", func(t *testing.T) {
+		server, requests := newResolver(t, 2, 0)
+		advertiser := newAdvertiser(t, &types.LookupResolverConfig{HTTPSEndpoint: stringPtr(server.URL), MaxRetries: intPtr(2)})
+
+		ads, err := advertiser.FindAllAdvertisements(overlay.ProtocolSHIP)
+		require.NoError(t, err)
+		assert.Len(t, ads, 1)
+		assert.Equal(t, int32(3), requests.Load())
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		server, requests := newResolver(t, 5, 0)
+		advertiser := newAdvertiser(t, &types.LookupResolverConfig{HTTPSEndpoint: stringPtr(server.URL), MaxRetries: intPtr(1)})
+
+		ads, err := advertiser.FindAllAdvertisements(overlay.ProtocolSHIP)
+		require.ErrorIs(t, err, errAdvertisementLookupFailed)
+		assert.Empty(t, ads)
+		assert.Equal(t, int32(2), requests.Load())
+	})
+
+	t.Run("times out slow lookups", func(t *testing.T) {
+		server, requests := newResolver(t, 0, 5*time.Second)
+		advertiser := newAdvertiser(t, &types.LookupResolverConfig{HTTPSEndpoint: stringPtr(server.URL), TimeoutMS: intPtr(50)})
+
+		start := time.Now()
+		ads, err := advertiser.FindAllAdvertisements(overlay.ProtocolSHIP)
+		require.ErrorIs(t, err, errAdvertisementLookupFailed)
+		assert.Empty(t, ads)
+		assert.Less(t, time.Since(start), 2*time.Second)
+		assert.Equal(t, int32(1), requests.Load())
+	})
+}
+
 func TestWalletAdvertiser_RevokeAdvertisements(t *testing.T) {
 	advertiser := setupInitializedAdvertiser(t)
+	advertisementBEEF, _ := createTestAdvertisementBEEF(t, newTestAdvertiserWallet(t), overlay.ProtocolSHIP, "tm_payments", false)
 
 	tests := []struct {
 		name           string
@@ -276,50 +593,62 @@ func TestWalletAdvertiser_RevokeAdvertisements(t *testing.T) {
 		shouldFail     bool
 	}{
 		{
-			name: "Valid advertisement with BEEF",
+			name:           "Empty advertisements array",
+			advertisements: []*oa.Advertisement{},
+			expectedError:  "at least one advertisement is required for revocation",
+		},
+		{
+			name: "Advertisement missing BEEF",
 			advertisements: []*oa.Advertisement{
 				{
 					Protocol:       overlay.ProtocolSHIP,
 					IdentityKey:    "test-key",
 					Domain:         "example.com",
 					TopicOrService: "payments",
-					Beef:           []byte("BEEF\x01\x00\x00\x00\x01\x01\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00"), // Valid minimal BEEF data
 					OutputIndex:    1,
 				},
 			},
-			shouldFail: false, // Implementation is now complete
+			expectedError: "advertisement at index 0 is missing BEEF data required for revocation",
 		},
 		{
-			name:           "Empty advertisements array",
-			advertisements: []*oa.Advertisement{},
-			expectedError:  "at least one advertisement is required for revocation",
+			name: "Advertisement with invalid BEEF",
+			advertisements: []*oa.Advertisement{
+				{
+					Protocol:       overlay.ProtocolSHIP,
+					IdentityKey:    "test-key",
+					Domain:         "example.com",
+					TopicOrService: "payments",
+					Beef:           []byte("test-beef"),
+				},
+			},
+			expectedError: "advertisement at index 0: invalid advertisement BEEF",
 		},
 		{
-			name: "Advertisement missing BEEF",
+			name: "Advertisement output index out of range",
 			advertisements: []*oa.Advertisement{
 				{
 					Protocol:       overlay.ProtocolSHIP,
 					IdentityKey:    "test-key",
 					Domain:         "example.com",
 					TopicOrService: "payments",
+					Beef:           advertisementBEEF,
 					OutputIndex:    1,
 				},
 			},
-			expectedError: "advertisement at index 0 is missing BEEF data required for revocation",
+			expectedError: "advertisement at index 0 does not contain the advertised output",
 		},
 		{
-			name: "Advertisement missing output index",
+			name: "Advertisement with unsupported protocol",
 			advertisements: []*oa.Advertisement{
 				{
-					Protocol:       overlay.ProtocolSHIP,
+					Protocol:       "INVALID",
 					IdentityKey:    "test-key",
 					Domain:         "example.com",
 					TopicOrService: "payments",
-					Beef:           []byte("test-beef"),
-					OutputIndex:    0, // This will trigger the validation error
+					Beef:           advertisementBEEF,
 				},
 			},
-			expectedError: "advertisement at index 0 is missing output index required for revocation",
+			expectedError: "unsupported protocol",
 		},
 	}
 
@@ -340,6 +669,168 @@ func TestWalletAdvertiser_RevokeAdvertisements(t *testing.T) {
 	}
 }
 
+func TestWalletAdvertiser_RevokeAdvertisements_SpendsAdvertisements(t *testing.T) {
+	testWallet := newTestAdvertiserWallet(t)
+	advertiser := setupAdvertiserWithWallet(t, testWallet)
+	mockRevocationActions(t, testWallet)
+
+	shipBEEF, shipTx := createTestAdvertisementBEEF(t, testWallet, overlay.ProtocolSHIP, "tm_payments", false)
+	slapBEEF, slapTx := createTestAdvertisementBEEF(t, testWallet, overlay.ProtocolSLAP, "ls_payments", true)
+	advertisements := []*oa.Advertisement{
+		{Protocol: overlay.ProtocolSHIP, TopicOrService: "tm_payments", Beef: shipBEEF, OutputIndex: 0},
+		{Protocol: overlay.ProtocolSLAP, TopicOrService: "ls_payments", Beef: slapBEEF, OutputIndex: 0},
+	}
+
+	result, err := advertiser.RevokeAdvertisements(advertisements)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tm_ship", "tm_slap"}, result.Topics)
+
+	revocationTx, err := transaction.NewTransactionFromBEEF(result.Beef)
+	require.NoError(t, err)
+	require.NotNil(t, revocationTx)
+	require.Len(t, revocationTx.Inputs, 2)
+
+	// Each input must spend an advertisement token with a valid PushDrop unlock
+	for i, adTx := range []*transaction.Transaction{shipTx, slapTx} {
+		input := revocationTx.Inputs[i]
+		assert.Equal(t, adTx.TxID().String(), input.SourceTXID.String())
+		assert.Equal(t, uint32(0), input.SourceTxOutIndex)
+
+		err := interpreter.NewEngine().Execute(
+			interpreter.WithTx(revocationTx, i, adTx.Outputs[0]),
+			interpreter.WithForkID(),
+			interpreter.WithAfterGenesis(),
+		)
+		require.NoError(t, err, "input %d does not unlock the advertisement token", i)
+	}
+
+	// The SHIP topic manager sees the revocation as consuming the advertisement without admitting new outputs
+	topicManager := ship.NewTopicManager(ship.NewMemoryStorage(), nil)
+	admittance, err := topicManager.IdentifyAdmissibleOutputs(context.Background(), result.Beef, map[uint32]*transaction.TransactionOutput{
+		0: shipTx.Outputs[0],
+	})
+	require.NoError(t, err)
+	assert.Empty(t, admittance.OutputsToAdmit)
+	assert.Empty(t, admittance.CoinsToRetain)
+}
+
+func TestWalletAdvertiser_RevokeAdvertisements_WalletErrors(t *testing.T) {
+	t.Run("create action fails", func(t *testing.T) {
+		testWallet := newTestAdvertiserWallet(t)
+		advertiser := setupAdvertiserWithWallet(t, testWallet)
+		testWallet.OnCreateAction().ReturnError(errTestWallet)
+
+		adBEEF, _ := createTestAdvertisementBEEF(t, testWallet, overlay.ProtocolSHIP, "tm_payments", false)
+		_, err := advertiser.RevokeAdvertisements([]*oa.Advertisement{{Protocol: overlay.ProtocolSHIP, Beef: adBEEF}})
+		require.ErrorIs(t, err, errTestWallet)
+	})
+
+	t.Run("sign action fails and the action is aborted", func(t *testing.T) {
+		testWallet := newTestAdvertiserWallet(t)
+		advertiser := setupAdvertiserWithWallet(t, testWallet)
+		mockRevocationActions(t, testWallet)
+		testWallet.OnSignAction().ReturnError(errTestWallet)
+		aborted := false
+		testWallet.OnAbortAction().Do(func(_ context.Context, args wallet.AbortActionArgs, _ string) (*wallet.AbortActionResult, error) {
+			aborted = true
+			assert.Equal(t, []byte("revocation"), args.Reference)
+			return &wallet.AbortActionResult{Aborted: true}, nil
+		})
+
+		adBEEF, _ := createTestAdvertisementBEEF(t, testWallet, overlay.ProtocolSHIP, "tm_payments", false)
+		_, err := advertiser.RevokeAdvertisements([]*oa.Advertisement{{Protocol: overlay.ProtocolSHIP, Beef: adBEEF}})
+		require.ErrorIs(t, err, errTestWallet)
+		assert.True(t, aborted)
+	})
+}
+
+func TestWalletAdvertiser_ContextPropagation(t *testing.T) {
+	type ctxKey struct{}
+
+	t.Run("create advertisements passes the context to the wallet", func(t *testing.T) {
+		testWallet := newTestAdvertiserWallet(t)
+		advertiser := setupAdvertiserWithWallet(t, testWallet)
+		testWallet.OnCreateAction().Do(func(ctx context.Context, _ wallet.CreateActionArgs, _ string) (*wallet.CreateActionResult, error) {
+			assert.Equal(t, "trace", ctx.Value(ctxKey{}))
+			return nil, errTestWallet
+		})
+
+		ctx := context.WithValue(context.Background(), ctxKey{}, "trace")
+		_, err := advertiser.CreateAdvertisementsContext(ctx, []*oa.AdvertisementData{{Protocol: overlay.ProtocolSHIP, TopicOrServiceName: "tm_payments"}})
+		require.ErrorIs(t, err, errTestWallet)
+	})
+
+	t.Run("revocation is aborted after the context is cancelled", func(t *testing.T) {
+		testWallet := newTestAdvertiserWallet(t)
+		advertiser := setupAdvertiserWithWallet(t, testWallet)
+		mockRevocationActions(t, testWallet)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		testWallet.OnSignAction().Do(func(ctx context.Context, _ wallet.SignActionArgs, _ string) (*wallet.SignActionResult, error) {
+			cancel()
+			return nil, ctx.Err()
+		})
+		aborted := false
+		testWallet.OnAbortAction().Do(func(ctx context.Context, _ wallet.AbortActionArgs, _ string) (*wallet.AbortActionResult, error) {
+			aborted = true
+			assert.NoError(t, ctx.Err())
+			return &wallet.AbortActionResult{Aborted: true}, nil
+		})
+
+		adBEEF, _ := createTestAdvertisementBEEF(t, testWallet, overlay.ProtocolSHIP, "tm_payments", false)
+		_, err := advertiser.RevokeAdvertisementsContext(ctx, []*oa.Advertisement{{Protocol: overlay.ProtocolSHIP, Beef: adBEEF}})
+		require.ErrorIs(t, err, context.Canceled)
+		assert.True(t, aborted)
+	})
+
+	t.Run("cancelled lookups are reported", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			// The server only notices the client going away once the body has been read
+			_, _ = io.Copy(io.Discard, r.Body)
+			<-r.Context().Done()
+		}))
+		defer server.Close()
+
+		advertiser, err := NewWalletAdvertiser(
+			"main",
+			"0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+			"https://storage.example.com",
+			"https://service.example.com/",
+			&types.LookupResolverConfig{HTTPSEndpoint: stringPtr(server.URL), MaxRetries: intPtr(3)},
+		)
+		require.NoError(t, err)
+		advertiser.SetSkipStorageValidation(true)
+		require.NoError(t, advertiser.Init())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err = advertiser.FindAllAdvertisementsContext(ctx, overlay.ProtocolSHIP)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("init stops checking storage when the context is cancelled", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+		defer server.Close()
+
+		advertiser, err := NewWalletAdvertiser(
+			"main",
+			"0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+			server.URL,
+			"https://service.example.com/",
+			nil,
+		)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err = advertiser.InitContext(ctx)
+		require.ErrorIs(t, err, context.Canceled)
+		assert.False(t, advertiser.IsInitialized())
+	})
+}
+
 type MockFinder struct{}
 
 func (m *MockFinder) Advertisements(protocol overlay.Protocol) ([]*oa.Advertisement, error) {
@@ -359,9 +850,8 @@ func (m *MockFinder) CreateAdvertisements(adsData []*oa.AdvertisementData, _, _
 	// Create mock topics based on the advertisements
 	var topics []string
 	for _, adData := range adsData {
-		switch adData.Protocol {
-		case overlay.ProtocolSHIP, overlay.ProtocolSLAP:
-			topics = append(topics, "tm_"+adData.TopicOrServiceName)
+		if topic, err := advertisementTopic(adData.Protocol); err == nil {
+			topics = append(topics, topic)
 		}
 	}
 
@@ -439,58 +929,43 @@ func createMockPushDropScript(adData *oa.AdvertisementData) *script.Script {
 }
 
 func TestWalletAdvertiser_ParseAdvertisement(t *testing.T) {
-	t.Run("Properly parses an advertisement script", func(t *testing.T) {
-		// Create advertiser with a valid test private key
-		testPrivateKey := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
-		advertiser, err := NewWalletAdvertiser(
-			"test",
-			testPrivateKey,
-			"https://fake-storage-url.com",
-			"https://advertise-me.com/",
-			nil,
-		)
-		require.NoError(t, err)
-
-		advertiser.SetSkipStorageValidation(true)
-		advertiser.Finder = &MockFinder{}
+	testWallet := newTestAdvertiserWallet(t)
+	advertiser := setupAdvertiserWithWallet(t, testWallet)
 
-		err = advertiser.Init()
-		require.NoError(t, err)
-
-		// Create an advertisement first (matching TypeScript test)
-		adsData := []*oa.AdvertisementData{
-			{
-				Protocol:           overlay.ProtocolSHIP,
-				TopicOrServiceName: "tm_meter",
-			},
-		}
-
-		result, err := advertiser.CreateAdvertisements(adsData)
-		require.NoError(t, err)
-		require.NotNil(t, result)
-
-		beef, err := transaction.NewBeefFromBytes(result.Beef)
-		require.NoError(t, err)
+	t.Run("Properly parses an advertisement script", func(t *testing.T) {
+		_, tx := createTestAdvertisementBEEF(t, testWallet, overlay.ProtocolSHIP, "tm_meter", false)
 
-		// Parse the advertisement script
-		var tx *transaction.Transaction
-		for _, beefTx := range beef.Transactions {
-			if len(beefTx.Transaction.Outputs) > 0 {
-				tx = beefTx.Transaction
-				break
-			}
-		}
 		parsedAd, err := advertiser.ParseAdvertisement(tx.Outputs[0].LockingScript)
 
 		require.NoError(t, err)
 		assert.NotNil(t, parsedAd)
 		assert.Equal(t, overlay.ProtocolSHIP, parsedAd.Protocol)
 		assert.Equal(t, "tm_meter", parsedAd.TopicOrService)
-		assert.Equal(t, "https://advertise-me.com", parsedAd.Domain)
-		assert.Equal(t, "02fe8d1eb1bcb3432b1db5833ff5f2226d9cb5e65cee430558c18ed3a3c86ce1af", parsedAd.IdentityKey)
+		assert.Equal(t, "https://service.example.com/", parsedAd.Domain)
+		assert.Equal(t, advertiser.identityKey, parsedAd.IdentityKey)
 	})
 
-	// TODO: Sad testing (matching TypeScript comment)
+	t.Run("Rejects invalid advertisement scripts", func(t *testing.T) {
+		_, wrongPrefix := createTestAdvertisementBEEF(t, testWallet, overlay.ProtocolSLAP, "tm_meter", false)
+
+		tests := []struct {
+			name        string
+			script      *script.Script
+			expectedErr error
+		}{
+			{"Not a PushDrop script", script.NewFromBytes([]byte{0x01}), utils.ErrTokenNotPushDrop},
+			{"Unlinked signature", createMockPushDropScript(&oa.AdvertisementData{Protocol: overlay.ProtocolSHIP, TopicOrServiceName: "tm_meter"}), utils.ErrTokenSignature},
+			{"Topic of the other protocol", wrongPrefix.Outputs[0].LockingScript, utils.ErrTokenTopicOrService},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := advertiser.ParseAdvertisement(tt.script)
+				require.ErrorIs(t, err, tt.expectedErr)
+				require.ErrorIs(t, err, utils.ErrInvalidAdvertisementToken)
+			})
+		}
+	})
 }
 
 func TestWalletAdvertiser_MethodsRequireInitialization(t *testing.T) {
@@ -524,13 +999,173 @@ func TestWalletAdvertiser_MethodsRequireInitialization(t *testing.T) {
 
 // Helper functions
 
+// errTestWallet is returned by mocked wallet methods
+var errTestWallet = errors.New("wallet error")
+
+// newTestAdvertiserWallet returns a test wallet holding the key of setupInitializedAdvertiser
+func newTestAdvertiserWallet(t *testing.T) *wallet.TestWallet {
+	privKey, err := ec.PrivateKeyFromHex("0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+	require.NoError(t, err)
+
+	return wallet.NewTestWallet(t, privKey)
+}
+
+// createTestAdvertisementBEEF creates a transaction whose first output is an advertisement token locked
+// by the wallet, and returns its BEEF (V2 if requested, V1 otherwise) along with the transaction.
+func createTestAdvertisementBEEF(t *testing.T, w wallet.Interface, protocol overlay.Protocol, topicOrService string, beefV2 bool) ([]byte, *transaction.Transaction) {
+	t.Helper()
+	return createTestAdvertisementBEEFForDomain(t, w, protocol, "https://service.example.com/", topicOrService, beefV2)
+}
+
+// createTestAdvertisementBEEFForDomain is createTestAdvertisementBEEF advertising the given domain
+func createTestAdvertisementBEEFForDomain(t *testing.T, w wallet.Interface, protocol overlay.Protocol, domain, topicOrService string, beefV2 bool) ([]byte, *transaction.Transaction) {
+	t.Helper()
+
+	identityKey, err := w.GetPublicKey(context.Background(), wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+	walletProtocol, err := advertisementProtocol(protocol)
+	require.NoError(t, err)
+
+	pd := pushdrop.PushDrop{Wallet: w}
+	lockingScript, err := pd.Lock(
+		context.Background(),
+		[][]byte{
+			[]byte(protocol),
+			identityKey.PublicKey.Compressed(),
+			[]byte(domain),
+			[]byte(topicOrService),
+		},
+		walletProtocol,
+		adTokenKeyID,
+		wallet.Counterparty{Type: wallet.CounterpartyTypeAnyone},
+		true,
+		true,
+		pushdrop.LockBefore,
+	)
+	require.NoError(t, err)
+
+	// The advertisement spends a funding transaction so its BEEF carries an ancestor
+	funding := transaction.NewTransaction()
+	funding.AddOutput(&transaction.TransactionOutput{Satoshis: 1000, LockingScript: testChangeScript(t)})
+
+	adTx := transaction.NewTransaction()
+	adTx.AddInput(&transaction.TransactionInput{
+		SourceTXID:        funding.TxID(),
+		SourceTransaction: funding,
+		SequenceNumber:    transaction.DefaultSequenceNumber,
+		UnlockingScript:   &script.Script{},
+	})
+	adTx.AddOutput(&transaction.TransactionOutput{Satoshis: AdTokenValue, LockingScript: lockingScript})
+
+	var beefBytes []byte
+	if beefV2 {
+		beef, err := transaction.NewBeefFromTransaction(adTx)
+		require.NoError(t, err)
+		beefBytes, err = beef.Bytes()
+		require.NoError(t, err)
+	} else {
+		beefBytes, err = adTx.BEEF()
+		require.NoError(t, err)
+	}
+
+	return beefBytes, adTx
+}
+
+// mockIssuanceAction makes the test wallet fund issuance transactions: CreateAction returns a signed
+// transaction holding the requested outputs followed by a change output.
+func mockIssuanceAction(t *testing.T, w *wallet.TestWallet) {
+	w.OnCreateAction().Do(func(_ context.Context, args wallet.CreateActionArgs, _ string) (*wallet.CreateActionResult, error) {
+		require.NotNil(t, args.Options)
+		require.NotNil(t, args.Options.RandomizeOutputs)
+		assert.False(t, *args.Options.RandomizeOutputs)
+
+		funding := transaction.NewTransaction()
+		funding.AddOutput(&transaction.TransactionOutput{Satoshis: 1000, LockingScript: testChangeScript(t)})
+
+		tx := transaction.NewTransaction()
+		tx.AddInput(&transaction.TransactionInput{
+			SourceTXID:        funding.TxID(),
+			SourceTransaction: funding,
+			SequenceNumber:    transaction.DefaultSequenceNumber,
+			UnlockingScript:   &script.Script{},
+		})
+		for _, output := range args.Outputs {
+			tx.AddOutput(&transaction.TransactionOutput{
+				Satoshis:      output.Satoshis,
+				LockingScript: script.NewFromBytes(output.LockingScript),
+			})
+		}
+		tx.AddOutput(&transaction.TransactionOutput{Satoshis: 900, LockingScript: testChangeScript(t)})
+
+		beef, err := tx.AtomicBEEF(false)
+		require.NoError(t, err)
+		return &wallet.CreateActionResult{Txid: *tx.TxID(), Tx: beef}, nil
+	})
+}
+
+// mockRevocationActions makes the test wallet build revocation transactions the way a funded wallet
+// would: CreateAction returns a signable transaction spending the requested inputs plus a change
+// output, and SignAction applies the provided unlocking scripts to it.
+func mockRevocationActions(t *testing.T, w *wallet.TestWallet) {
+	var pending *transaction.Transaction
+
+	w.OnCreateAction().Do(func(_ context.Context, args wallet.CreateActionArgs, _ string) (*wallet.CreateActionResult, error) {
+		inputBEEF, err := transaction.NewBeefFromBytes(args.InputBEEF)
+		require.NoError(t, err)
+
+		tx := transaction.NewTransaction()
+		for _, input := range args.Inputs {
+			source := inputBEEF.FindTransactionByHash(&input.Outpoint.Txid)
+			require.NotNil(t, source, "input BEEF is missing %s", input.Outpoint.String())
+			tx.AddInput(&transaction.TransactionInput{
+				SourceTXID:        &input.Outpoint.Txid,
+				SourceTxOutIndex:  input.Outpoint.Index,
+				SourceTransaction: source,
+				SequenceNumber:    transaction.DefaultSequenceNumber,
+			})
+		}
+		tx.AddOutput(&transaction.TransactionOutput{Satoshis: 1, LockingScript: testChangeScript(t)})
+		pending = tx
+
+		signable, err := tx.AtomicBEEF(false)
+		require.NoError(t, err)
+		return &wallet.CreateActionResult{
+			SignableTransaction: &wallet.SignableTransaction{Tx: signable, Reference: []byte("revocation")},
+		}, nil
+	})
+
+	w.OnSignAction().Do(func(_ context.Context, args wallet.SignActionArgs, _ string) (*wallet.SignActionResult, error) {
+		require.NotNil(t, pending)
+		for index, spend := range args.Spends {
+			pending.Inputs[index].UnlockingScript = script.NewFromBytes(spend.UnlockingScript)
+		}
+
+		signed, err := pending.AtomicBEEF(false)
+		require.NoError(t, err)
+		return &wallet.SignActionResult{Txid: *pending.TxID(), Tx: signed}, nil
+	})
+}
+
+// testChangeScript returns a P2PKH locking script paying to a fixed address
+func testChangeScript(t *testing.T) *script.Script {
+	s, err := script.NewFromHex("76a914000102030405060708090a0b0c0d0e0f1011121388ac")
+	require.NoError(t, err)
+	return s
+}
+
 func setupInitializedAdvertiser(t *testing.T) *WalletAdvertiser {
-	advertiser, err := NewWalletAdvertiser(
+	return setupAdvertiserWithWallet(t, newTestAdvertiserWallet(t))
+}
+
+// setupAdvertiserWithWallet returns an initialized advertiser creating its transactions through w
+func setupAdvertiserWithWallet(t *testing.T, w wallet.Interface) *WalletAdvertiser {
+	advertiser, err := NewWalletAdvertiserWithOptions(
+		context.Background(),
 		"main",
 		"0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
 		"https://storage.example.com",
 		"https://service.example.com/",
-		nil,
+		WithWallet(w),
 	)
 	require.NoError(t, err)
 
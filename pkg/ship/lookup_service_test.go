@@ -6,13 +6,19 @@ import (
 	"encoding/json"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/types"
+	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/utils"
 	"github.com/bsv-blockchain/go-overlay-services/pkg/core/engine"
 	"github.com/bsv-blockchain/go-sdk/chainhash"
+	"github.com/bsv-blockchain/go-sdk/overlay"
 	"github.com/bsv-blockchain/go-sdk/overlay/lookup"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
 	"github.com/bsv-blockchain/go-sdk/script"
 	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/bsv-blockchain/go-sdk/transaction/template/pushdrop"
+	"github.com/bsv-blockchain/go-sdk/wallet"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -20,6 +26,13 @@ import (
 
 const TxID = "bdf1e48e845a65ba8c139c9b94844de30716f38d53787ba0a435e8705c4216d5"
 
+// Stored txids returned by the mocked storage in lookup tests
+const (
+	testTxidA = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	testTxidB = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	testTxidC = "cccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccc"
+)
+
 // Static error variables for testing
 var (
 	errTestStorage = errors.New("storage error")
@@ -32,9 +45,9 @@ type MockStorage struct {
 	mock.Mock
 }
 
-func (m *MockStorage) StoreSHIPRecord(ctx context.Context, txid string, outputIndex int, identityKey, domain, topic string) error {
+func (m *MockStorage) StoreSHIPRecord(ctx context.Context, txid string, outputIndex int, identityKey, domain, topic string) (bool, error) {
 	args := m.Called(ctx, txid, outputIndex, identityKey, domain, topic)
-	return args.Error(0)
+	return args.Bool(0), args.Error(1)
 }
 
 func (m *MockStorage) DeleteSHIPRecord(ctx context.Context, txid string, outputIndex int) error {
@@ -47,16 +60,62 @@ func (m *MockStorage) FindRecord(ctx context.Context, query types.SHIPQuery) ([]
 	return args.Get(0).([]types.UTXOReference), args.Error(1)
 }
 
+func (m *MockStorage) FindSHIPRecords(ctx context.Context, query types.SHIPQuery) ([]types.SHIPRecord, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).([]types.SHIPRecord), args.Error(1)
+}
+
 func (m *MockStorage) FindAll(ctx context.Context, limit, skip *int, sortOrder *types.SortOrder) ([]types.UTXOReference, error) {
 	args := m.Called(ctx, limit, skip, sortOrder)
 	return args.Get(0).([]types.UTXOReference), args.Error(1)
 }
 
+func (m *MockStorage) CountSHIPRecords(ctx context.Context, query types.SHIPQuery, groupBy types.AggregateMode) ([]types.AggregateCount, error) {
+	args := m.Called(ctx, query, groupBy)
+	return args.Get(0).([]types.AggregateCount), args.Error(1)
+}
+
+func (m *MockStorage) UpdateSHIPRecordBlock(ctx context.Context, txid string, blockHeight uint32, blockHash string) error {
+	args := m.Called(ctx, txid, blockHeight, blockHash)
+	return args.Error(0)
+}
+
+func (m *MockStorage) ResetSHIPRecordBlocks(ctx context.Context, fromHeight uint32) (int64, error) {
+	args := m.Called(ctx, fromHeight)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *MockStorage) EnsureIndexes(ctx context.Context) error {
 	args := m.Called(ctx)
 	return args.Error(0)
 }
 
+// mockChainTracker is a chain tracker reporting a fixed chain height
+type mockChainTracker struct {
+	height uint32
+	err    error
+}
+
+func (m *mockChainTracker) IsValidRootForHeight(_ context.Context, _ *chainhash.Hash, _ uint32) (bool, error) {
+	return true, nil
+}
+
+func (m *mockChainTracker) CurrentHeight(_ context.Context) (uint32, error) {
+	return m.height, m.err
+}
+
+// mockBlockHashTracker is a chain tracker that also resolves block hashes
+type mockBlockHashTracker struct {
+	mockChainTracker
+
+	hashes  map[uint32]*chainhash.Hash
+	hashErr error
+}
+
+func (m *mockBlockHashTracker) BlockHash(_ context.Context, height uint32) (*chainhash.Hash, error) {
+	return m.hashes[height], m.hashErr
+}
+
 // Mock PushDropDecoder and Utils are no longer needed since we use real implementations
 
 // Test helper functions
@@ -67,6 +126,20 @@ func createTestSHIPLookupService() (*LookupService, *MockStorage) {
 	return service, mockStorage
 }
 
+// assertFormulaAnswer asserts that answer is a formula answer with one outpoint per expected UTXO
+func assertFormulaAnswer(t *testing.T, expected []types.UTXOReference, answer *lookup.LookupAnswer) {
+	t.Helper()
+
+	require.NotNil(t, answer)
+	assert.Equal(t, lookup.AnswerTypeFormula, answer.Type)
+	require.Len(t, answer.Formulas, len(expected))
+	for i, utxo := range expected {
+		require.NotNil(t, answer.Formulas[i].Outpoint)
+		assert.Equal(t, utxo.Txid, hex.EncodeToString(answer.Formulas[i].Outpoint.Txid[:]))
+		assert.Equal(t, uint32(utxo.OutputIndex), answer.Formulas[i].Outpoint.Index) //nolint:gosec // test indexes are small
+	}
+}
+
 // createValidPushDropScript creates a valid PushDrop script with the specified fields
 func createValidPushDropScript(fields [][]byte) string {
 	// Create a valid public key (33 bytes) - this is a known valid public key
@@ -110,6 +183,33 @@ func createValidPushDropScript(fields [][]byte) string {
 	return s.String()
 }
 
+// createSignedAdvertisementScript creates an advertisement token locked and signed by a test wallet,
+// and returns it along with the hex-encoded identity key of the wallet.
+func createSignedAdvertisementScript(t testing.TB, protocol overlay.Protocol, domain, topicOrService string) (*script.Script, string) {
+	t.Helper()
+
+	privKey, err := ec.PrivateKeyFromHex("0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+	require.NoError(t, err)
+	w := wallet.NewTestWallet(t, privKey)
+	identityKey, err := w.GetPublicKey(context.Background(), wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+
+	pd := pushdrop.PushDrop{Wallet: w}
+	lockingScript, err := pd.Lock(
+		context.Background(),
+		[][]byte{[]byte(protocol), identityKey.PublicKey.Compressed(), []byte(domain), []byte(topicOrService)},
+		wallet.Protocol{SecurityLevel: wallet.SecurityLevelEveryAppAndCounterparty, Protocol: string(protocol.ID())},
+		utils.AdvertisementTokenKeyID,
+		wallet.Counterparty{Type: wallet.CounterpartyTypeAnyone},
+		true,
+		true,
+		pushdrop.LockBefore,
+	)
+	require.NoError(t, err)
+
+	return lockingScript, identityKey.PublicKey.ToDERHex()
+}
+
 // createValidPushDropResult helper removed - using real PushDrop scripts instead
 
 // Test NewLookupService
@@ -128,16 +228,8 @@ func TestNewSHIPLookupService(t *testing.T) {
 func TestOutputAdmittedByTopic_Success(t *testing.T) {
 	service, mockStorage := createTestSHIPLookupService()
 
-	// Create valid PushDrop script with SHIP data
-	fields := [][]byte{
-		[]byte("SHIP"),                // Protocol identifier
-		{0x01, 0x02, 0x03, 0x04},      // Identity key bytes
-		[]byte("https://example.com"), // Domain
-		[]byte("tm_bridge"),           // Topic
-	}
-	validScriptHex := createValidPushDropScript(fields)
-	scriptObj, err := script.NewFromHex(validScriptHex)
-	require.NoError(t, err)
+	// Create a signed SHIP advertisement token
+	scriptObj, identityKey := createSignedAdvertisementScript(t, overlay.ProtocolSHIP, "https://example.com", "tm_bridge")
 
 	// Create outpoint
 	txidBytes, err := hex.DecodeString(TxID)
@@ -157,7 +249,7 @@ func TestOutputAdmittedByTopic_Success(t *testing.T) {
 	}
 
 	// Set up mock for storage (txid is now hex-encoded from outpoint)
-	mockStorage.On("StoreSHIPRecord", mock.Anything, TxID, 0, "01020304", "https://example.com", "tm_bridge").Return(nil)
+	mockStorage.On("StoreSHIPRecord", mock.Anything, TxID, 0, identityKey, "https://example.com", "tm_bridge").Return(true, nil)
 
 	// Execute
 	err = service.OutputAdmittedByTopic(context.Background(), payload)
@@ -167,6 +259,32 @@ func TestOutputAdmittedByTopic_Success(t *testing.T) {
 	mockStorage.AssertExpectations(t)
 }
 
+func TestOutputAdmittedByTopic_ReplayedAdmission(t *testing.T) {
+	service, mockStorage := createTestSHIPLookupService()
+
+	// Create a signed SHIP advertisement token
+	scriptObj, identityKey := createSignedAdvertisementScript(t, overlay.ProtocolSHIP, "https://example.com", "tm_bridge")
+
+	txidBytes, err := hex.DecodeString(TxID)
+	require.NoError(t, err)
+	var txidArray [32]byte
+	copy(txidArray[:], txidBytes)
+
+	payload := &engine.OutputAdmittedByTopic{
+		Topic:         Topic,
+		Outpoint:      &transaction.Outpoint{Txid: txidArray, Index: 0},
+		LockingScript: scriptObj,
+	}
+
+	// The record is already present, e.g. because the engine replayed the admission after a restart
+	mockStorage.On("StoreSHIPRecord", mock.Anything, TxID, 0, identityKey, "https://example.com", "tm_bridge").Return(false, nil)
+
+	err = service.OutputAdmittedByTopic(context.Background(), payload)
+
+	require.NoError(t, err)
+	mockStorage.AssertExpectations(t)
+}
+
 func TestOutputAdmittedByTopic_IgnoreNonSHIPTopic(t *testing.T) {
 	service, _ := createTestSHIPLookupService()
 
@@ -220,14 +338,13 @@ func TestOutputAdmittedByTopic_PushDropDecodeError(t *testing.T) {
 	}
 
 	err = service.OutputAdmittedByTopic(context.Background(), payload)
-	require.Error(t, err)
-	assert.Contains(t, err.Error(), "failed to decode PushDrop locking script")
+	require.ErrorIs(t, err, utils.ErrTokenNotPushDrop)
 }
 
 func TestOutputAdmittedByTopic_InsufficientFields(t *testing.T) {
 	service, _ := createTestSHIPLookupService()
 
-	// Create PushDrop script with only 2 fields instead of required 4
+	// Create PushDrop script with only 2 fields instead of required 5
 	fields := [][]byte{
 		[]byte("SHIP"),
 		{0x01, 0x02, 0x03, 0x04},
@@ -254,8 +371,7 @@ func TestOutputAdmittedByTopic_InsufficientFields(t *testing.T) {
 	}
 
 	err = service.OutputAdmittedByTopic(context.Background(), payload)
-	require.Error(t, err)
-	assert.Contains(t, err.Error(), "expected at least 4 fields")
+	require.ErrorIs(t, err, utils.ErrTokenFieldCount)
 	assert.Contains(t, err.Error(), "got 2")
 }
 
@@ -294,6 +410,44 @@ func TestOutputAdmittedByTopic_IgnoreNonSHIPProtocol(t *testing.T) {
 	require.NoError(t, err) // Should silently ignore non-SHIP protocols
 }
 
+func TestOutputAdmittedByTopic_RejectsInvalidTokens(t *testing.T) {
+	unsigned, err := script.NewFromHex(createValidPushDropScript([][]byte{
+		[]byte("SHIP"),
+		{0x01, 0x02, 0x03, 0x04},
+		[]byte("https://example.com"),
+		[]byte("tm_bridge"),
+		{0x30, 0x44, 0x02, 0x20, 0x01, 0x02, 0x03},
+	}))
+	require.NoError(t, err)
+	localhost, _ := createSignedAdvertisementScript(t, overlay.ProtocolSHIP, "https://localhost", "tm_bridge")
+	otherPrefix, _ := createSignedAdvertisementScript(t, overlay.ProtocolSHIP, "https://example.com", "ls_bridge")
+
+	tests := []struct {
+		name          string
+		lockingScript *script.Script
+		expectedErr   error
+	}{
+		{"Unlinked signature", unsigned, utils.ErrTokenSignature},
+		{"Non-advertisable URI", localhost, utils.ErrTokenURI},
+		{"Name of the other protocol", otherPrefix, utils.ErrTokenTopicOrService},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The storage mock has no expectations, so storing the record would fail the test
+			service, mockStorage := createTestSHIPLookupService()
+
+			err := service.OutputAdmittedByTopic(context.Background(), &engine.OutputAdmittedByTopic{
+				Topic:         Topic,
+				Outpoint:      &transaction.Outpoint{Index: 0},
+				LockingScript: tt.lockingScript,
+			})
+			require.ErrorIs(t, err, tt.expectedErr)
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
+
 // Test OutputSpent
 
 func TestOutputSpent_Success(t *testing.T) {
@@ -400,23 +554,88 @@ func TestLookup_LegacyFindAll(t *testing.T) {
 	}
 
 	expectedResults := []types.UTXOReference{
-		{Txid: "abc123", OutputIndex: 0},
-		{Txid: "def456", OutputIndex: 1},
+		{Txid: testTxidA, OutputIndex: 0},
+		{Txid: testTxidB, OutputIndex: 1},
 	}
 
 	mockStorage.On("FindAll", mock.Anything, (*int)(nil), (*int)(nil), (*types.SortOrder)(nil)).Return(expectedResults, nil)
 
 	results, err := service.Lookup(context.Background(), question)
 	require.NoError(t, err)
-	assert.Equal(t, lookup.AnswerTypeFreeform, results.Type)
-	if utxos, ok := results.Result.([]types.UTXOReference); ok {
-		assert.Equal(t, expectedResults, utxos)
-	} else {
-		t.Errorf("Expected UTXOReference slice, got %T", results.Result)
+	assertFormulaAnswer(t, expectedResults, results)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestLookup_FreeformAnswers(t *testing.T) {
+	service, mockStorage := createTestSHIPLookupService()
+	service.SetFreeformAnswers(true)
+
+	question := &lookup.LookupQuestion{
+		Service: Service,
+		Query:   json.RawMessage(`"findAll"`),
 	}
+
+	expectedResults := []types.UTXOReference{
+		{Txid: testTxidA, OutputIndex: 0},
+		{Txid: testTxidB, OutputIndex: 1},
+	}
+
+	mockStorage.On("FindAll", mock.Anything, (*int)(nil), (*int)(nil), (*types.SortOrder)(nil)).Return(expectedResults, nil)
+
+	results, err := service.Lookup(context.Background(), question)
+	require.NoError(t, err)
+	assert.Equal(t, lookup.AnswerTypeFreeform, results.Type)
+	assert.Equal(t, expectedResults, results.Result)
+	assert.Empty(t, results.Formulas)
 	mockStorage.AssertExpectations(t)
 }
 
+func TestLookup_FormulaOutpointMatchesAdmittedOutpoint(t *testing.T) {
+	service, mockStorage := createTestSHIPLookupService()
+
+	txidBytes, err := hex.DecodeString(TxID)
+	require.NoError(t, err)
+	var txidArray [32]byte
+	copy(txidArray[:], txidBytes)
+
+	// Txids are stored as the hex encoding of the admitted outpoint's txid bytes
+	mockStorage.On("FindAll", mock.Anything, (*int)(nil), (*int)(nil), (*types.SortOrder)(nil)).
+		Return([]types.UTXOReference{{Txid: TxID, OutputIndex: 2}}, nil)
+
+	results, err := service.Lookup(context.Background(), &lookup.LookupQuestion{
+		Service: Service,
+		Query:   json.RawMessage(`"findAll"`),
+	})
+	require.NoError(t, err)
+	require.Len(t, results.Formulas, 1)
+	assert.Equal(t, transaction.Outpoint{Txid: txidArray, Index: 2}, *results.Formulas[0].Outpoint)
+}
+
+func TestLookup_InvalidStoredUTXOReference(t *testing.T) {
+	tests := []struct {
+		name string
+		utxo types.UTXOReference
+	}{
+		{name: "non-hex txid", utxo: types.UTXOReference{Txid: "not-hex", OutputIndex: 0}},
+		{name: "short txid", utxo: types.UTXOReference{Txid: "abc123", OutputIndex: 0}},
+		{name: "negative output index", utxo: types.UTXOReference{Txid: testTxidA, OutputIndex: -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockStorage := createTestSHIPLookupService()
+			mockStorage.On("FindAll", mock.Anything, (*int)(nil), (*int)(nil), (*types.SortOrder)(nil)).
+				Return([]types.UTXOReference{tt.utxo}, nil)
+
+			_, err := service.Lookup(context.Background(), &lookup.LookupQuestion{
+				Service: Service,
+				Query:   json.RawMessage(`"findAll"`),
+			})
+			require.ErrorIs(t, err, errInvalidUTXOReference)
+		})
+	}
+}
+
 func TestLookup_NilQuery(t *testing.T) {
 	service, _ := createTestSHIPLookupService()
 
@@ -479,19 +698,14 @@ func TestLookup_ObjectQuery_FindAll(t *testing.T) {
 	}
 
 	expectedResults := []types.UTXOReference{
-		{Txid: "abc123", OutputIndex: 0},
+		{Txid: testTxidA, OutputIndex: 0},
 	}
 
 	mockStorage.On("FindAll", mock.Anything, &limit, &skip, &sortOrder).Return(expectedResults, nil)
 
 	results, err := service.Lookup(context.Background(), question)
 	require.NoError(t, err)
-	assert.Equal(t, lookup.AnswerTypeFreeform, results.Type)
-	if utxos, ok := results.Result.([]types.UTXOReference); ok {
-		assert.Equal(t, expectedResults, utxos)
-	} else {
-		t.Errorf("Expected UTXOReference slice, got %T", results.Result)
-	}
+	assertFormulaAnswer(t, expectedResults, results)
 	mockStorage.AssertExpectations(t)
 }
 
@@ -522,22 +736,294 @@ func TestLookup_ObjectQuery_SpecificQuery(t *testing.T) {
 	}
 
 	expectedResults := []types.UTXOReference{
-		{Txid: "abc123", OutputIndex: 0},
+		{Txid: testTxidA, OutputIndex: 0},
 	}
 
 	mockStorage.On("FindRecord", mock.Anything, expectedQuery).Return(expectedResults, nil)
 
+	results, err := service.Lookup(context.Background(), question)
+	require.NoError(t, err)
+	assertFormulaAnswer(t, expectedResults, results)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestLookup_ObjectQuery_IncludeRecords(t *testing.T) {
+	service, mockStorage := createTestSHIPLookupService()
+
+	domain := "https://example.com"
+	includeRecords := true
+
+	question := &lookup.LookupQuestion{
+		Service: Service,
+		Query:   json.RawMessage(`{"domain":"https://example.com","includeRecords":true}`),
+	}
+
+	expectedQuery := types.SHIPQuery{
+		Domain:         &domain,
+		IncludeRecords: &includeRecords,
+	}
+
+	expectedRecords := []types.SHIPRecord{
+		{
+			Txid:        testTxidA,
+			OutputIndex: 0,
+			IdentityKey: "01020304",
+			Domain:      domain,
+			Topic:       "tm_bridge",
+			CreatedAt:   time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	mockStorage.On("FindSHIPRecords", mock.Anything, expectedQuery).Return(expectedRecords, nil)
+
 	results, err := service.Lookup(context.Background(), question)
 	require.NoError(t, err)
 	assert.Equal(t, lookup.AnswerTypeFreeform, results.Type)
-	if utxos, ok := results.Result.([]types.UTXOReference); ok {
-		assert.Equal(t, expectedResults, utxos)
-	} else {
-		t.Errorf("Expected UTXOReference slice, got %T", results.Result)
+	assert.Equal(t, expectedRecords, results.Result)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestLookup_ObjectQuery_FindAllIncludeRecords(t *testing.T) {
+	service, mockStorage := createTestSHIPLookupService()
+
+	domain := "https://example.com"
+	limit := 10
+	question := &lookup.LookupQuestion{
+		Service: Service,
+		Query:   json.RawMessage(`{"findAll":true,"domain":"https://example.com","limit":10,"includeRecords":true}`),
 	}
+
+	// findAll ignores the domain filter but keeps pagination
+	expectedQuery := types.SHIPQuery{Limit: &limit}
+	expectedRecords := []types.SHIPRecord{{Txid: testTxidA, Domain: domain, Topic: "tm_bridge"}}
+
+	mockStorage.On("FindSHIPRecords", mock.Anything, expectedQuery).Return(expectedRecords, nil)
+
+	results, err := service.Lookup(context.Background(), question)
+	require.NoError(t, err)
+	assert.Equal(t, lookup.AnswerTypeFreeform, results.Type)
+	assert.Equal(t, expectedRecords, results.Result)
 	mockStorage.AssertExpectations(t)
 }
 
+func TestLookup_ValidationError_InvalidIncludeRecords(t *testing.T) {
+	service, _ := createTestSHIPLookupService()
+
+	question := &lookup.LookupQuestion{
+		Service: Service,
+		Query:   json.RawMessage(`{"includeRecords":"yes"}`),
+	}
+
+	_, err := service.Lookup(context.Background(), question)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid query format")
+}
+
+func TestLookup_CursorQuery(t *testing.T) {
+	createdAt := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	records := []types.SHIPRecord{
+		{Txid: testTxidA, OutputIndex: 0, IdentityKey: "01020304", Domain: "https://example.com", Topic: "tm_bridge", CreatedAt: createdAt.Add(time.Second)},
+		{Txid: testTxidB, OutputIndex: 1, IdentityKey: "01020304", Domain: "https://example.com", Topic: "tm_bridge", CreatedAt: createdAt},
+	}
+	nextCursor := types.RecordCursor{CreatedAt: createdAt, Txid: testTxidB, OutputIndex: 1}.Encode()
+
+	tests := []struct {
+		name          string
+		query         string
+		expectedQuery types.SHIPQuery
+		records       []types.SHIPRecord
+		expectedPage  types.SHIPPage
+	}{
+		{
+			name:          "full page returns the next cursor",
+			query:         `{"topics":["tm_bridge"],"limit":2,"cursor":""}`,
+			expectedQuery: types.SHIPQuery{Topics: []string{"tm_bridge"}, Limit: intPtr(2), Cursor: stringPtr("")},
+			records:       records,
+			expectedPage: types.SHIPPage{
+				UTXOs:      []types.UTXOReference{{Txid: testTxidA, OutputIndex: 0}, {Txid: testTxidB, OutputIndex: 1}},
+				NextCursor: nextCursor,
+			},
+		},
+		{
+			name:          "last page has no next cursor",
+			query:         `{"limit":3,"cursor":"` + nextCursor + `"}`,
+			expectedQuery: types.SHIPQuery{Limit: intPtr(3), Cursor: &nextCursor},
+			records:       records,
+			expectedPage: types.SHIPPage{
+				UTXOs: []types.UTXOReference{{Txid: testTxidA, OutputIndex: 0}, {Txid: testTxidB, OutputIndex: 1}},
+			},
+		},
+		{
+			name:          "empty page",
+			query:         `{"limit":2,"cursor":"` + nextCursor + `"}`,
+			expectedQuery: types.SHIPQuery{Limit: intPtr(2), Cursor: &nextCursor},
+			expectedPage:  types.SHIPPage{UTXOs: []types.UTXOReference{}},
+		},
+		{
+			name:          "include records",
+			query:         `{"limit":2,"cursor":"","includeRecords":true}`,
+			expectedQuery: types.SHIPQuery{Limit: intPtr(2), Cursor: stringPtr(""), IncludeRecords: boolPtr(true)},
+			records:       records,
+			expectedPage:  types.SHIPPage{Records: records, NextCursor: nextCursor},
+		},
+		{
+			name:          "findAll ignores filters but keeps the cursor",
+			query:         `{"findAll":true,"domain":"https://example.com","limit":2,"cursor":"` + nextCursor + `"}`,
+			expectedQuery: types.SHIPQuery{Limit: intPtr(2), Cursor: &nextCursor},
+			records:       records[:1],
+			expectedPage: types.SHIPPage{
+				UTXOs: []types.UTXOReference{{Txid: testTxidA, OutputIndex: 0}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockStorage := createTestSHIPLookupService()
+			mockStorage.On("FindSHIPRecords", mock.Anything, tt.expectedQuery).Return(tt.records, nil)
+
+			results, err := service.Lookup(context.Background(), &lookup.LookupQuestion{
+				Service: Service,
+				Query:   json.RawMessage(tt.query),
+			})
+			require.NoError(t, err)
+			assert.Equal(t, lookup.AnswerTypeFreeform, results.Type)
+			assert.Equal(t, tt.expectedPage, results.Result)
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLookup_MaxResultsPerIdentity(t *testing.T) {
+	createdAt := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	records := []types.SHIPRecord{
+		{Txid: testTxidA, OutputIndex: 0, IdentityKey: "01020304", Domain: "https://spam.example.com", Topic: "tm_bridge", CreatedAt: createdAt.Add(2 * time.Second)},
+		{Txid: testTxidB, OutputIndex: 1, IdentityKey: "01020304", Domain: "https://spam.example.com", Topic: "tm_bridge", CreatedAt: createdAt.Add(time.Second)},
+		{Txid: testTxidC, OutputIndex: 2, IdentityKey: "05060708", Domain: "https://example.com", Topic: "tm_bridge", CreatedAt: createdAt},
+	}
+	capped := []types.UTXOReference{{Txid: testTxidA, OutputIndex: 0}, {Txid: testTxidC, OutputIndex: 2}}
+
+	t.Run("filtered query", func(t *testing.T) {
+		service, mockStorage := createTestSHIPLookupService()
+		service.SetMaxResultsPerIdentity(1)
+		mockStorage.On("FindSHIPRecords", mock.Anything, types.SHIPQuery{Topics: []string{"tm_bridge"}}).Return(records, nil)
+
+		results, err := service.Lookup(context.Background(), &lookup.LookupQuestion{Service: Service, Query: json.RawMessage(`{"topics":["tm_bridge"]}`)})
+		require.NoError(t, err)
+		assertFormulaAnswer(t, capped, results)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("legacy findAll", func(t *testing.T) {
+		service, mockStorage := createTestSHIPLookupService()
+		service.SetMaxResultsPerIdentity(1)
+		mockStorage.On("FindSHIPRecords", mock.Anything, types.SHIPQuery{}).Return(records, nil)
+
+		results, err := service.Lookup(context.Background(), &lookup.LookupQuestion{Service: Service, Query: json.RawMessage(`"findAll"`)})
+		require.NoError(t, err)
+		assertFormulaAnswer(t, capped, results)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("findAll with pagination", func(t *testing.T) {
+		service, mockStorage := createTestSHIPLookupService()
+		service.SetMaxResultsPerIdentity(2)
+		mockStorage.On("FindSHIPRecords", mock.Anything, types.SHIPQuery{Limit: intPtr(3)}).Return(records, nil)
+
+		results, err := service.Lookup(context.Background(), &lookup.LookupQuestion{Service: Service, Query: json.RawMessage(`{"findAll":true,"limit":3}`)})
+		require.NoError(t, err)
+		assertFormulaAnswer(t, shipRecordsToUTXOReferences(records), results)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("include records", func(t *testing.T) {
+		service, mockStorage := createTestSHIPLookupService()
+		service.SetMaxResultsPerIdentity(1)
+		mockStorage.On("FindSHIPRecords", mock.Anything, types.SHIPQuery{IncludeRecords: boolPtr(true)}).Return(records, nil)
+
+		results, err := service.Lookup(context.Background(), &lookup.LookupQuestion{Service: Service, Query: json.RawMessage(`{"includeRecords":true}`)})
+		require.NoError(t, err)
+		assert.Equal(t, []types.SHIPRecord{records[0], records[2]}, results.Result)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("cursor page continues after the dropped records", func(t *testing.T) {
+		service, mockStorage := createTestSHIPLookupService()
+		service.SetMaxResultsPerIdentity(1)
+		mockStorage.On("FindSHIPRecords", mock.Anything, types.SHIPQuery{Limit: intPtr(2), Cursor: stringPtr("")}).Return(records[:2], nil)
+
+		results, err := service.Lookup(context.Background(), &lookup.LookupQuestion{Service: Service, Query: json.RawMessage(`{"limit":2,"cursor":""}`)})
+		require.NoError(t, err)
+		assert.Equal(t, types.SHIPPage{
+			UTXOs:      []types.UTXOReference{{Txid: testTxidA, OutputIndex: 0}},
+			NextCursor: types.RecordCursor{CreatedAt: records[1].CreatedAt, Txid: testTxidB, OutputIndex: 1}.Encode(),
+		}, results.Result)
+		mockStorage.AssertExpectations(t)
+	})
+}
+
+func TestLookup_CursorPagination(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	for i, txid := range []string{testTxidA, testTxidB, testTxidC} {
+		_, err := storage.StoreSHIPRecord(ctx, txid, i, "01020304", "https://example.com", "tm_bridge")
+		require.NoError(t, err)
+	}
+	service := NewLookupService(storage)
+
+	// findAll walks every record once, page by page
+	var seen []types.UTXOReference
+	cursor := ""
+	for range 3 {
+		answer, err := service.Lookup(ctx, &lookup.LookupQuestion{
+			Service: Service,
+			Query:   json.RawMessage(`{"findAll":true,"limit":2,"cursor":"` + cursor + `"}`),
+		})
+		require.NoError(t, err)
+		page, ok := answer.Result.(types.SHIPPage)
+		require.True(t, ok)
+		seen = append(seen, page.UTXOs...)
+		if cursor = page.NextCursor; cursor == "" {
+			break
+		}
+	}
+	assert.Empty(t, cursor)
+	assert.ElementsMatch(t, []types.UTXOReference{
+		{Txid: testTxidA, OutputIndex: 0},
+		{Txid: testTxidB, OutputIndex: 1},
+		{Txid: testTxidC, OutputIndex: 2},
+	}, seen)
+}
+
+func TestLookup_ValidationError_CursorWithoutLimit(t *testing.T) {
+	for _, query := range []string{
+		`{"cursor":""}`,
+		`{"findAll":true,"cursor":""}`,
+		`{"limit":0,"cursor":""}`,
+	} {
+		t.Run(query, func(t *testing.T) {
+			service, mockStorage := createTestSHIPLookupService()
+
+			_, err := service.Lookup(context.Background(), &lookup.LookupQuestion{Service: Service, Query: json.RawMessage(query)})
+			require.ErrorIs(t, err, errQueryCursorWithoutLimit)
+			mockStorage.AssertNotCalled(t, "FindSHIPRecords", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestLookup_ValidationError_InvalidCursor(t *testing.T) {
+	service, _ := createTestSHIPLookupService()
+
+	question := &lookup.LookupQuestion{
+		Service: Service,
+		Query:   json.RawMessage(`{"cursor":"not a cursor"}`),
+	}
+
+	_, err := service.Lookup(context.Background(), question)
+	require.ErrorIs(t, err, errQueryCursorInvalid)
+	require.ErrorIs(t, err, types.ErrInvalidCursor)
+}
+
 func TestLookup_ValidationError_NegativeLimit(t *testing.T) {
 	service, _ := createTestSHIPLookupService()
 
@@ -595,6 +1081,295 @@ func TestLookup_ValidationError_InvalidSortOrder(t *testing.T) {
 	assert.Contains(t, err.Error(), "query.sortOrder must be 'asc' or 'desc'")
 }
 
+func TestLookup_ObjectQuery_MultipleDomains(t *testing.T) {
+	service, mockStorage := createTestSHIPLookupService()
+
+	question := &lookup.LookupQuestion{
+		Service: Service,
+		Query:   json.RawMessage(`{"domains":["https://a.example.com","https://b.example.com"],"topics":["tm_bridge"]}`),
+	}
+
+	expectedQuery := types.SHIPQuery{
+		Domains: []string{"https://a.example.com", "https://b.example.com"},
+		Topics:  []string{"tm_bridge"},
+	}
+	expectedResults := []types.UTXOReference{{Txid: testTxidA, OutputIndex: 0}}
+
+	mockStorage.On("FindRecord", mock.Anything, expectedQuery).Return(expectedResults, nil)
+
+	results, err := service.Lookup(context.Background(), question)
+	require.NoError(t, err)
+	assertFormulaAnswer(t, expectedResults, results)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestLookup_ValidationError_InvalidDomains(t *testing.T) {
+	service, _ := createTestSHIPLookupService()
+
+	question := &lookup.LookupQuestion{
+		Service: Service,
+		Query:   json.RawMessage(`{"domains":["https://a.example.com",""]}`),
+	}
+
+	_, err := service.Lookup(context.Background(), question)
+	require.ErrorIs(t, err, errQueryDomainsInvalid)
+}
+
+func TestLookup_ObjectQuery_HostPatternAndSchemes(t *testing.T) {
+	service, mockStorage := createTestSHIPLookupService()
+
+	question := &lookup.LookupQuestion{
+		Service: Service,
+		Query:   json.RawMessage(`{"hostPattern":"*.example.com","schemes":["https+bsvauth","wss"]}`),
+	}
+
+	expectedQuery := types.SHIPQuery{
+		HostPattern: stringPtr("*.example.com"),
+		Schemes:     []string{"https+bsvauth", "wss"},
+	}
+	expectedResults := []types.UTXOReference{{Txid: testTxidA, OutputIndex: 0}}
+
+	mockStorage.On("FindRecord", mock.Anything, expectedQuery).Return(expectedResults, nil)
+
+	results, err := service.Lookup(context.Background(), question)
+	require.NoError(t, err)
+	assertFormulaAnswer(t, expectedResults, results)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestLookup_ValidationError_InvalidHostPatternAndSchemes(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected error
+	}{
+		{"inner wildcard", `{"hostPattern":"api.*.example.com"}`, errQueryHostPatternInvalid},
+		{"pattern with scheme", `{"hostPattern":"https://example.com"}`, errQueryHostPatternInvalid},
+		{"empty pattern", `{"hostPattern":""}`, errQueryHostPatternInvalid},
+		{"unsupported scheme", `{"schemes":["https","http"]}`, errQuerySchemesInvalid},
+		{"scheme with separator", `{"schemes":["https://"]}`, errQuerySchemesInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _ := createTestSHIPLookupService()
+
+			question := &lookup.LookupQuestion{
+				Service: Service,
+				Query:   json.RawMessage(tt.query),
+			}
+
+			_, err := service.Lookup(context.Background(), question)
+			require.ErrorIs(t, err, tt.expected)
+		})
+	}
+}
+
+func TestLookup_ObjectQuery_CreatedRange(t *testing.T) {
+	service, mockStorage := createTestSHIPLookupService()
+
+	question := &lookup.LookupQuestion{
+		Service: Service,
+		Query:   json.RawMessage(`{"createdAfter":"2024-01-01T00:00:00Z","createdBefore":"2024-01-02T02:00:00+02:00"}`),
+	}
+
+	createdAfter := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	createdBefore := time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC)
+	expectedResults := []types.UTXOReference{{Txid: testTxidA, OutputIndex: 0}}
+
+	mockStorage.On("FindRecord", mock.Anything, mock.MatchedBy(func(query types.SHIPQuery) bool {
+		return query.CreatedAfter != nil && query.CreatedAfter.Equal(createdAfter) &&
+			query.CreatedBefore != nil && query.CreatedBefore.Equal(createdBefore)
+	})).Return(expectedResults, nil)
+
+	results, err := service.Lookup(context.Background(), question)
+	require.NoError(t, err)
+	assertFormulaAnswer(t, expectedResults, results)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestLookup_ValidationError_InvalidCreatedRange(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"createdAfter after createdBefore", `{"createdAfter":"2024-01-02T00:00:00Z","createdBefore":"2024-01-01T00:00:00Z"}`},
+		{"empty range", `{"createdAfter":"2024-01-01T00:00:00Z","createdBefore":"2024-01-01T02:00:00+02:00"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _ := createTestSHIPLookupService()
+
+			_, err := service.Lookup(context.Background(), &lookup.LookupQuestion{
+				Service: Service,
+				Query:   json.RawMessage(tt.query),
+			})
+			require.ErrorIs(t, err, errQueryCreatedRangeInvalid)
+		})
+	}
+}
+
+func TestLookup_InvalidCreatedAfterFormat(t *testing.T) {
+	service, _ := createTestSHIPLookupService()
+
+	_, err := service.Lookup(context.Background(), &lookup.LookupQuestion{
+		Service: Service,
+		Query:   json.RawMessage(`{"createdAfter":"yesterday"}`),
+	})
+	require.ErrorContains(t, err, "invalid query format")
+}
+
+func TestLookup_AggregateQuery(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		expectedQuery types.SHIPQuery
+		groupBy       types.AggregateMode
+	}{
+		{
+			name:          "count by topic",
+			query:         `{"aggregate":"countByTopic"}`,
+			expectedQuery: types.SHIPQuery{Aggregate: aggregatePtr(types.AggregateCountByTopic)},
+			groupBy:       types.AggregateCountByTopic,
+		},
+		{
+			name:  "filtered count by identity key",
+			query: `{"aggregate":"countByIdentityKey","domain":"https://a.example.com","limit":5}`,
+			expectedQuery: types.SHIPQuery{
+				Aggregate: aggregatePtr(types.AggregateCountByIdentityKey),
+				Domain:    stringPtr("https://a.example.com"),
+				Limit:     intPtr(5),
+			},
+			groupBy: types.AggregateCountByIdentityKey,
+		},
+		{
+			name:          "findAll count by domain ignores filters and cursor",
+			query:         `{"aggregate":"countByDomain","findAll":true,"topics":["tm_bridge"],"cursor":""}`,
+			expectedQuery: types.SHIPQuery{},
+			groupBy:       types.AggregateCountByDomain,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockStorage := createTestSHIPLookupService()
+
+			counts := []types.AggregateCount{{Key: "tm_bridge", Count: 3}, {Key: "tm_sync", Count: 1}}
+			mockStorage.On("CountSHIPRecords", mock.Anything, tt.expectedQuery, tt.groupBy).Return(counts, nil)
+
+			answer, err := service.Lookup(context.Background(), &lookup.LookupQuestion{
+				Service: Service,
+				Query:   json.RawMessage(tt.query),
+			})
+			require.NoError(t, err)
+			assert.Equal(t, lookup.AnswerTypeFreeform, answer.Type)
+			assert.Equal(t, types.AggregateResult{Aggregate: tt.groupBy, Counts: counts}, answer.Result)
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLookup_ValidationError_InvalidAggregate(t *testing.T) {
+	for _, query := range []string{`{"aggregate":"countByService"}`, `{"aggregate":"sum"}`, `{"aggregate":""}`} {
+		t.Run(query, func(t *testing.T) {
+			service, _ := createTestSHIPLookupService()
+
+			_, err := service.Lookup(context.Background(), &lookup.LookupQuestion{
+				Service: Service,
+				Query:   json.RawMessage(query),
+			})
+			require.ErrorIs(t, err, errQueryAggregateInvalid)
+		})
+	}
+}
+
+func TestLookup_AggregateStorageError(t *testing.T) {
+	service, mockStorage := createTestSHIPLookupService()
+
+	mockStorage.On("CountSHIPRecords", mock.Anything, mock.Anything, types.AggregateCountByTopic).Return([]types.AggregateCount(nil), errTestStorage)
+
+	_, err := service.Lookup(context.Background(), &lookup.LookupQuestion{
+		Service: Service,
+		Query:   json.RawMessage(`{"aggregate":"countByTopic"}`),
+	})
+	require.ErrorIs(t, err, errTestStorage)
+}
+
+func TestLookup_ObjectQuery_MinConfirmations(t *testing.T) {
+	service, mockStorage := createTestSHIPLookupService()
+	service.SetChainTracker(&mockChainTracker{height: 800000})
+
+	expectedResults := []types.UTXOReference{{Txid: testTxidA, OutputIndex: 0}}
+
+	mockStorage.On("FindRecord", mock.Anything, mock.MatchedBy(func(query types.SHIPQuery) bool {
+		return query.MinConfirmations != nil && *query.MinConfirmations == 6 &&
+			query.ChainTip != nil && *query.ChainTip == 800000
+	})).Return(expectedResults, nil)
+
+	results, err := service.Lookup(context.Background(), &lookup.LookupQuestion{
+		Service: Service,
+		Query:   json.RawMessage(`{"minConfirmations":6}`),
+	})
+	require.NoError(t, err)
+	assertFormulaAnswer(t, expectedResults, results)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestLookup_ObjectQuery_ZeroMinConfirmations(t *testing.T) {
+	service, mockStorage := createTestSHIPLookupService()
+
+	mockStorage.On("FindRecord", mock.Anything, mock.MatchedBy(func(query types.SHIPQuery) bool {
+		return query.ChainTip == nil
+	})).Return([]types.UTXOReference{}, nil)
+
+	_, err := service.Lookup(context.Background(), &lookup.LookupQuestion{
+		Service: Service,
+		Query:   json.RawMessage(`{"minConfirmations":0}`),
+	})
+	require.NoError(t, err)
+	mockStorage.AssertExpectations(t)
+}
+
+func TestLookup_ValidationError_InvalidMinConfirmations(t *testing.T) {
+	tests := []struct {
+		name    string
+		tracker *mockChainTracker
+		query   string
+		wantErr error
+	}{
+		{"negative", &mockChainTracker{height: 800000}, `{"minConfirmations":-1}`, errQueryMinConfirmationsInvalid},
+		{"without chain tracker", nil, `{"minConfirmations":1}`, errQueryMinConfirmationsUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _ := createTestSHIPLookupService()
+			if tt.tracker != nil {
+				service.SetChainTracker(tt.tracker)
+			}
+
+			_, err := service.Lookup(context.Background(), &lookup.LookupQuestion{
+				Service: Service,
+				Query:   json.RawMessage(tt.query),
+			})
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestLookup_MinConfirmationsChainTrackerError(t *testing.T) {
+	service, mockStorage := createTestSHIPLookupService()
+	service.SetChainTracker(&mockChainTracker{err: errTestStorage})
+
+	_, err := service.Lookup(context.Background(), &lookup.LookupQuestion{
+		Service: Service,
+		Query:   json.RawMessage(`{"minConfirmations":1}`),
+	})
+	require.ErrorIs(t, err, errTestStorage)
+	mockStorage.AssertNotCalled(t, "FindRecord", mock.Anything, mock.Anything)
+}
+
 // Test GetDocumentation
 
 func TestGetDocumentation(t *testing.T) {
@@ -639,16 +1414,8 @@ func TestLookup_StorageError(t *testing.T) {
 func TestOutputAdmittedByTopic_StorageError(t *testing.T) {
 	service, mockStorage := createTestSHIPLookupService()
 
-	// Create valid PushDrop script with SHIP data
-	fields := [][]byte{
-		[]byte("SHIP"),                // Protocol identifier
-		{0x01, 0x02, 0x03, 0x04},      // Identity key bytes
-		[]byte("https://example.com"), // Domain
-		[]byte("tm_bridge"),           // Topic
-	}
-	validScriptHex := createValidPushDropScript(fields)
-	scriptObj, err := script.NewFromHex(validScriptHex)
-	require.NoError(t, err)
+	// Create a signed SHIP advertisement token
+	scriptObj, identityKey := createSignedAdvertisementScript(t, overlay.ProtocolSHIP, "https://example.com", "tm_bridge")
 
 	// Create outpoint
 	txidBytes, err := hex.DecodeString(TxID)
@@ -667,7 +1434,7 @@ func TestOutputAdmittedByTopic_StorageError(t *testing.T) {
 		LockingScript: scriptObj,
 	}
 
-	mockStorage.On("StoreSHIPRecord", mock.Anything, TxID, 0, "01020304", "https://example.com", "tm_bridge").Return(errTestStorage)
+	mockStorage.On("StoreSHIPRecord", mock.Anything, TxID, 0, identityKey, "https://example.com", "tm_bridge").Return(false, errTestStorage)
 
 	err = service.OutputAdmittedByTopic(context.Background(), payload)
 	require.Error(t, err)
@@ -712,21 +1479,15 @@ func TestLookup_ComplexObjectQuery(t *testing.T) {
 	}
 
 	expectedResults := []types.UTXOReference{
-		{Txid: "abc123", OutputIndex: 0},
-		{Txid: "def456", OutputIndex: 1},
+		{Txid: testTxidA, OutputIndex: 0},
+		{Txid: testTxidB, OutputIndex: 1},
 	}
 
 	mockStorage.On("FindRecord", mock.Anything, expectedQuery).Return(expectedResults, nil)
 
 	results, err := service.Lookup(context.Background(), question)
 	require.NoError(t, err)
-	assert.Equal(t, lookup.AnswerTypeFreeform, results.Type)
-	if utxos, ok := results.Result.([]types.UTXOReference); ok {
-		assert.Equal(t, expectedResults, utxos)
-		assert.Len(t, utxos, 2)
-	} else {
-		t.Errorf("Expected UTXOReference slice, got %T", results.Result)
-	}
+	assertFormulaAnswer(t, expectedResults, results)
 	mockStorage.AssertExpectations(t)
 }
 
@@ -749,21 +1510,96 @@ func TestSHIPLookupService_OutputNoLongerRetainedInHistory(t *testing.T) {
 }
 
 func TestSHIPLookupService_OutputBlockHeightUpdated(t *testing.T) {
-	mockStorage := &MockStorage{}
-	service := NewLookupService(mockStorage)
+	txid := chainhash.HashH([]byte("ship block height"))
+	storedTxid := hex.EncodeToString(txid[:])
+	blockHash := chainhash.HashH([]byte("block 12345"))
+
+	tests := []struct {
+		name        string
+		tracker     *mockBlockHashTracker
+		blockHeight uint32
+		wantHash    string
+	}{
+		{"without chain tracker", nil, 12345, ""},
+		{"with block hash resolver", &mockBlockHashTracker{hashes: map[uint32]*chainhash.Hash{12345: &blockHash}}, 12345, blockHash.String()},
+		{"unresolved block hash", &mockBlockHashTracker{}, 12345, ""},
+		{"reset to unconfirmed", &mockBlockHashTracker{hashErr: errTestStorage}, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockStorage := createTestSHIPLookupService()
+			if tt.tracker != nil {
+				service.SetChainTracker(tt.tracker)
+			}
+
+			mockStorage.On("UpdateSHIPRecordBlock", mock.Anything, storedTxid, tt.blockHeight, tt.wantHash).Return(nil)
+
+			err := service.OutputBlockHeightUpdated(context.Background(), &txid, tt.blockHeight, 0)
+			require.NoError(t, err)
+			mockStorage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSHIPLookupService_OutputBlockHeightUpdated_Errors(t *testing.T) {
+	txid := chainhash.HashH([]byte("ship block height"))
 
-	// Create test transaction ID
-	txidBytes, err := hex.DecodeString("0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+	t.Run("block hash resolver error", func(t *testing.T) {
+		service, mockStorage := createTestSHIPLookupService()
+		service.SetChainTracker(&mockBlockHashTracker{hashErr: errTestStorage})
+
+		err := service.OutputBlockHeightUpdated(context.Background(), &txid, 12345, 0)
+		require.ErrorIs(t, err, errTestStorage)
+		mockStorage.AssertNotCalled(t, "UpdateSHIPRecordBlock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("storage error", func(t *testing.T) {
+		service, mockStorage := createTestSHIPLookupService()
+		mockStorage.On("UpdateSHIPRecordBlock", mock.Anything, mock.Anything, uint32(12345), "").Return(errTestStorage)
+
+		err := service.OutputBlockHeightUpdated(context.Background(), &txid, 12345, 0)
+		require.ErrorIs(t, err, errTestStorage)
+	})
+}
+
+func TestSHIPLookupService_ResetBlockHeights(t *testing.T) {
+	service, mockStorage := createTestSHIPLookupService()
+
+	mockStorage.On("ResetSHIPRecordBlocks", mock.Anything, uint32(800000)).Return(int64(3), nil)
+
+	reset, err := service.ResetBlockHeights(context.Background(), 800000)
 	require.NoError(t, err)
-	var txidArray [32]byte
-	copy(txidArray[:], txidBytes)
-	txid := &chainhash.Hash{}
-	copy(txid[:], txidArray[:])
+	assert.Equal(t, int64(3), reset)
+	mockStorage.AssertExpectations(t)
+}
 
-	// Test that OutputBlockHeightUpdated does nothing (no-op)
-	err = service.OutputBlockHeightUpdated(context.Background(), txid, 12345, 0)
+func TestSHIPLookupService_ResetBlockHeights_Reorg(t *testing.T) {
+	ctx := context.Background()
+	storage := NewMemoryStorage()
+	_, err := storage.StoreSHIPRecord(ctx, testTxidA, 0, "01020304", "https://example.com", "tm_bridge")
 	require.NoError(t, err)
+	service := NewLookupService(storage)
+	service.SetChainTracker(&mockChainTracker{height: 800010})
 
-	// Verify no storage methods were called
-	mockStorage.AssertExpectations(t)
+	txid, err := chainhash.NewHashFromHex(testTxidA)
+	require.NoError(t, err)
+	confirmed := func() *lookup.LookupAnswer {
+		answer, err := service.Lookup(ctx, &lookup.LookupQuestion{Service: Service, Query: json.RawMessage(`{"minConfirmations":6}`)})
+		require.NoError(t, err)
+		return answer
+	}
+
+	require.NoError(t, service.OutputBlockHeightUpdated(ctx, txid, 800000, 0))
+	assertFormulaAnswer(t, []types.UTXOReference{{Txid: testTxidA, OutputIndex: 0}}, confirmed())
+
+	// A reorganization orphans the blocks from 799999 on, so the record is no longer confirmed
+	reset, err := service.ResetBlockHeights(ctx, 799999)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), reset)
+	assertFormulaAnswer(t, nil, confirmed())
+
+	// The engine reports the transaction mined again on the new chain
+	require.NoError(t, service.OutputBlockHeightUpdated(ctx, txid, 800002, 0))
+	assertFormulaAnswer(t, []types.UTXOReference{{Txid: testTxidA, OutputIndex: 0}}, confirmed())
 }
@@ -0,0 +1,299 @@
+package ship
+
+// In-memory storage and retrieval of SHIP records.
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/types"
+	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/utils"
+)
+
+// MemoryStorage implements StorageInterface entirely in memory.
+// It honours the same filter, pagination, sort order and createdAt semantics as the
+// MongoDB-backed Storage, making it suitable for tests and local development setups
+// that do not want to run a database.
+type MemoryStorage struct {
+	// mutex protects concurrent access to records
+	mutex sync.RWMutex
+	// records holds the stored SHIP records in insertion order
+	records []types.SHIPRecord
+	// now returns the timestamp assigned to newly stored records
+	now func() time.Time
+}
+
+// Compile-time verification that MemoryStorage implements StorageInterface
+var _ StorageInterface = (*MemoryStorage)(nil)
+
+// NewMemoryStorage constructs a new, empty MemoryStorage instance.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		records: make([]types.SHIPRecord, 0),
+		now:     time.Now,
+	}
+}
+
+// EnsureIndexes is a no-op for the in-memory storage, which does not use indexes.
+func (s *MemoryStorage) EnsureIndexes(_ context.Context) error {
+	return nil
+}
+
+// StoreSHIPRecord stores a new SHIP record in memory.
+// The record includes transaction information, identity key, domain, topic, the
+// normalized scheme and host of the domain, and an automatically generated creation timestamp.
+// Storing is idempotent: if a record for the same txid and outputIndex already exists it is
+// left untouched and false is returned. It returns true when the record was newly inserted.
+func (s *MemoryStorage) StoreSHIPRecord(_ context.Context, txid string, outputIndex int, identityKey, domain, topic string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, record := range s.records {
+		if record.Txid == txid && record.OutputIndex == outputIndex {
+			return false, nil
+		}
+	}
+
+	uri, _ := utils.ParseAdvertisableURI(domain)
+	s.records = append(s.records, types.SHIPRecord{
+		Txid:         txid,
+		OutputIndex:  outputIndex,
+		IdentityKey:  identityKey,
+		Domain:       domain,
+		Topic:        topic,
+		Scheme:       uri.Scheme,
+		Host:         uri.Host,
+		ReversedHost: uri.ReversedHost(),
+		CreatedAt:    s.now(),
+	})
+
+	return true, nil
+}
+
+// DeleteSHIPRecord deletes a SHIP record based on transaction ID and output index.
+// Deleting a record that does not exist is not an error.
+// Since storing is idempotent there is at most one record per outpoint.
+func (s *MemoryStorage) DeleteSHIPRecord(_ context.Context, txid string, outputIndex int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, record := range s.records {
+		if record.Txid == txid && record.OutputIndex == outputIndex {
+			s.records = slices.Delete(s.records, i, i+1)
+			break
+		}
+	}
+
+	return nil
+}
+
+// UpdateSHIPRecordBlock records the block height and hash of all SHIP records of a transaction.
+// A block height of 0 marks the records as unconfirmed again, e.g. after their block was orphaned.
+func (s *MemoryStorage) UpdateSHIPRecordBlock(_ context.Context, txid string, blockHeight uint32, blockHash string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i := range s.records {
+		if s.records[i].Txid == txid {
+			s.records[i].BlockHeight = blockHeight
+			s.records[i].BlockHash = blockHash
+		}
+	}
+
+	return nil
+}
+
+// ResetSHIPRecordBlocks marks all SHIP records mined at or above fromHeight as unconfirmed.
+// It returns the number of records reset.
+func (s *MemoryStorage) ResetSHIPRecordBlocks(_ context.Context, fromHeight uint32) (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var reset int64
+	for i := range s.records {
+		if s.records[i].BlockHeight > 0 && s.records[i].BlockHeight >= fromHeight {
+			s.records[i].BlockHeight = 0
+			s.records[i].BlockHash = ""
+			reset++
+		}
+	}
+
+	return reset, nil
+}
+
+// FindRecord finds SHIP records based on the provided query parameters.
+// It supports filtering by domain, host pattern, schemes, topics, identity key, creation time, and confirmations, with pagination and sorting options.
+func (s *MemoryStorage) FindRecord(ctx context.Context, query types.SHIPQuery) ([]types.UTXOReference, error) {
+	records, err := s.FindSHIPRecords(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return shipRecordsToUTXOReferences(records), nil
+}
+
+// FindSHIPRecords finds full SHIP records based on the provided query parameters.
+// It applies the same filtering, pagination and sorting as FindRecord.
+// The FindAll flag of the query is not interpreted; a query without filters matches all records.
+func (s *MemoryStorage) FindSHIPRecords(_ context.Context, query types.SHIPQuery) ([]types.SHIPRecord, error) {
+	var cursor *types.RecordCursor
+	if query.Cursor != nil && *query.Cursor != "" {
+		var err error
+		if cursor, err = types.DecodeRecordCursor(*query.Cursor); err != nil {
+			return nil, err
+		}
+	}
+	ascending := query.SortOrder != nil && *query.SortOrder == types.SortOrderAsc
+	pattern, err := queryHostPattern(query)
+	if err != nil {
+		return nil, err
+	}
+	maxHeight, confirmed, err := maxConfirmedHeight(query)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	domains := queryDomains(query)
+	matches := make([]types.SHIPRecord, 0, len(s.records))
+	for _, record := range s.records {
+		// Only keep records after the cursor in the requested sort order
+		if cursor != nil {
+			position := shipRecordCursor(record).Compare(*cursor)
+			if (ascending && position <= 0) || (!ascending && position >= 0) {
+				continue
+			}
+		}
+		if len(domains) > 0 && !slices.Contains(domains, record.Domain) {
+			continue
+		}
+		if pattern != nil && !pattern.Matches(record.Host) {
+			continue
+		}
+		if len(query.Schemes) > 0 && !slices.Contains(query.Schemes, record.Scheme) {
+			continue
+		}
+		if len(query.Topics) > 0 && !slices.Contains(query.Topics, record.Topic) {
+			continue
+		}
+		if query.IdentityKey != nil && record.IdentityKey != *query.IdentityKey {
+			continue
+		}
+		if query.CreatedAfter != nil && record.CreatedAt.Before(*query.CreatedAfter) {
+			continue
+		}
+		if query.CreatedBefore != nil && !record.CreatedAt.Before(*query.CreatedBefore) {
+			continue
+		}
+		if confirmed && (record.BlockHeight == 0 || record.BlockHeight > maxHeight) {
+			continue
+		}
+		matches = append(matches, record)
+	}
+
+	return paginateSHIPRecords(matches, query.Limit, query.Skip, query.SortOrder), nil
+}
+
+// FindAll returns all SHIP records with optional pagination and sorting.
+func (s *MemoryStorage) FindAll(_ context.Context, limit, skip *int, sortOrder *types.SortOrder) ([]types.UTXOReference, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return shipRecordsToUTXOReferences(paginateSHIPRecords(slices.Clone(s.records), limit, skip, sortOrder)), nil
+}
+
+// CountSHIPRecords counts the SHIP records matching the query, grouped by the field selected by groupBy.
+// Limit, skip and sort order of the query are not interpreted, and neither is its FindAll flag.
+// Counts are ordered by descending count, ties broken by ascending key.
+func (s *MemoryStorage) CountSHIPRecords(ctx context.Context, query types.SHIPQuery, groupBy types.AggregateMode) ([]types.AggregateCount, error) {
+	if _, _, err := aggregateField(groupBy); err != nil {
+		return nil, err
+	}
+
+	query.Limit, query.Skip = nil, nil
+	records, err := s.FindSHIPRecords(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64)
+	for _, record := range records {
+		switch groupBy {
+		case types.AggregateCountByTopic:
+			counts[record.Topic]++
+		case types.AggregateCountByDomain:
+			counts[record.Domain]++
+		case types.AggregateCountByIdentityKey:
+			counts[record.IdentityKey]++
+		}
+	}
+
+	results := make([]types.AggregateCount, 0, len(counts))
+	for key, count := range counts {
+		results = append(results, types.AggregateCount{Key: key, Count: count})
+	}
+	slices.SortFunc(results, func(a, b types.AggregateCount) int {
+		if result := cmp.Compare(b.Count, a.Count); result != 0 {
+			return result
+		}
+		return cmp.Compare(a.Key, b.Key)
+	})
+
+	return results, nil
+}
+
+// paginateSHIPRecords sorts the records by createdAt, txid and outputIndex (descending unless
+// ascending is requested) and applies skip and limit.
+func paginateSHIPRecords(records []types.SHIPRecord, limit, skip *int, sortOrder *types.SortOrder) []types.SHIPRecord {
+	ascending := sortOrder != nil && *sortOrder == types.SortOrderAsc
+	slices.SortFunc(records, func(a, b types.SHIPRecord) int {
+		if ascending {
+			return shipRecordCursor(a).Compare(shipRecordCursor(b))
+		}
+		return shipRecordCursor(b).Compare(shipRecordCursor(a))
+	})
+
+	if skip != nil && *skip > 0 {
+		if *skip >= len(records) {
+			return nil
+		}
+		records = records[*skip:]
+	}
+
+	if limit != nil && *limit > 0 && len(records) > *limit {
+		records = records[:*limit]
+	}
+
+	if len(records) == 0 {
+		return nil
+	}
+
+	return records
+}
+
+// shipRecordCursor returns the pagination position of a record.
+func shipRecordCursor(record types.SHIPRecord) types.RecordCursor {
+	return types.RecordCursor{
+		CreatedAt:   record.CreatedAt,
+		Txid:        record.Txid,
+		OutputIndex: record.OutputIndex,
+	}
+}
+
+// shipRecordsToUTXOReferences projects the records to their UTXO references.
+func shipRecordsToUTXOReferences(records []types.SHIPRecord) []types.UTXOReference {
+	var results []types.UTXOReference
+	for _, record := range records {
+		results = append(results, types.UTXOReference{
+			Txid:        record.Txid,
+			OutputIndex: record.OutputIndex,
+		})
+	}
+
+	return results
+}
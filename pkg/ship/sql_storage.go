@@ -0,0 +1,417 @@
+package ship
+
+// SQL (GORM) based storage and retrieval of SHIP records.
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/types"
+	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/utils"
+)
+
+// sqlSHIPRecord is the GORM model backing the "ship_records" table.
+// The (domain, topic), unique (txid, output_index), (created_at, txid, output_index), reversed_host,
+// scheme and block_height indexes mirror the indexes created by the MongoDB Storage.
+type sqlSHIPRecord struct {
+	ID           uint      `gorm:"primaryKey"`
+	Txid         string    `gorm:"column:txid;size:64;not null;uniqueIndex:idx_ship_records_outpoint,priority:1;index:idx_ship_records_position,priority:2"`
+	OutputIndex  int       `gorm:"column:output_index;not null;uniqueIndex:idx_ship_records_outpoint,priority:2;index:idx_ship_records_position,priority:3"`
+	IdentityKey  string    `gorm:"column:identity_key;not null"`
+	Domain       string    `gorm:"column:domain;not null;index:idx_ship_records_domain_topic,priority:1"`
+	Topic        string    `gorm:"column:topic;not null;index:idx_ship_records_domain_topic,priority:2"`
+	Scheme       string    `gorm:"column:scheme;not null;default:'';index:idx_ship_records_scheme"`
+	Host         string    `gorm:"column:host;not null;default:''"`
+	ReversedHost string    `gorm:"column:reversed_host;not null;default:'';index:idx_ship_records_reversed_host"`
+	BlockHeight  uint32    `gorm:"column:block_height;not null;default:0;index:idx_ship_records_block_height"`
+	BlockHash    string    `gorm:"column:block_hash;size:64;not null;default:''"`
+	CreatedAt    time.Time `gorm:"column:created_at;not null;index:idx_ship_records_position,priority:1"`
+}
+
+// TableName returns the name of the table holding SHIP records.
+func (sqlSHIPRecord) TableName() string {
+	return "ship_records"
+}
+
+// SQLStorage implements a storage engine for SHIP protocol records on top of GORM.
+// It works with any GORM dialect (e.g. SQLite or Postgres) and provides the same
+// filtering, pagination and sorting semantics as the MongoDB-backed Storage.
+type SQLStorage struct {
+	db *gorm.DB
+	// now returns the timestamp assigned to newly stored records
+	now func() time.Time
+}
+
+// Compile-time verification that SQLStorage implements StorageInterface
+var _ StorageInterface = (*SQLStorage)(nil)
+
+// NewSQLStorage constructs a new SQLStorage instance with the provided GORM database.
+// EnsureIndexes must be called before use to migrate the "ship_records" table.
+func NewSQLStorage(db *gorm.DB) *SQLStorage {
+	return &SQLStorage{
+		db:  db,
+		now: time.Now,
+	}
+}
+
+// EnsureIndexes migrates the SHIP records table and creates its indexes.
+// This method should be called once during application initialization.
+// It creates a compound index on domain and topic fields, a unique index on txid and
+// output_index, an index matching the pagination order, indexes on the normalized
+// reversed_host and scheme columns, and an index on block_height. Tables created before the unique index existed may hold duplicates;
+// call RemoveDuplicateRecords first to clean them up.
+func (s *SQLStorage) EnsureIndexes(ctx context.Context) error {
+	if err := s.db.WithContext(ctx).AutoMigrate(&sqlSHIPRecord{}); err != nil {
+		return fmt.Errorf("failed to migrate SHIP records table: %w", err)
+	}
+
+	return nil
+}
+
+// StoreSHIPRecord stores a new SHIP record in the database.
+// The record includes transaction information, identity key, domain, topic, the
+// normalized scheme and host of the domain, and an automatically generated creation timestamp.
+// Storing is idempotent: if a record for the same txid and outputIndex already exists it is
+// left untouched (including its creation timestamp) and false is returned. It returns true
+// when the record was newly inserted.
+func (s *SQLStorage) StoreSHIPRecord(ctx context.Context, txid string, outputIndex int, identityKey, domain, topic string) (bool, error) {
+	uri, _ := utils.ParseAdvertisableURI(domain)
+	record := sqlSHIPRecord{
+		Txid:         txid,
+		OutputIndex:  outputIndex,
+		IdentityKey:  identityKey,
+		Domain:       domain,
+		Topic:        topic,
+		Scheme:       uri.Scheme,
+		Host:         uri.Host,
+		ReversedHost: uri.ReversedHost(),
+		// Timestamps are stored in UTC so they compare consistently with pagination cursors
+		CreatedAt: s.now().UTC(),
+	}
+
+	result := s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "txid"}, {Name: "output_index"}},
+			DoNothing: true,
+		}).
+		Create(&record)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to store SHIP record: %w", result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// RemoveDuplicateRecords is a migration helper that removes duplicate SHIP records sharing
+// the same txid and output_index, keeping the oldest record of each outpoint.
+// Tables populated before the unique outpoint index was introduced may contain such
+// duplicates, which prevent EnsureIndexes from creating the index. It returns the number
+// of records removed.
+func (s *SQLStorage) RemoveDuplicateRecords(ctx context.Context) (int64, error) {
+	db := s.db.WithContext(ctx)
+	oldest := db.Model(&sqlSHIPRecord{}).Select("MIN(id)").Group("txid, output_index")
+
+	result := db.Where("id NOT IN (?)", oldest).Delete(&sqlSHIPRecord{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to remove duplicate SHIP records: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// BackfillHostFields is a migration helper that derives the scheme, host and reversed_host columns
+// of SHIP records stored before these columns were introduced. Records without them never match
+// hostPattern or schemes queries. It returns the number of records updated.
+func (s *SQLStorage) BackfillHostFields(ctx context.Context) (int64, error) {
+	db := s.db.WithContext(ctx)
+
+	var rows []sqlSHIPRecord
+	if err := db.Select("id", "domain").Where("scheme = ?", "").Find(&rows).Error; err != nil {
+		return 0, fmt.Errorf("failed to find SHIP records without host fields: %w", err)
+	}
+
+	var updated int64
+	for _, row := range rows {
+		uri, ok := utils.ParseAdvertisableURI(row.Domain)
+		if !ok {
+			continue
+		}
+
+		result := db.Model(&sqlSHIPRecord{}).Where("id = ?", row.ID).Updates(map[string]interface{}{
+			"scheme":        uri.Scheme,
+			"host":          uri.Host,
+			"reversed_host": uri.ReversedHost(),
+		})
+		if result.Error != nil {
+			return updated, fmt.Errorf("failed to backfill SHIP record host fields: %w", result.Error)
+		}
+		updated += result.RowsAffected
+	}
+
+	return updated, nil
+}
+
+// DeleteSHIPRecord deletes a SHIP record from the database based on transaction ID and output index.
+// This method is typically used when a UTXO is spent and the associated SHIP record should be removed.
+func (s *SQLStorage) DeleteSHIPRecord(ctx context.Context, txid string, outputIndex int) error {
+	err := s.db.WithContext(ctx).
+		Where("txid = ? AND output_index = ?", txid, outputIndex).
+		Delete(&sqlSHIPRecord{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete SHIP record: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateSHIPRecordBlock records the block height and hash of all SHIP records of a transaction.
+// A block height of 0 marks the records as unconfirmed again, e.g. after their block was orphaned.
+func (s *SQLStorage) UpdateSHIPRecordBlock(ctx context.Context, txid string, blockHeight uint32, blockHash string) error {
+	err := s.db.WithContext(ctx).
+		Model(&sqlSHIPRecord{}).
+		Where("txid = ?", txid).
+		Updates(map[string]interface{}{
+			"block_height": blockHeight,
+			"block_hash":   blockHash,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update SHIP record block: %w", err)
+	}
+
+	return nil
+}
+
+// ResetSHIPRecordBlocks marks all SHIP records mined at or above fromHeight as unconfirmed.
+// It is used when a chain reorganization orphans those blocks; the records regain their block
+// height once the engine reports it for the new chain. It returns the number of records reset.
+func (s *SQLStorage) ResetSHIPRecordBlocks(ctx context.Context, fromHeight uint32) (int64, error) {
+	result := s.db.WithContext(ctx).
+		Model(&sqlSHIPRecord{}).
+		Where("block_height >= ?", max(fromHeight, 1)).
+		Updates(map[string]interface{}{
+			"block_height": 0,
+			"block_hash":   "",
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to reset SHIP record blocks: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// FindRecord finds SHIP records based on the provided query parameters.
+// It supports filtering by domain, host pattern, schemes, topics, identity key, creation time, and confirmations, with pagination and sorting options.
+// Returns only UTXO references (txid and outputIndex) as projection for efficient querying.
+func (s *SQLStorage) FindRecord(ctx context.Context, query types.SHIPQuery) ([]types.UTXOReference, error) {
+	tx, err := s.filterSHIPRecords(s.db.WithContext(ctx).Model(&sqlSHIPRecord{}), query)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := s.findUTXOReferences(tx, query.Limit, query.Skip, query.SortOrder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find SHIP records: %w", err)
+	}
+
+	return results, nil
+}
+
+// FindSHIPRecords finds full SHIP records based on the provided query parameters.
+// It applies the same filtering, pagination and sorting as FindRecord, but returns the complete
+// records (identity key, domain, topic, block and creation time) instead of only UTXO references.
+// The FindAll flag of the query is not interpreted; a query without filters matches all records.
+func (s *SQLStorage) FindSHIPRecords(ctx context.Context, query types.SHIPQuery) ([]types.SHIPRecord, error) {
+	tx, err := s.filterSHIPRecords(s.db.WithContext(ctx).Model(&sqlSHIPRecord{}), query)
+	if err != nil {
+		return nil, err
+	}
+	tx = paginate(tx, query.Limit, query.Skip, query.SortOrder)
+
+	var rows []sqlSHIPRecord
+	if err := tx.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to find SHIP records: %w", err)
+	}
+
+	var results []types.SHIPRecord
+	for _, row := range rows {
+		results = append(results, types.SHIPRecord{
+			Txid:         row.Txid,
+			OutputIndex:  row.OutputIndex,
+			IdentityKey:  row.IdentityKey,
+			Domain:       row.Domain,
+			Topic:        row.Topic,
+			Scheme:       row.Scheme,
+			Host:         row.Host,
+			ReversedHost: row.ReversedHost,
+			BlockHeight:  row.BlockHeight,
+			BlockHash:    row.BlockHash,
+			CreatedAt:    row.CreatedAt,
+		})
+	}
+
+	return results, nil
+}
+
+// FindAll returns all SHIP records in the database with optional pagination and sorting.
+// Returns only UTXO references (txid and outputIndex) as projection for efficient querying.
+func (s *SQLStorage) FindAll(ctx context.Context, limit, skip *int, sortOrder *types.SortOrder) ([]types.UTXOReference, error) {
+	results, err := s.findUTXOReferences(s.db.WithContext(ctx).Model(&sqlSHIPRecord{}), limit, skip, sortOrder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find all SHIP records: %w", err)
+	}
+
+	return results, nil
+}
+
+// CountSHIPRecords counts the SHIP records matching the query, grouped by the field selected by groupBy.
+// The grouping runs in the database, so no records are transferred. Limit, skip and sort order of the
+// query are not interpreted, and neither is its FindAll flag; a query without filters counts all records.
+// Counts are ordered by descending count, ties broken by ascending key.
+func (s *SQLStorage) CountSHIPRecords(ctx context.Context, query types.SHIPQuery, groupBy types.AggregateMode) ([]types.AggregateCount, error) {
+	_, column, err := aggregateField(groupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.filterSHIPRecords(s.db.WithContext(ctx).Model(&sqlSHIPRecord{}), query)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		GroupKey    string
+		RecordCount int64
+	}
+	err = tx.Select(column + " AS group_key, COUNT(*) AS record_count").
+		Group(column).
+		Order("record_count DESC").
+		Order("group_key ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count SHIP records: %w", err)
+	}
+
+	results := make([]types.AggregateCount, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, types.AggregateCount{Key: row.GroupKey, Count: row.RecordCount})
+	}
+
+	return results, nil
+}
+
+// filterSHIPRecords applies the domains, host pattern, schemes, topics, identity key, creation time,
+// confirmations and cursor filters of a query.
+func (s *SQLStorage) filterSHIPRecords(tx *gorm.DB, query types.SHIPQuery) (*gorm.DB, error) {
+	// Add domain filter using IN if any domains are provided
+	if domains := queryDomains(query); len(domains) > 0 {
+		tx = tx.Where("domain IN ?", domains)
+	}
+
+	// Add host filter on the indexed reversed host if a host pattern is provided
+	pattern, err := queryHostPattern(query)
+	if err != nil {
+		return nil, err
+	}
+	if pattern != nil {
+		if pattern.Wildcard {
+			lower, upper := pattern.ReversedRange()
+			tx = tx.Where("reversed_host >= ? AND reversed_host < ?", lower, upper)
+		} else {
+			tx = tx.Where("reversed_host = ?", utils.ReverseHost(pattern.Host))
+		}
+	}
+
+	// Add schemes filter using IN if provided
+	if len(query.Schemes) > 0 {
+		tx = tx.Where("scheme IN ?", query.Schemes)
+	}
+
+	// Add topics filter using IN if provided
+	if len(query.Topics) > 0 {
+		tx = tx.Where("topic IN ?", query.Topics)
+	}
+
+	// Add identity key filter if provided
+	if query.IdentityKey != nil {
+		tx = tx.Where("identity_key = ?", *query.IdentityKey)
+	}
+
+	// Add creation time range filter if provided; timestamps are stored in UTC
+	if query.CreatedAfter != nil {
+		tx = tx.Where("created_at >= ?", query.CreatedAfter.UTC())
+	}
+	if query.CreatedBefore != nil {
+		tx = tx.Where("created_at < ?", query.CreatedBefore.UTC())
+	}
+
+	// Add confirmations filter on the block height if requested
+	maxHeight, confirmed, err := maxConfirmedHeight(query)
+	if err != nil {
+		return nil, err
+	}
+	if confirmed {
+		tx = tx.Where("block_height >= ? AND block_height <= ?", 1, maxHeight)
+	}
+
+	// Only match records after the cursor in the requested sort order
+	if query.Cursor != nil && *query.Cursor != "" {
+		cursor, err := types.DecodeRecordCursor(*query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		tx = afterCursor(tx, cursor, query.SortOrder)
+	}
+
+	return tx, nil
+}
+
+// findUTXOReferences applies sorting and pagination to the given query and projects the
+// matching rows to UTXO references.
+func (s *SQLStorage) findUTXOReferences(tx *gorm.DB, limit, skip *int, sortOrder *types.SortOrder) ([]types.UTXOReference, error) {
+	var results []types.UTXOReference
+	if err := paginate(tx, limit, skip, sortOrder).Select("txid", "output_index").Scan(&results).Error; err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// paginate applies sorting (default descending by createdAt, then txid and output_index) and
+// pagination to the given query.
+func paginate(tx *gorm.DB, limit, skip *int, sortOrder *types.SortOrder) *gorm.DB {
+	if sortOrder != nil && *sortOrder == types.SortOrderAsc {
+		tx = tx.Order("created_at ASC").Order("txid ASC").Order("output_index ASC")
+	} else {
+		tx = tx.Order("created_at DESC").Order("txid DESC").Order("output_index DESC")
+	}
+
+	if skip != nil && *skip > 0 {
+		tx = tx.Offset(*skip)
+	}
+
+	if limit != nil && *limit > 0 {
+		tx = tx.Limit(*limit)
+	}
+
+	return tx
+}
+
+// afterCursor restricts the query to records that sort after the cursor in the
+// (created_at, txid, output_index) ordering used by paginate.
+func afterCursor(tx *gorm.DB, cursor *types.RecordCursor, sortOrder *types.SortOrder) *gorm.DB {
+	operator := "<"
+	if sortOrder != nil && *sortOrder == types.SortOrderAsc {
+		operator = ">"
+	}
+
+	return tx.Where(
+		"(created_at "+operator+" ? OR (created_at = ? AND txid "+operator+" ?) OR (created_at = ? AND txid = ? AND output_index "+operator+" ?))",
+		cursor.CreatedAt,
+		cursor.CreatedAt, cursor.Txid,
+		cursor.CreatedAt, cursor.Txid, cursor.OutputIndex,
+	)
+}
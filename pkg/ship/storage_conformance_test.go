@@ -0,0 +1,986 @@
+package ship
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/types"
+	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/utils"
+)
+
+// storageFactory creates an empty storage backend whose record timestamps are taken from now.
+type storageFactory func(t *testing.T, now func() time.Time) StorageInterface
+
+// newTestClock returns a deterministic clock that advances by one second on every call.
+// Timestamps are millisecond-aligned so they survive a round trip through MongoDB unchanged.
+func newTestClock() func() time.Time {
+	current := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return func() time.Time {
+		current = current.Add(time.Second)
+		return current
+	}
+}
+
+// conformanceRecord describes a record seeded into every backend under test
+type conformanceRecord struct {
+	txid        string
+	outputIndex int
+	identityKey string
+	domain      string
+	topic       string
+}
+
+// conformanceRecords are stored in order, so each one is newer than the one before it
+//
+//nolint:gochecknoglobals // shared fixture for the conformance suite
+var conformanceRecords = []conformanceRecord{
+	{txid: "txid1", outputIndex: 0, identityKey: "key1", domain: "https://a.example.com", topic: "tm_alpha"},
+	{txid: "txid2", outputIndex: 0, identityKey: "key1", domain: "https://b.example.com", topic: "tm_beta"},
+	{txid: "txid3", outputIndex: 1, identityKey: "key2", domain: "https://a.example.com", topic: "tm_beta"},
+	{txid: "txid4", outputIndex: 0, identityKey: "key2", domain: "https://a.example.com", topic: "tm_gamma"},
+}
+
+// seedConformanceStorage creates a backend and stores the conformance records in it
+func seedConformanceStorage(t *testing.T, newStorage storageFactory) StorageInterface {
+	storage := newStorage(t, newTestClock())
+	for _, record := range conformanceRecords {
+		inserted, err := storage.StoreSHIPRecord(context.Background(), record.txid, record.outputIndex, record.identityKey, record.domain, record.topic)
+		require.NoError(t, err)
+		require.True(t, inserted)
+	}
+	return storage
+}
+
+// utxoRefs builds the expected UTXO references for the given conformance record positions
+func utxoRefs(positions ...int) []types.UTXOReference {
+	refs := make([]types.UTXOReference, 0, len(positions))
+	for _, position := range positions {
+		refs = append(refs, types.UTXOReference{
+			Txid:        conformanceRecords[position].txid,
+			OutputIndex: conformanceRecords[position].outputIndex,
+		})
+	}
+	return refs
+}
+
+// shipRecords builds the expected full records for the given conformance record positions,
+// including the createdAt timestamp assigned by newTestClock when the records were seeded.
+func shipRecords(positions ...int) []types.SHIPRecord {
+	start := newTestClock()()
+	records := make([]types.SHIPRecord, 0, len(positions))
+	for _, position := range positions {
+		record := conformanceRecords[position]
+		uri, _ := utils.ParseAdvertisableURI(record.domain)
+		records = append(records, types.SHIPRecord{
+			Txid:         record.txid,
+			OutputIndex:  record.outputIndex,
+			IdentityKey:  record.identityKey,
+			Domain:       record.domain,
+			Topic:        record.topic,
+			Scheme:       uri.Scheme,
+			Host:         uri.Host,
+			ReversedHost: uri.ReversedHost(),
+			CreatedAt:    start.Add(time.Duration(position) * time.Second),
+		})
+	}
+	return records
+}
+
+// createdAt returns the creation time of the given conformance record.
+func createdAt(position int) *time.Time {
+	created := shipRecords(position)[0].CreatedAt
+	return &created
+}
+
+// cursorAt returns the encoded cursor positioned at the given conformance record.
+func cursorAt(position int) *string {
+	record := shipRecords(position)[0]
+	cursor := types.RecordCursor{CreatedAt: record.CreatedAt, Txid: record.Txid, OutputIndex: record.OutputIndex}.Encode()
+	return &cursor
+}
+
+// walkSHIPRecords pages through all records matching query using cursors of pageSize records,
+// the way a crawler following nextCursor would, and returns their UTXO references.
+func walkSHIPRecords(t *testing.T, storage StorageInterface, query types.SHIPQuery, pageSize int) []types.UTXOReference {
+	t.Helper()
+
+	var refs []types.UTXOReference
+	cursor := ""
+	for {
+		query.Cursor = stringPtr(cursor)
+		query.Limit = intPtr(pageSize)
+		page, err := storage.FindSHIPRecords(context.Background(), query)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(page), pageSize)
+
+		for _, record := range page {
+			refs = append(refs, types.UTXOReference{Txid: record.Txid, OutputIndex: record.OutputIndex})
+		}
+		if len(page) < pageSize {
+			return refs
+		}
+
+		last := page[len(page)-1]
+		cursor = types.RecordCursor{CreatedAt: last.CreatedAt, Txid: last.Txid, OutputIndex: last.OutputIndex}.Encode()
+	}
+}
+
+// normalizeSHIPRecords converts record timestamps to UTC so records read back from a
+// database compare equal to the expected ones.
+func normalizeSHIPRecords(records []types.SHIPRecord) []types.SHIPRecord {
+	for i := range records {
+		records[i].CreatedAt = records[i].CreatedAt.UTC()
+	}
+	return records
+}
+
+// runStorageConformanceTests verifies that a StorageInterface implementation honours the
+// filter, pagination, sort order and createdAt semantics shared by all SHIP storage backends.
+func runStorageConformanceTests(t *testing.T, newStorage storageFactory) {
+	t.Run("FindRecord", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			query    types.SHIPQuery
+			expected []types.UTXOReference
+		}{
+			{
+				name:     "no filters returns newest first",
+				query:    types.SHIPQuery{},
+				expected: utxoRefs(3, 2, 1, 0),
+			},
+			{
+				name:     "filter by domain",
+				query:    types.SHIPQuery{Domain: stringPtr("https://a.example.com")},
+				expected: utxoRefs(3, 2, 0),
+			},
+			{
+				name:     "filter by any of several topics",
+				query:    types.SHIPQuery{Topics: []string{"tm_alpha", "tm_gamma"}},
+				expected: utxoRefs(3, 0),
+			},
+			{
+				name:     "filter by any of several domains",
+				query:    types.SHIPQuery{Domains: []string{"https://b.example.com", "https://missing.example.com"}},
+				expected: utxoRefs(1),
+			},
+			{
+				name:     "domain and domains are combined",
+				query:    types.SHIPQuery{Domain: stringPtr("https://a.example.com"), Domains: []string{"https://b.example.com"}},
+				expected: utxoRefs(3, 2, 1, 0),
+			},
+			{
+				name:     "filter by identity key",
+				query:    types.SHIPQuery{IdentityKey: stringPtr("key1")},
+				expected: utxoRefs(1, 0),
+			},
+			{
+				name: "combined filters",
+				query: types.SHIPQuery{
+					Domain:      stringPtr("https://a.example.com"),
+					Topics:      []string{"tm_beta"},
+					IdentityKey: stringPtr("key2"),
+				},
+				expected: utxoRefs(2),
+			},
+			{
+				name:     "ascending sort order",
+				query:    types.SHIPQuery{Domain: stringPtr("https://a.example.com"), SortOrder: sortOrderPtr(types.SortOrderAsc)},
+				expected: utxoRefs(0, 2, 3),
+			},
+			{
+				name:     "skip and limit",
+				query:    types.SHIPQuery{Skip: intPtr(1), Limit: intPtr(2)},
+				expected: utxoRefs(2, 1),
+			},
+			{
+				name:     "skip and limit with ascending sort order",
+				query:    types.SHIPQuery{Skip: intPtr(1), Limit: intPtr(2), SortOrder: sortOrderPtr(types.SortOrderAsc)},
+				expected: utxoRefs(1, 2),
+			},
+			{
+				name:     "zero limit is ignored",
+				query:    types.SHIPQuery{Limit: intPtr(0)},
+				expected: utxoRefs(3, 2, 1, 0),
+			},
+			{
+				name:  "skip past the end",
+				query: types.SHIPQuery{Skip: intPtr(10)},
+			},
+			{
+				name:     "empty cursor starts at the first record",
+				query:    types.SHIPQuery{Cursor: stringPtr("")},
+				expected: utxoRefs(3, 2, 1, 0),
+			},
+			{
+				name:     "cursor continues after the record",
+				query:    types.SHIPQuery{Cursor: cursorAt(2)},
+				expected: utxoRefs(1, 0),
+			},
+			{
+				name:     "cursor with ascending sort order",
+				query:    types.SHIPQuery{Cursor: cursorAt(1), SortOrder: sortOrderPtr(types.SortOrderAsc)},
+				expected: utxoRefs(2, 3),
+			},
+			{
+				name:     "cursor combined with filters and limit",
+				query:    types.SHIPQuery{Cursor: cursorAt(3), Domain: stringPtr("https://a.example.com"), Limit: intPtr(1)},
+				expected: utxoRefs(2),
+			},
+			{
+				name:     "created after is inclusive",
+				query:    types.SHIPQuery{CreatedAfter: createdAt(1)},
+				expected: utxoRefs(3, 2, 1),
+			},
+			{
+				name:     "created before is exclusive",
+				query:    types.SHIPQuery{CreatedBefore: createdAt(2)},
+				expected: utxoRefs(1, 0),
+			},
+			{
+				name:     "created time window",
+				query:    types.SHIPQuery{CreatedAfter: createdAt(1), CreatedBefore: createdAt(3)},
+				expected: utxoRefs(2, 1),
+			},
+			{
+				name:     "created after in another time zone",
+				query:    types.SHIPQuery{CreatedAfter: timePtr(createdAt(2).In(time.FixedZone("UTC+2", 2*60*60)))},
+				expected: utxoRefs(3, 2),
+			},
+			{
+				name:     "created after combined with domain",
+				query:    types.SHIPQuery{CreatedAfter: createdAt(1), Domain: stringPtr("https://a.example.com")},
+				expected: utxoRefs(3, 2),
+			},
+			{
+				name:     "created after combined with cursor",
+				query:    types.SHIPQuery{CreatedAfter: createdAt(1), Cursor: cursorAt(3)},
+				expected: utxoRefs(2, 1),
+			},
+			{
+				name:  "created after the newest record",
+				query: types.SHIPQuery{CreatedAfter: timePtr(createdAt(3).Add(time.Millisecond))},
+			},
+			{
+				name:  "cursor at the last record",
+				query: types.SHIPQuery{Cursor: cursorAt(0)},
+			},
+			{
+				name:  "no matches",
+				query: types.SHIPQuery{Domain: stringPtr("https://missing.example.com")},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				storage := seedConformanceStorage(t, newStorage)
+
+				results, err := storage.FindRecord(context.Background(), tt.query)
+				require.NoError(t, err)
+
+				// FindSHIPRecords must apply exactly the same filters, sorting and pagination
+				records, err := storage.FindSHIPRecords(context.Background(), tt.query)
+				require.NoError(t, err)
+
+				if len(tt.expected) == 0 {
+					assert.Empty(t, results)
+					assert.Empty(t, records)
+					return
+				}
+				assert.Equal(t, tt.expected, results)
+
+				refs := make([]types.UTXOReference, 0, len(records))
+				for _, record := range records {
+					refs = append(refs, types.UTXOReference{Txid: record.Txid, OutputIndex: record.OutputIndex})
+				}
+				assert.Equal(t, tt.expected, refs)
+			})
+		}
+	})
+
+	t.Run("FindSHIPRecords returns full records", func(t *testing.T) {
+		storage := seedConformanceStorage(t, newStorage)
+
+		records, err := storage.FindSHIPRecords(context.Background(), types.SHIPQuery{IdentityKey: stringPtr("key2")})
+		require.NoError(t, err)
+		assert.Equal(t, shipRecords(3, 2), normalizeSHIPRecords(records))
+	})
+
+	t.Run("FindAll", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			limit     *int
+			skip      *int
+			sortOrder *types.SortOrder
+			expected  []types.UTXOReference
+		}{
+			{
+				name:     "defaults to newest first",
+				expected: utxoRefs(3, 2, 1, 0),
+			},
+			{
+				name:      "explicit descending sort order",
+				sortOrder: sortOrderPtr(types.SortOrderDesc),
+				expected:  utxoRefs(3, 2, 1, 0),
+			},
+			{
+				name:      "ascending sort order",
+				sortOrder: sortOrderPtr(types.SortOrderAsc),
+				expected:  utxoRefs(0, 1, 2, 3),
+			},
+			{
+				name:     "limit",
+				limit:    intPtr(2),
+				expected: utxoRefs(3, 2),
+			},
+			{
+				name:      "skip with ascending sort order",
+				skip:      intPtr(3),
+				sortOrder: sortOrderPtr(types.SortOrderAsc),
+				expected:  utxoRefs(3),
+			},
+			{
+				name: "skip past the end",
+				skip: intPtr(4),
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				storage := seedConformanceStorage(t, newStorage)
+
+				results, err := storage.FindAll(context.Background(), tt.limit, tt.skip, tt.sortOrder)
+				require.NoError(t, err)
+				if len(tt.expected) == 0 {
+					assert.Empty(t, results)
+					return
+				}
+				assert.Equal(t, tt.expected, results)
+			})
+		}
+	})
+
+	t.Run("cursor pagination walks every record exactly once", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			query    types.SHIPQuery
+			expected []types.UTXOReference
+		}{
+			{
+				name:     "descending",
+				expected: utxoRefs(3, 2, 1, 0),
+			},
+			{
+				name:     "ascending",
+				query:    types.SHIPQuery{SortOrder: sortOrderPtr(types.SortOrderAsc)},
+				expected: utxoRefs(0, 1, 2, 3),
+			},
+			{
+				name:     "filtered",
+				query:    types.SHIPQuery{Domain: stringPtr("https://a.example.com")},
+				expected: utxoRefs(3, 2, 0),
+			},
+		}
+
+		for _, tt := range tests {
+			for _, pageSize := range []int{1, 2, 3, 4} {
+				t.Run(fmt.Sprintf("%s with page size %d", tt.name, pageSize), func(t *testing.T) {
+					storage := seedConformanceStorage(t, newStorage)
+
+					assert.Equal(t, tt.expected, walkSHIPRecords(t, storage, tt.query, pageSize))
+				})
+			}
+		}
+	})
+
+	t.Run("cursor pagination breaks createdAt ties on txid and outputIndex", func(t *testing.T) {
+		createdAt := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+		storage := newStorage(t, func() time.Time { return createdAt })
+		for _, ref := range []types.UTXOReference{{Txid: "txidB", OutputIndex: 1}, {Txid: "txidA", OutputIndex: 0}, {Txid: "txidB", OutputIndex: 0}} {
+			_, err := storage.StoreSHIPRecord(context.Background(), ref.Txid, ref.OutputIndex, "key1", "https://a.example.com", "tm_alpha")
+			require.NoError(t, err)
+		}
+
+		expected := []types.UTXOReference{{Txid: "txidB", OutputIndex: 1}, {Txid: "txidB", OutputIndex: 0}, {Txid: "txidA", OutputIndex: 0}}
+		assert.Equal(t, expected, walkSHIPRecords(t, storage, types.SHIPQuery{}, 1))
+
+		results, err := storage.FindAll(context.Background(), nil, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, expected, results)
+	})
+
+	t.Run("cursor pages are stable across inserts and deletes", func(t *testing.T) {
+		storage := seedConformanceStorage(t, newStorage)
+
+		firstPage, err := storage.FindSHIPRecords(context.Background(), types.SHIPQuery{Limit: intPtr(2)})
+		require.NoError(t, err)
+		require.Len(t, firstPage, 2)
+
+		// A newer record is admitted and an already returned one is spent between the two calls
+		_, err = storage.StoreSHIPRecord(context.Background(), "txid5", 0, "key3", "https://c.example.com", "tm_delta")
+		require.NoError(t, err)
+		require.NoError(t, storage.DeleteSHIPRecord(context.Background(), "txid4", 0))
+
+		last := firstPage[1]
+		cursor := types.RecordCursor{CreatedAt: last.CreatedAt, Txid: last.Txid, OutputIndex: last.OutputIndex}.Encode()
+		results, err := storage.FindRecord(context.Background(), types.SHIPQuery{Cursor: &cursor, Limit: intPtr(2)})
+		require.NoError(t, err)
+		assert.Equal(t, utxoRefs(1, 0), results)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		storage := seedConformanceStorage(t, newStorage)
+
+		_, err := storage.FindRecord(context.Background(), types.SHIPQuery{Cursor: stringPtr("not a cursor")})
+		require.ErrorIs(t, err, types.ErrInvalidCursor)
+
+		_, err = storage.FindSHIPRecords(context.Background(), types.SHIPQuery{Cursor: stringPtr("not a cursor")})
+		require.ErrorIs(t, err, types.ErrInvalidCursor)
+	})
+
+	t.Run("host pattern and scheme filters", func(t *testing.T) {
+		domains := []string{
+			"https://example.com",
+			"https+bsvauth://api.example.com",
+			"https+bsvauth+smf://eu.api.example.com:8443/",
+			"wss://badexample.com",
+			"js8c+bsvauth+smf:?lat=40.7128&long=-74.0060&freq=7.078&radius=100",
+			"https://API.Example.org/",
+		}
+		refs := func(positions ...int) []types.UTXOReference {
+			results := make([]types.UTXOReference, 0, len(positions))
+			for _, position := range positions {
+				results = append(results, types.UTXOReference{Txid: fmt.Sprintf("host%d", position), OutputIndex: 0})
+			}
+			return results
+		}
+
+		tests := []struct {
+			name     string
+			query    types.SHIPQuery
+			expected []types.UTXOReference
+		}{
+			{
+				name:     "exact host",
+				query:    types.SHIPQuery{HostPattern: stringPtr("example.com")},
+				expected: refs(0),
+			},
+			{
+				name:     "exact host is case-insensitive",
+				query:    types.SHIPQuery{HostPattern: stringPtr("api.EXAMPLE.org.")},
+				expected: refs(5),
+			},
+			{
+				name:     "wildcard matches subdomains at any depth but not the apex",
+				query:    types.SHIPQuery{HostPattern: stringPtr("*.example.com")},
+				expected: refs(2, 1),
+			},
+			{
+				name:     "nested wildcard",
+				query:    types.SHIPQuery{HostPattern: stringPtr("*.api.example.com")},
+				expected: refs(2),
+			},
+			{
+				name:     "schemes",
+				query:    types.SHIPQuery{Schemes: []string{"https+bsvauth", "wss"}},
+				expected: refs(3, 1),
+			},
+			{
+				name:     "scheme without host",
+				query:    types.SHIPQuery{Schemes: []string{"js8c+bsvauth+smf"}},
+				expected: refs(4),
+			},
+			{
+				name:     "host pattern combined with schemes",
+				query:    types.SHIPQuery{HostPattern: stringPtr("*.example.com"), Schemes: []string{"https+bsvauth"}},
+				expected: refs(1),
+			},
+			{
+				name:     "host pattern combined with domain",
+				query:    types.SHIPQuery{HostPattern: stringPtr("*.example.com"), Domain: stringPtr("https+bsvauth+smf://eu.api.example.com:8443/")},
+				expected: refs(2),
+			},
+			{
+				name:  "no matches",
+				query: types.SHIPQuery{HostPattern: stringPtr("*.example.net")},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				storage := newStorage(t, newTestClock())
+				for i, domain := range domains {
+					_, err := storage.StoreSHIPRecord(context.Background(), fmt.Sprintf("host%d", i), 0, "key1", domain, "tm_alpha")
+					require.NoError(t, err)
+				}
+
+				results, err := storage.FindRecord(context.Background(), tt.query)
+				require.NoError(t, err)
+
+				records, err := storage.FindSHIPRecords(context.Background(), tt.query)
+				require.NoError(t, err)
+
+				if len(tt.expected) == 0 {
+					assert.Empty(t, results)
+					assert.Empty(t, records)
+					return
+				}
+				assert.Equal(t, tt.expected, results)
+				require.Len(t, records, len(tt.expected))
+				for _, record := range records {
+					uri, ok := utils.ParseAdvertisableURI(record.Domain)
+					require.True(t, ok)
+					assert.Equal(t, uri.Scheme, record.Scheme)
+					assert.Equal(t, uri.Host, record.Host)
+				}
+			})
+		}
+	})
+
+	t.Run("CountSHIPRecords", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			query    types.SHIPQuery
+			groupBy  types.AggregateMode
+			expected []types.AggregateCount
+		}{
+			{
+				name:     "by topic",
+				groupBy:  types.AggregateCountByTopic,
+				expected: []types.AggregateCount{{Key: "tm_beta", Count: 2}, {Key: "tm_alpha", Count: 1}, {Key: "tm_gamma", Count: 1}},
+			},
+			{
+				name:     "by domain",
+				groupBy:  types.AggregateCountByDomain,
+				expected: []types.AggregateCount{{Key: "https://a.example.com", Count: 3}, {Key: "https://b.example.com", Count: 1}},
+			},
+			{
+				name:     "by identity key",
+				groupBy:  types.AggregateCountByIdentityKey,
+				expected: []types.AggregateCount{{Key: "key1", Count: 2}, {Key: "key2", Count: 2}},
+			},
+			{
+				name:     "filtered",
+				query:    types.SHIPQuery{Domain: stringPtr("https://a.example.com")},
+				groupBy:  types.AggregateCountByTopic,
+				expected: []types.AggregateCount{{Key: "tm_alpha", Count: 1}, {Key: "tm_beta", Count: 1}, {Key: "tm_gamma", Count: 1}},
+			},
+			{
+				name:     "pagination and sort order are ignored",
+				query:    types.SHIPQuery{Limit: intPtr(1), Skip: intPtr(1), SortOrder: sortOrderPtr(types.SortOrderAsc)},
+				groupBy:  types.AggregateCountByIdentityKey,
+				expected: []types.AggregateCount{{Key: "key1", Count: 2}, {Key: "key2", Count: 2}},
+			},
+			{
+				name:    "no matches",
+				query:   types.SHIPQuery{Domain: stringPtr("https://missing.example.com")},
+				groupBy: types.AggregateCountByTopic,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				storage := seedConformanceStorage(t, newStorage)
+
+				counts, err := storage.CountSHIPRecords(context.Background(), tt.query, tt.groupBy)
+				require.NoError(t, err)
+				if len(tt.expected) == 0 {
+					assert.Empty(t, counts)
+					return
+				}
+				assert.Equal(t, tt.expected, counts)
+			})
+		}
+
+		t.Run("unsupported aggregate", func(t *testing.T) {
+			storage := seedConformanceStorage(t, newStorage)
+
+			_, err := storage.CountSHIPRecords(context.Background(), types.SHIPQuery{}, types.AggregateCountByService)
+			require.ErrorIs(t, err, errUnsupportedAggregate)
+		})
+	})
+
+	t.Run("block heights and confirmations", func(t *testing.T) {
+		// txid1 to txid3 are mined at heights 100, 105 and 110; txid4 is unconfirmed
+		seed := func(t *testing.T) StorageInterface {
+			storage := seedConformanceStorage(t, newStorage)
+			for txid, height := range map[string]uint32{"txid1": 100, "txid2": 105, "txid3": 110} {
+				require.NoError(t, storage.UpdateSHIPRecordBlock(context.Background(), txid, height, fmt.Sprintf("hash%d", height)))
+			}
+			return storage
+		}
+
+		tests := []struct {
+			name     string
+			query    types.SHIPQuery
+			expected []types.UTXOReference
+		}{
+			{"zero confirmations match unconfirmed records", types.SHIPQuery{MinConfirmations: intPtr(0), ChainTip: uint32Ptr(110)}, utxoRefs(3, 2, 1, 0)},
+			{"one confirmation matches mined records", types.SHIPQuery{MinConfirmations: intPtr(1), ChainTip: uint32Ptr(110)}, utxoRefs(2, 1, 0)},
+			{"six confirmations", types.SHIPQuery{MinConfirmations: intPtr(6), ChainTip: uint32Ptr(110)}, utxoRefs(1, 0)},
+			{"eleven confirmations", types.SHIPQuery{MinConfirmations: intPtr(11), ChainTip: uint32Ptr(110)}, utxoRefs(0)},
+			{"more confirmations than any record", types.SHIPQuery{MinConfirmations: intPtr(12), ChainTip: uint32Ptr(110)}, nil},
+			{"more confirmations than blocks", types.SHIPQuery{MinConfirmations: intPtr(200), ChainTip: uint32Ptr(110)}, nil},
+			{"combined with other filters", types.SHIPQuery{MinConfirmations: intPtr(1), ChainTip: uint32Ptr(110), IdentityKey: stringPtr("key2")}, utxoRefs(2)},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				storage := seed(t)
+
+				results, err := storage.FindRecord(context.Background(), tt.query)
+				require.NoError(t, err)
+				if len(tt.expected) == 0 {
+					assert.Empty(t, results)
+					return
+				}
+				assert.Equal(t, tt.expected, results)
+			})
+		}
+
+		t.Run("full records carry the block", func(t *testing.T) {
+			storage := seed(t)
+
+			records, err := storage.FindSHIPRecords(context.Background(), types.SHIPQuery{SortOrder: sortOrderPtr(types.SortOrderAsc)})
+			require.NoError(t, err)
+			require.Len(t, records, 4)
+			assert.Equal(t, uint32(100), records[0].BlockHeight)
+			assert.Equal(t, "hash100", records[0].BlockHash)
+			assert.Equal(t, uint32(0), records[3].BlockHeight)
+			assert.Empty(t, records[3].BlockHash)
+		})
+
+		t.Run("all outputs of the transaction are updated", func(t *testing.T) {
+			storage := seed(t)
+
+			_, err := storage.StoreSHIPRecord(context.Background(), "txid4", 1, "key2", "https://a.example.com", "tm_gamma")
+			require.NoError(t, err)
+			require.NoError(t, storage.UpdateSHIPRecordBlock(context.Background(), "txid4", 111, "hash111"))
+
+			results, err := storage.FindRecord(context.Background(), types.SHIPQuery{MinConfirmations: intPtr(1), ChainTip: uint32Ptr(111), Topics: []string{"tm_gamma"}})
+			require.NoError(t, err)
+			assert.ElementsMatch(t, []types.UTXOReference{{Txid: "txid4", OutputIndex: 0}, {Txid: "txid4", OutputIndex: 1}}, results)
+		})
+
+		t.Run("reorg resets orphaned blocks", func(t *testing.T) {
+			storage := seed(t)
+
+			reset, err := storage.ResetSHIPRecordBlocks(context.Background(), 105)
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), reset)
+
+			results, err := storage.FindRecord(context.Background(), types.SHIPQuery{MinConfirmations: intPtr(1), ChainTip: uint32Ptr(104)})
+			require.NoError(t, err)
+			assert.Equal(t, utxoRefs(0), results)
+
+			records, err := storage.FindSHIPRecords(context.Background(), types.SHIPQuery{Domain: stringPtr("https://b.example.com")})
+			require.NoError(t, err)
+			require.Len(t, records, 1)
+			assert.Equal(t, uint32(0), records[0].BlockHeight)
+			assert.Empty(t, records[0].BlockHash)
+		})
+
+		t.Run("confirmations require the chain tip", func(t *testing.T) {
+			storage := seed(t)
+
+			_, err := storage.FindRecord(context.Background(), types.SHIPQuery{MinConfirmations: intPtr(1)})
+			require.ErrorIs(t, err, errChainTipRequired)
+
+			_, err = storage.CountSHIPRecords(context.Background(), types.SHIPQuery{MinConfirmations: intPtr(1)}, types.AggregateCountByTopic)
+			require.ErrorIs(t, err, errChainTipRequired)
+		})
+	})
+
+	t.Run("invalid host pattern", func(t *testing.T) {
+		storage := seedConformanceStorage(t, newStorage)
+
+		_, err := storage.FindRecord(context.Background(), types.SHIPQuery{HostPattern: stringPtr("api.*.example.com")})
+		require.ErrorIs(t, err, utils.ErrInvalidHostPattern)
+
+		_, err = storage.FindSHIPRecords(context.Background(), types.SHIPQuery{HostPattern: stringPtr("")})
+		require.ErrorIs(t, err, utils.ErrInvalidHostPattern)
+	})
+
+	t.Run("FindAll on empty storage", func(t *testing.T) {
+		storage := newStorage(t, newTestClock())
+
+		results, err := storage.FindAll(context.Background(), nil, nil, nil)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+
+	t.Run("DeleteSHIPRecord removes only the given outpoint", func(t *testing.T) {
+		storage := seedConformanceStorage(t, newStorage)
+
+		require.NoError(t, storage.DeleteSHIPRecord(context.Background(), "txid3", 1))
+
+		results, err := storage.FindAll(context.Background(), nil, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, utxoRefs(3, 1, 0), results)
+	})
+
+	t.Run("DeleteSHIPRecord matches on output index", func(t *testing.T) {
+		storage := seedConformanceStorage(t, newStorage)
+
+		require.NoError(t, storage.DeleteSHIPRecord(context.Background(), "txid3", 0))
+
+		results, err := storage.FindAll(context.Background(), nil, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, utxoRefs(3, 2, 1, 0), results)
+	})
+
+	t.Run("DeleteSHIPRecord of a missing record is not an error", func(t *testing.T) {
+		storage := newStorage(t, newTestClock())
+
+		require.NoError(t, storage.DeleteSHIPRecord(context.Background(), "missing", 0))
+	})
+
+	t.Run("StoreSHIPRecord is idempotent per outpoint", func(t *testing.T) {
+		storage := seedConformanceStorage(t, newStorage)
+
+		// Replaying an admission must neither duplicate the record nor move it to the front
+		inserted, err := storage.StoreSHIPRecord(context.Background(), "txid1", 0, "key1", "https://a.example.com", "tm_alpha")
+		require.NoError(t, err)
+		assert.False(t, inserted)
+
+		results, err := storage.FindAll(context.Background(), nil, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, utxoRefs(3, 2, 1, 0), results)
+	})
+
+	t.Run("StoreSHIPRecord stores other outputs of the same transaction", func(t *testing.T) {
+		storage := seedConformanceStorage(t, newStorage)
+
+		inserted, err := storage.StoreSHIPRecord(context.Background(), "txid1", 1, "key1", "https://a.example.com", "tm_alpha")
+		require.NoError(t, err)
+		assert.True(t, inserted)
+
+		results, err := storage.FindAll(context.Background(), intPtr(1), nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []types.UTXOReference{{Txid: "txid1", OutputIndex: 1}}, results)
+	})
+
+	t.Run("EnsureIndexes", func(t *testing.T) {
+		storage := newStorage(t, newTestClock())
+
+		require.NoError(t, storage.EnsureIndexes(context.Background()))
+	})
+}
+
+func TestMemoryStorageConformance(t *testing.T) {
+	runStorageConformanceTests(t, func(_ *testing.T, now func() time.Time) StorageInterface {
+		storage := NewMemoryStorage()
+		storage.now = now
+		return storage
+	})
+}
+
+func TestSQLStorageConformance(t *testing.T) {
+	runStorageConformanceTests(t, func(t *testing.T, now func() time.Time) StorageInterface {
+		db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "ship.db")), &gorm.Config{})
+		require.NoError(t, err)
+
+		storage := NewSQLStorage(db)
+		storage.now = now
+		require.NoError(t, storage.EnsureIndexes(context.Background()))
+		return storage
+	})
+}
+
+func TestSQLStorageRemoveDuplicateRecords(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "ship.db")), &gorm.Config{})
+	require.NoError(t, err)
+
+	storage := NewSQLStorage(db)
+	storage.now = newTestClock()
+	require.NoError(t, storage.EnsureIndexes(ctx))
+
+	// Simulate a table populated before the unique outpoint index existed
+	require.NoError(t, db.Migrator().DropIndex(&sqlSHIPRecord{}, "idx_ship_records_outpoint"))
+	for _, record := range []sqlSHIPRecord{
+		{Txid: "txid1", OutputIndex: 0, IdentityKey: "key1", Domain: "https://a.example.com", Topic: "tm_alpha", CreatedAt: storage.now()},
+		{Txid: "txid2", OutputIndex: 0, IdentityKey: "key1", Domain: "https://b.example.com", Topic: "tm_beta", CreatedAt: storage.now()},
+		{Txid: "txid1", OutputIndex: 0, IdentityKey: "key1", Domain: "https://a.example.com", Topic: "tm_alpha", CreatedAt: storage.now()},
+		{Txid: "txid1", OutputIndex: 0, IdentityKey: "key1", Domain: "https://a.example.com", Topic: "tm_alpha", CreatedAt: storage.now()},
+	} {
+		require.NoError(t, db.Create(&record).Error)
+	}
+
+	removed, err := storage.RemoveDuplicateRecords(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), removed)
+
+	require.NoError(t, storage.EnsureIndexes(ctx))
+
+	results, err := storage.FindAll(ctx, nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, utxoRefs(1, 0), results)
+}
+
+func TestSQLStorageBackfillHostFields(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "ship.db")), &gorm.Config{})
+	require.NoError(t, err)
+
+	storage := NewSQLStorage(db)
+	storage.now = newTestClock()
+	require.NoError(t, storage.EnsureIndexes(ctx))
+
+	// Simulate records stored before the host columns existed
+	for _, record := range []sqlSHIPRecord{
+		{Txid: "txid1", OutputIndex: 0, IdentityKey: "key1", Domain: "https://a.example.com", Topic: "tm_alpha", CreatedAt: storage.now()},
+		{Txid: "txid2", OutputIndex: 0, IdentityKey: "key1", Domain: "https://b.example.com", Topic: "tm_beta", CreatedAt: storage.now()},
+	} {
+		require.NoError(t, db.Create(&record).Error)
+	}
+
+	results, err := storage.FindRecord(ctx, types.SHIPQuery{HostPattern: stringPtr("*.example.com")})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	updated, err := storage.BackfillHostFields(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), updated)
+
+	results, err = storage.FindRecord(ctx, types.SHIPQuery{HostPattern: stringPtr("*.example.com"), Schemes: []string{"https"}})
+	require.NoError(t, err)
+	assert.Equal(t, utxoRefs(1, 0), results)
+
+	// Running the migration again is a no-op
+	updated, err = storage.BackfillHostFields(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, updated)
+}
+
+// TestMongoStorageConformance runs the conformance suite against a live MongoDB server.
+// It is skipped unless MONGO_TEST_URI points at a server the tests may create databases on.
+func TestMongoStorageConformance(t *testing.T) {
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI is not set")
+	}
+
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+	defer func() {
+		_ = client.Disconnect(ctx)
+	}()
+	require.NoError(t, client.Ping(ctx, nil))
+
+	runStorageConformanceTests(t, func(t *testing.T, now func() time.Time) StorageInterface {
+		db := client.Database(fmt.Sprintf("ship_conformance_%d", time.Now().UnixNano()))
+		t.Cleanup(func() {
+			_ = db.Drop(context.Background())
+		})
+
+		storage := NewStorage(db)
+		storage.now = now
+		require.NoError(t, storage.EnsureIndexes(context.Background()))
+		return storage
+	})
+}
+
+// TestMongoStorageRemoveDuplicateRecords verifies the de-duplication migration against a live
+// MongoDB server. It is skipped unless MONGO_TEST_URI is set.
+func TestMongoStorageRemoveDuplicateRecords(t *testing.T) {
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI is not set")
+	}
+
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+	defer func() {
+		_ = client.Disconnect(ctx)
+	}()
+
+	db := client.Database(fmt.Sprintf("ship_dedupe_%d", time.Now().UnixNano()))
+	defer func() {
+		_ = db.Drop(ctx)
+	}()
+
+	storage := NewStorage(db)
+	now := newTestClock()
+
+	// Simulate a collection populated before the unique outpoint index existed
+	for _, record := range []types.SHIPRecord{
+		{Txid: "txid1", OutputIndex: 0, IdentityKey: "key1", Domain: "https://a.example.com", Topic: "tm_alpha", CreatedAt: now()},
+		{Txid: "txid2", OutputIndex: 0, IdentityKey: "key1", Domain: "https://b.example.com", Topic: "tm_beta", CreatedAt: now()},
+		{Txid: "txid1", OutputIndex: 0, IdentityKey: "key1", Domain: "https://a.example.com", Topic: "tm_alpha", CreatedAt: now()},
+		{Txid: "txid1", OutputIndex: 0, IdentityKey: "key1", Domain: "https://a.example.com", Topic: "tm_alpha", CreatedAt: now()},
+	} {
+		_, err := storage.shipRecords.InsertOne(ctx, record)
+		require.NoError(t, err)
+	}
+
+	removed, err := storage.RemoveDuplicateRecords(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), removed)
+
+	require.NoError(t, storage.EnsureIndexes(ctx))
+
+	results, err := storage.FindAll(ctx, nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, utxoRefs(1, 0), results)
+}
+
+// TestMongoStorageBackfillHostFields verifies the host fields migration against a live
+// MongoDB server. It is skipped unless MONGO_TEST_URI is set.
+func TestMongoStorageBackfillHostFields(t *testing.T) {
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI is not set")
+	}
+
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+	defer func() {
+		_ = client.Disconnect(ctx)
+	}()
+
+	db := client.Database(fmt.Sprintf("ship_backfill_%d", time.Now().UnixNano()))
+	defer func() {
+		_ = db.Drop(ctx)
+	}()
+
+	storage := NewStorage(db)
+	require.NoError(t, storage.EnsureIndexes(ctx))
+	now := newTestClock()
+
+	// Simulate records stored before the host fields existed
+	for _, record := range []bson.M{
+		{"txid": "txid1", "outputIndex": 0, "identityKey": "key1", "domain": "https://a.example.com", "topic": "tm_alpha", "createdAt": now()},
+		{"txid": "txid2", "outputIndex": 0, "identityKey": "key1", "domain": "https://b.example.com", "topic": "tm_beta", "createdAt": now()},
+	} {
+		_, err := storage.shipRecords.InsertOne(ctx, record)
+		require.NoError(t, err)
+	}
+
+	updated, err := storage.BackfillHostFields(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), updated)
+
+	results, err := storage.FindRecord(ctx, types.SHIPQuery{HostPattern: stringPtr("*.example.com"), Schemes: []string{"https"}})
+	require.NoError(t, err)
+	assert.Equal(t, utxoRefs(1, 0), results)
+
+	// Running the migration again is a no-op
+	updated, err = storage.BackfillHostFields(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, updated)
+}
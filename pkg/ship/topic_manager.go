@@ -6,16 +6,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"log/slog"
-	"strings"
+	"math"
 	"sync"
 	"time"
 
+	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/types"
 	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/utils"
 	"github.com/bsv-blockchain/go-sdk/overlay"
+	"github.com/bsv-blockchain/go-sdk/script"
 	"github.com/bsv-blockchain/go-sdk/transaction"
-	"github.com/bsv-blockchain/go-sdk/transaction/template/pushdrop"
 )
 
 // Static error variables for err113 compliance
@@ -68,17 +68,30 @@ type TopicManager struct {
 	storage StorageInterface
 	// lookupService provides access to SHIP lookup operations (optional integration)
 	lookupService *LookupService
+	// logger receives the admission logs, the default slog logger if nil
+	logger *slog.Logger
+	// admissionObserver receives the admission reports (optional)
+	admissionObserver types.AdmissionObserver
+	// policies are the admission rules applied after the built-in checks (optional)
+	policies utils.AdmissionPolicies
+	// quotas limits the active records admitted per identity key, identity key and topic, and domain
+	quotas types.AdvertisementQuotas
+	// verificationConcurrency bounds the outputs verified in parallel, 0 for runtime.GOMAXPROCS(0)
+	verificationConcurrency int
 }
 
 // NewTopicManager creates a new SHIP topic manager instance.
 // This constructor initializes the topic manager with the required dependencies
 // for managing overlay network topic subscriptions and message routing.
-func NewTopicManager(storage StorageInterface, lookupService *LookupService) *TopicManager {
+// The optional admission policies are run in order on the SHIP advertisements passing the built-in
+// checks, and an advertisement is only admitted if every policy admits it.
+func NewTopicManager(storage StorageInterface, lookupService *LookupService, policies ...utils.AdmissionPolicy) *TopicManager {
 	return &TopicManager{
 		subscriptions: make(map[string]*TopicSubscription),
 		handlers:      make(map[string]TopicMessageHandler),
 		storage:       storage,
 		lookupService: lookupService,
+		policies:      policies,
 	}
 }
 
@@ -297,99 +310,215 @@ func (tm *TopicManager) GetTotalMessageCount() int64 {
 	return total
 }
 
+// SetLogger sets the logger receiving the admission logs of IdentifyAdmissibleOutputs.
+// A nil logger restores the default slog logger.
+func (tm *TopicManager) SetLogger(logger *slog.Logger) {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+	tm.logger = logger
+}
+
+// SetAdmissionObserver sets an optional hook receiving the admission report of every transaction
+// evaluated by IdentifyAdmissibleOutputs, telling why each output was admitted or rejected.
+// A nil observer removes the hook.
+func (tm *TopicManager) SetAdmissionObserver(observer types.AdmissionObserver) {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+	tm.admissionObserver = observer
+}
+
+// SetAdvertisementQuotas sets the quotas limiting the active SHIP records admitted per identity key,
+// identity key and topic, and domain. The records are counted in the storage of the topic manager when
+// admitting outputs, and outputs exceeding a quota are rejected with types.AdmissionRejectionQuota.
+func (tm *TopicManager) SetAdvertisementQuotas(quotas types.AdvertisementQuotas) {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+	tm.quotas = quotas
+}
+
+// SetVerificationConcurrency bounds the number of outputs of a transaction whose signatures are
+// verified in parallel by IdentifyAdmissibleOutputs. A zero or negative concurrency, the default,
+// uses runtime.GOMAXPROCS(0) goroutines, while a concurrency of 1 verifies the outputs serially.
+func (tm *TopicManager) SetVerificationConcurrency(concurrency int) {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+	tm.verificationConcurrency = concurrency
+}
+
 // IdentifyAdmissibleOutputs implements the engine.TopicManager interface
 // For SHIP, this identifies outputs that should be admitted to the overlay
 func (tm *TopicManager) IdentifyAdmissibleOutputs(ctx context.Context, beef []byte, previousCoins map[uint32]*transaction.TransactionOutput) (overlay.AdmittanceInstructions, error) {
-	outputsToAdmit := []uint32{}
+	tm.mutex.RLock()
+	logger, observer, quotas, concurrency := tm.logger, tm.admissionObserver, tm.quotas, tm.verificationConcurrency
+	tm.mutex.RUnlock()
+
+	report, err := tm.evaluateAdmission(ctx, beef, previousCoins, quotas, concurrency)
+	if err != nil {
+		return overlay.AdmittanceInstructions{}, err
+	}
+
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	logAdmission(ctx, logger, report)
+	if observer != nil {
+		observer(ctx, report)
+	}
+
+	return overlay.AdmittanceInstructions{
+		OutputsToAdmit: report.Admitted(),
+		CoinsToRetain:  []uint32{},
+	}, nil
+}
+
+// evaluateAdmission decides for each output of the transaction whether it is a valid SHIP advertisement
+// admitted by the policies and within the quotas. The signatures of the outputs are verified with at most
+// concurrency goroutines. It only fails if the records cannot be counted.
+func (tm *TopicManager) evaluateAdmission(ctx context.Context, beef []byte, previousCoins map[uint32]*transaction.TransactionOutput, quotas types.AdvertisementQuotas, concurrency int) (*types.AdmissionReport, error) {
+	report := &types.AdmissionReport{
+		Topic:         Topic,
+		Decisions:     []types.AdmissionDecision{},
+		PreviousCoins: len(previousCoins),
+	}
 
 	// Parse transaction from BEEF format
 	parsedTransaction, err := transaction.NewTransactionFromBEEF(beef)
 	if err != nil {
-		// Only log error if no outputs were admitted and no previous coins consumed
-		if len(outputsToAdmit) == 0 && len(previousCoins) == 0 {
-			log.Printf("⛴️ Error identifying admissible outputs: %v", err)
+		report.Reason = types.AdmissionRejectionInvalidBEEF
+		report.Err = err
+		return report, nil
+	}
+	report.Txid = parsedTransaction.TxID().String()
+
+	// Discount the advertisements spent by the transaction from the quotas
+	var quotaEnforcer *utils.QuotaEnforcer
+	if quotas != (types.AdvertisementQuotas{}) {
+		quotaEnforcer = utils.NewQuotaEnforcer(quotas, quotaCounter{storage: tm.storage})
+		for _, coin := range previousCoins {
+			if coin == nil {
+				continue
+			}
+			if token, err := utils.ParseAdvertisementToken(ctx, coin.LockingScript, overlay.ProtocolSHIP); err == nil {
+				quotaEnforcer.Release(token)
+			}
 		}
-		return overlay.AdmittanceInstructions{
-			OutputsToAdmit: outputsToAdmit,
-			CoinsToRetain:  []uint32{},
-		}, nil
 	}
 
-	// Check each output for SHIP token validity
+	// Decode and validate the tokens of all outputs, verifying their signatures in parallel
+	lockingScripts := make([]*script.Script, len(parsedTransaction.Outputs))
 	for i, output := range parsedTransaction.Outputs {
-		// Decode PushDrop token
-		result := pushdrop.Decode(output.LockingScript)
-		if result == nil {
-			continue // It's common for other outputs to be invalid; no need to log an error here
-		}
+		lockingScripts[i] = output.LockingScript
+	}
+	tokens, tokenErrs := utils.ParseAdvertisementTokens(ctx, lockingScripts, overlay.ProtocolSHIP, concurrency)
 
-		// SHIP tokens must have exactly 5 fields
-		if len(result.Fields) != 5 {
-			continue
+	// Check each output for SHIP token validity, applying the policies and quotas in output order
+	for i, token := range tokens {
+		if i > math.MaxUint32 {
+			break
 		}
-
-		// Check SHIP identifier (first field)
-		shipIdentifier := utils.UTFBytesToString(result.Fields[0])
-		if shipIdentifier != "SHIP" {
-			continue
+		decision := types.AdmissionDecision{OutputIndex: uint32(i)}
+		err := tokenErrs[i]
+		if err == nil {
+			err = tm.policies.Admit(ctx, token)
 		}
-
-		// Check advertised URI (third field)
-		advertisedURI := utils.UTFBytesToString(result.Fields[2])
-		if !utils.IsAdvertisableURI(advertisedURI) {
-			continue
+		if err == nil && quotaEnforcer != nil {
+			if err = tm.admitWithinQuotas(ctx, quotaEnforcer, recordTxid(parsedTransaction.TxID()), i, token); err != nil && !errors.Is(err, utils.ErrQuotaExceeded) {
+				return nil, err
+			}
 		}
-
-		// Check topic name (fourth field)
-		topic := utils.UTFBytesToString(result.Fields[3])
-		if !utils.IsValidTopicOrServiceName(topic) {
-			continue
+		if err != nil {
+			decision.Reason = utils.TokenRejectionReason(err)
+			decision.Err = err
+		} else {
+			decision.Admitted = true
 		}
+		report.Decisions = append(report.Decisions, decision)
+	}
 
-		// SHIP only accepts "tm_" (topic manager) advertisements
-		if !strings.HasPrefix(topic, "tm_") {
-			continue
+	return report, nil
+}
+
+// admitWithinQuotas counts the token of an output against the quotas, unless the output is already
+// stored, as when an admitted transaction is resubmitted. Its record is then part of the stored counts
+// already, and rejecting it would drop an advertisement that is within the quotas.
+func (tm *TopicManager) admitWithinQuotas(ctx context.Context, quotaEnforcer *utils.QuotaEnforcer, txid string, outputIndex int, token *utils.AdvertisementToken) error {
+	records, err := tm.storage.FindSHIPRecords(ctx, types.SHIPQuery{IdentityKey: &token.IdentityKey, Domain: &token.Domain, Topics: []string{token.TopicOrService}})
+	if err != nil {
+		return fmt.Errorf("failed to find stored SHIP records: %w", err)
+	}
+	for _, record := range records {
+		if record.Txid == txid && record.OutputIndex == outputIndex {
+			return nil
 		}
+	}
+	return quotaEnforcer.Admit(ctx, token)
+}
 
-		// Check token signature linkage
-		lockingPublicKey := result.LockingPublicKey.ToDERHex()
-		tokenFields := make(utils.TokenFields, len(result.Fields))
-		copy(tokenFields, result.Fields)
+// quotaCounter counts the active SHIP records in storage against the advertisement quotas
+type quotaCounter struct {
+	storage StorageInterface
+}
 
-		if valid, err := utils.IsTokenSignatureCorrectlyLinked(ctx, lockingPublicKey, tokenFields); err == nil && valid {
-			if i >= 0 && i <= 0xFFFFFFFF {
-				outputsToAdmit = append(outputsToAdmit, uint32(i))
-			}
-		} else if err == nil && !valid {
-			slog.Info("Invalid token signature linkage", "outputIndex", i, "txid", parsedTransaction.TxID())
-		}
+// Compile-time verification that quotaCounter implements utils.QuotaCounter
+var _ utils.QuotaCounter = quotaCounter{}
+
+// CountByIdentityKey counts the SHIP records of the identity key
+func (c quotaCounter) CountByIdentityKey(ctx context.Context, identityKey string) (int64, error) {
+	return c.count(ctx, types.SHIPQuery{IdentityKey: &identityKey}, types.AggregateCountByIdentityKey)
+}
+
+// CountByIdentityKeyAndTopicOrService counts the SHIP records of the identity key for the topic
+func (c quotaCounter) CountByIdentityKeyAndTopicOrService(ctx context.Context, identityKey, topic string) (int64, error) {
+	return c.count(ctx, types.SHIPQuery{IdentityKey: &identityKey, Topics: []string{topic}}, types.AggregateCountByIdentityKey)
+}
+
+// CountByDomain counts the SHIP records advertising the domain
+func (c quotaCounter) CountByDomain(ctx context.Context, domain string) (int64, error) {
+	return c.count(ctx, types.SHIPQuery{Domain: &domain}, types.AggregateCountByDomain)
+}
+
+// count sums the record counts of the query
+func (c quotaCounter) count(ctx context.Context, query types.SHIPQuery, groupBy types.AggregateMode) (int64, error) {
+	counts, err := c.storage.CountSHIPRecords(ctx, query, groupBy)
+	if err != nil {
+		return 0, err
 	}
 
-	// Friendly logging with ship emojis
-	if len(outputsToAdmit) > 0 {
-		if len(outputsToAdmit) == 1 {
-			log.Printf("🛳️ Ahoy! Admitted %d SHIP output!", len(outputsToAdmit))
-		} else {
-			log.Printf("🛳️ Ahoy! Admitted %d SHIP outputs!", len(outputsToAdmit))
-		}
+	var total int64
+	for _, count := range counts {
+		total += count.Count
 	}
+	return total, nil
+}
 
-	if len(previousCoins) > 0 {
-		if len(previousCoins) == 1 {
-			log.Printf("🚢 Consumed %d previous SHIP coin!", len(previousCoins))
-		} else {
-			log.Printf("🚢 Consumed %d previous SHIP coins!", len(previousCoins))
-		}
+// logAdmission logs the admission report. It is common for other outputs not to be SHIP tokens,
+// so those rejections are only logged at debug level, while rejected SHIP tokens are logged at info level.
+func logAdmission(ctx context.Context, logger *slog.Logger, report *types.AdmissionReport) {
+	if report.Reason != "" {
+		logger.WarnContext(ctx, "⛴️ Error identifying admissible outputs", "topic", report.Topic, "reason", report.Reason, "error", report.Err)
+		return
 	}
 
-	if len(outputsToAdmit) == 0 && len(previousCoins) == 0 {
-		log.Printf("⚓ No SHIP outputs admitted and no previous SHIP coins consumed.")
+	for _, decision := range report.Rejected() {
+		level := slog.LevelInfo
+		if decision.Reason == types.AdmissionRejectionNotPushDrop || decision.Reason == types.AdmissionRejectionProtocol {
+			level = slog.LevelDebug
+		}
+		logger.Log(ctx, level, "Rejected SHIP output", "txid", report.Txid, "outputIndex", decision.OutputIndex, "reason", decision.Reason, "error", decision.Err)
 	}
 
-	return overlay.AdmittanceInstructions{
-		OutputsToAdmit: outputsToAdmit,
-		CoinsToRetain:  []uint32{},
-	}, nil
+	// Friendly logging with ship emojis
+	admitted := len(report.Admitted())
+	if admitted > 0 {
+		logger.InfoContext(ctx, "🛳️ Ahoy! Admitted SHIP outputs!", "txid", report.Txid, "count", admitted)
+	}
+	if report.PreviousCoins > 0 {
+		logger.InfoContext(ctx, "🚢 Consumed previous SHIP coins!", "txid", report.Txid, "count", report.PreviousCoins)
+	}
+	if admitted == 0 && report.PreviousCoins == 0 {
+		logger.InfoContext(ctx, "⚓ No SHIP outputs admitted and no previous SHIP coins consumed.", "txid", report.Txid)
+	}
 }
 
 // IdentifyNeededInputs implements the engine.TopicManager interface
@@ -0,0 +1,43 @@
+package ship
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/bsv-blockchain/go-sdk/overlay"
+	"github.com/bsv-blockchain/go-sdk/script"
+)
+
+// BenchmarkIdentifyAdmissibleOutputs compares serial and parallel signature verification when admitting
+// a batch advertisement transaction
+func BenchmarkIdentifyAdmissibleOutputs(b *testing.B) {
+	lockingScripts := make([]*script.Script, 48)
+	for i := range lockingScripts {
+		lockingScripts[i], _ = createSignedAdvertisementScript(b, overlay.ProtocolSHIP, "https://example.com", fmt.Sprintf("tm_topic_%c", 'a'+i%26))
+	}
+	beef, _ := createAdmissionTestBEEF(b, lockingScripts...)
+
+	for _, bm := range []struct {
+		name        string
+		concurrency int
+	}{
+		{"serial", 1},
+		{"parallel", 0},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			topicManager := NewTopicManager(NewMemoryStorage(), nil)
+			topicManager.SetLogger(slog.New(slog.DiscardHandler))
+			topicManager.SetVerificationConcurrency(bm.concurrency)
+
+			b.ReportAllocs()
+			for b.Loop() {
+				instructions, err := topicManager.IdentifyAdmissibleOutputs(context.Background(), beef, nil)
+				if err != nil || len(instructions.OutputsToAdmit) != len(lockingScripts) {
+					b.Fatalf("expected %d admitted outputs, got %v, %v", len(lockingScripts), instructions.OutputsToAdmit, err)
+				}
+			}
+		})
+	}
+}
@@ -51,6 +51,10 @@ This allows other nodes to discover hosts by querying the lookup service for val
    - Must align with what is contemplated in BRC-101, which enforces certain URI formats (e.g., ` + "`https://`" + `, ` + "`wss://`" + `, or custom prefixed ` + "`https+bsvauth...`" + ` URIs).
    - No ` + "`localhost`" + ` or invalid URIs allowed.
 
+4. **Admission Policies**:
+   - Operators may configure additional admission policies (such as identity key allowlists or domain suffix filters), which every output passing the checks above must also satisfy.
+   - Operators may also limit the number of active records per identity key, per identity key and topic, and per domain. Outputs exceeding a quota are not admitted, while resubmitting an already admitted output is not counted twice.
+
 If any of these checks fail, the SHIP token output is _not_ admitted by the topic manager.
 
 ---
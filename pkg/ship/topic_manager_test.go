@@ -1,13 +1,22 @@
 package ship
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"testing"
 	"time"
 
+	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/types"
+	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/utils"
+	"github.com/bsv-blockchain/go-overlay-services/pkg/core/engine"
+	"github.com/bsv-blockchain/go-sdk/overlay"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -640,3 +649,235 @@ func TestIntegrationWithLookupService(t *testing.T) {
 	// Storage should be the same instance
 	assert.Equal(t, mockStorage, topicManager.storage)
 }
+
+// Admission reports
+
+// createAdmissionTestBEEF creates the BEEF of a transaction with an output per locking script
+func createAdmissionTestBEEF(t testing.TB, lockingScripts ...*script.Script) ([]byte, *transaction.Transaction) {
+	t.Helper()
+
+	tx := transaction.NewTransaction()
+	for _, lockingScript := range lockingScripts {
+		tx.AddOutput(&transaction.TransactionOutput{LockingScript: lockingScript, Satoshis: 1})
+	}
+	beef, err := tx.BEEF()
+	require.NoError(t, err)
+	return beef, tx
+}
+
+func TestIdentifyAdmissibleOutputs_AdmissionReport(t *testing.T) {
+	valid, _ := createSignedAdvertisementScript(t, overlay.ProtocolSHIP, "https://example.com", "tm_meter")
+	otherProtocol, _ := createSignedAdvertisementScript(t, overlay.ProtocolSLAP, "https://example.com", "ls_meter")
+	localhost, _ := createSignedAdvertisementScript(t, overlay.ProtocolSHIP, "https://localhost", "tm_meter")
+	otherPrefix, _ := createSignedAdvertisementScript(t, overlay.ProtocolSHIP, "https://example.com", "ls_meter")
+	unsigned, err := script.NewFromHex(createValidPushDropScript([][]byte{
+		[]byte("SHIP"),
+		{0x01, 0x02, 0x03, 0x04},
+		[]byte("https://example.com"),
+		[]byte("tm_meter"),
+		{0x30, 0x44, 0x02, 0x20, 0x01, 0x02, 0x03},
+	}))
+	require.NoError(t, err)
+	beef, tx := createAdmissionTestBEEF(t, valid, script.NewFromBytes([]byte{script.OpTRUE}), otherProtocol, localhost, otherPrefix, unsigned)
+
+	var logs bytes.Buffer
+	var reports []*types.AdmissionReport
+	topicManager := createTestSHIPTopicManager()
+	topicManager.SetLogger(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	topicManager.SetAdmissionObserver(func(_ context.Context, report *types.AdmissionReport) {
+		reports = append(reports, report)
+	})
+
+	instructions, err := topicManager.IdentifyAdmissibleOutputs(context.Background(), beef, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{0}, instructions.OutputsToAdmit)
+	assert.Empty(t, instructions.CoinsToRetain)
+
+	require.Len(t, reports, 1)
+	report := reports[0]
+	assert.Equal(t, Topic, report.Topic)
+	assert.Equal(t, tx.TxID().String(), report.Txid)
+	assert.Empty(t, report.Reason)
+	assert.Equal(t, []uint32{0}, report.Admitted())
+
+	expectedReasons := []types.AdmissionRejectionReason{
+		"",
+		types.AdmissionRejectionNotPushDrop,
+		types.AdmissionRejectionProtocol,
+		types.AdmissionRejectionURI,
+		types.AdmissionRejectionTopicOrService,
+		types.AdmissionRejectionSignature,
+	}
+	require.Len(t, report.Decisions, len(expectedReasons))
+	for i, decision := range report.Decisions {
+		assert.Equal(t, uint32(i), decision.OutputIndex)
+		assert.Equal(t, expectedReasons[i] == "", decision.Admitted)
+		assert.Equal(t, expectedReasons[i], decision.Reason)
+		if decision.Admitted {
+			assert.NoError(t, decision.Err)
+		} else {
+			assert.Error(t, decision.Err)
+		}
+	}
+
+	// Outputs that are not SHIP tokens are only logged at debug level
+	output := logs.String()
+	assert.Contains(t, output, "reason=uri")
+	assert.Contains(t, output, "reason=topicOrService")
+	assert.Contains(t, output, "reason=signature")
+	assert.NotContains(t, output, "reason=notPushDrop")
+	assert.NotContains(t, output, "reason=protocol")
+	assert.Contains(t, output, "count=1")
+
+	t.Run("removing the observer", func(t *testing.T) {
+		topicManager.SetAdmissionObserver(nil)
+		topicManager.SetLogger(nil)
+		_, err := topicManager.IdentifyAdmissibleOutputs(context.Background(), beef, nil)
+		require.NoError(t, err)
+		assert.Len(t, reports, 1)
+	})
+}
+
+func TestIdentifyAdmissibleOutputs_InvalidBEEF(t *testing.T) {
+	var logs bytes.Buffer
+	var report *types.AdmissionReport
+	topicManager := createTestSHIPTopicManager()
+	topicManager.SetLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+	topicManager.SetAdmissionObserver(func(_ context.Context, r *types.AdmissionReport) {
+		report = r
+	})
+
+	instructions, err := topicManager.IdentifyAdmissibleOutputs(context.Background(), []byte("not a BEEF"), map[uint32]*transaction.TransactionOutput{0: {}})
+	require.NoError(t, err)
+	assert.Empty(t, instructions.OutputsToAdmit)
+	assert.Empty(t, instructions.CoinsToRetain)
+
+	require.NotNil(t, report)
+	assert.Equal(t, types.AdmissionRejectionInvalidBEEF, report.Reason)
+	require.Error(t, report.Err)
+	assert.Empty(t, report.Txid)
+	assert.Empty(t, report.Decisions)
+	assert.Equal(t, 1, report.PreviousCoins)
+	assert.Contains(t, logs.String(), "level=WARN")
+	assert.Contains(t, logs.String(), "reason=invalidBeef")
+}
+
+func TestIdentifyAdmissibleOutputs_AdmissionPolicies(t *testing.T) {
+	allowed, identityKey := createSignedAdvertisementScript(t, overlay.ProtocolSHIP, "https://api.example.com", "tm_meter")
+	otherDomain, _ := createSignedAdvertisementScript(t, overlay.ProtocolSHIP, "https://example.org", "tm_meter")
+	otherTopic, _ := createSignedAdvertisementScript(t, overlay.ProtocolSHIP, "https://example.com", "tm_bridge")
+	beef, _ := createAdmissionTestBEEF(t, allowed, otherDomain, otherTopic)
+
+	tests := []struct {
+		name            string
+		policies        []utils.AdmissionPolicy
+		expectedOutputs []uint32
+	}{
+		{"No policies", nil, []uint32{0, 1, 2}},
+		{"Domain suffix filter", []utils.AdmissionPolicy{utils.DomainSuffixFilter("example.com")}, []uint32{0, 2}},
+		{"Chain of policies", []utils.AdmissionPolicy{utils.IdentityKeyAllowlist(identityKey), utils.DomainSuffixFilter("example.com"), utils.TopicOrServiceAllowlist("tm_meter")}, []uint32{0}},
+		{"Denied identity key", []utils.AdmissionPolicy{utils.IdentityKeyDenylist(identityKey)}, []uint32{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var report *types.AdmissionReport
+			topicManager := NewTopicManager(new(MockStorage), nil, tt.policies...)
+			topicManager.SetLogger(slog.New(slog.DiscardHandler))
+			topicManager.SetAdmissionObserver(func(_ context.Context, r *types.AdmissionReport) {
+				report = r
+			})
+
+			instructions, err := topicManager.IdentifyAdmissibleOutputs(context.Background(), beef, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedOutputs, instructions.OutputsToAdmit)
+
+			require.NotNil(t, report)
+			for _, decision := range report.Rejected() {
+				assert.Equal(t, types.AdmissionRejectionPolicy, decision.Reason)
+				require.ErrorIs(t, decision.Err, utils.ErrAdmissionPolicyRejected)
+			}
+		})
+	}
+}
+
+func TestIdentifyAdmissibleOutputs_AdvertisementQuotas(t *testing.T) {
+	stored, identityKey := createSignedAdvertisementScript(t, overlay.ProtocolSHIP, "https://example.com", "tm_meter")
+	meter, _ := createSignedAdvertisementScript(t, overlay.ProtocolSHIP, "https://api.example.com", "tm_meter")
+	bridge, _ := createSignedAdvertisementScript(t, overlay.ProtocolSHIP, "https://api.example.com", "tm_bridge")
+	beef, tx := createAdmissionTestBEEF(t, meter, bridge)
+
+	tests := []struct {
+		name            string
+		quotas          types.AdvertisementQuotas
+		previousCoins   map[uint32]*transaction.TransactionOutput
+		storedOutputs   []uint32
+		expectedOutputs []uint32
+	}{
+		{"No quotas", types.AdvertisementQuotas{}, nil, nil, []uint32{0, 1}},
+		{"Identity quota", types.AdvertisementQuotas{MaxRecordsPerIdentity: 2}, nil, nil, []uint32{0}},
+		{"Identity and topic quota", types.AdvertisementQuotas{MaxRecordsPerIdentityTopic: 1}, nil, nil, []uint32{1}},
+		{"Domain quota", types.AdvertisementQuotas{MaxRecordsPerDomain: 1}, nil, nil, []uint32{0}},
+		{"Spent advertisements are discounted", types.AdvertisementQuotas{MaxRecordsPerIdentity: 2}, map[uint32]*transaction.TransactionOutput{0: {LockingScript: stored}}, nil, []uint32{0, 1}},
+		{"Resubmitted transaction at the limit", types.AdvertisementQuotas{MaxRecordsPerIdentity: 3, MaxRecordsPerIdentityTopic: 2, MaxRecordsPerDomain: 2}, nil, []uint32{0, 1}, []uint32{0, 1}},
+		{"Partially admitted transaction resubmitted", types.AdvertisementQuotas{MaxRecordsPerIdentity: 2}, nil, []uint32{0}, []uint32{0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := NewMemoryStorage()
+			_, err := storage.StoreSHIPRecord(context.Background(), TxID, 0, identityKey, "https://example.com", "tm_meter")
+			require.NoError(t, err)
+			// Resubmitted outputs are stored the way the engine reports admitted outputs
+			lookupService := NewLookupService(storage)
+			for _, outputIndex := range tt.storedOutputs {
+				require.NoError(t, lookupService.OutputAdmittedByTopic(context.Background(), &engine.OutputAdmittedByTopic{
+					Topic:         Topic,
+					Outpoint:      &transaction.Outpoint{Txid: *tx.TxID(), Index: outputIndex},
+					LockingScript: tx.Outputs[outputIndex].LockingScript,
+				}))
+			}
+
+			var report *types.AdmissionReport
+			topicManager := NewTopicManager(storage, nil)
+			topicManager.SetLogger(slog.New(slog.DiscardHandler))
+			topicManager.SetAdvertisementQuotas(tt.quotas)
+			topicManager.SetAdmissionObserver(func(_ context.Context, r *types.AdmissionReport) {
+				report = r
+			})
+
+			instructions, err := topicManager.IdentifyAdmissibleOutputs(context.Background(), beef, tt.previousCoins)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedOutputs, instructions.OutputsToAdmit)
+
+			require.NotNil(t, report)
+			for _, decision := range report.Rejected() {
+				assert.Equal(t, types.AdmissionRejectionQuota, decision.Reason)
+				require.ErrorIs(t, decision.Err, utils.ErrQuotaExceeded)
+			}
+		})
+	}
+
+	t.Run("Storage error", func(t *testing.T) {
+		mockStorage := new(MockStorage)
+		mockStorage.On("FindSHIPRecords", mock.Anything, mock.Anything).Return([]types.SHIPRecord{}, nil)
+		mockStorage.On("CountSHIPRecords", mock.Anything, mock.Anything, mock.Anything).Return([]types.AggregateCount(nil), errTestHandler)
+		topicManager := NewTopicManager(mockStorage, nil)
+		topicManager.SetLogger(slog.New(slog.DiscardHandler))
+		topicManager.SetAdvertisementQuotas(types.AdvertisementQuotas{MaxRecordsPerDomain: 1})
+
+		_, err := topicManager.IdentifyAdmissibleOutputs(context.Background(), beef, nil)
+		require.ErrorIs(t, err, errTestHandler)
+	})
+
+	t.Run("Stored records lookup error", func(t *testing.T) {
+		mockStorage := new(MockStorage)
+		mockStorage.On("FindSHIPRecords", mock.Anything, mock.Anything).Return([]types.SHIPRecord(nil), errTestHandler)
+		topicManager := NewTopicManager(mockStorage, nil)
+		topicManager.SetLogger(slog.New(slog.DiscardHandler))
+		topicManager.SetAdvertisementQuotas(types.AdvertisementQuotas{MaxRecordsPerDomain: 1})
+
+		_, err := topicManager.IdentifyAdmissibleOutputs(context.Background(), beef, nil)
+		require.ErrorIs(t, err, errTestHandler)
+	})
+}
@@ -41,12 +41,32 @@ When you call ` + "`lookup(question)`" + ` on the SLAP Lookup Service, you must
      ` + "```" + `ts
      interface SLAPQuery {
        domain?: string
+       domains?: string[]
+       hostPattern?: string
+       schemes?: string[]
+       createdAfter?: string
+       createdBefore?: string
+       aggregate?: 'countByService' | 'countByDomain' | 'countByIdentityKey'
+       minConfirmations?: number
        service?: string
+       services?: string[]
+       includeRecords?: boolean
+       limit?: number
+       cursor?: string
      }
      ` + "```" + `
      where:
      - ` + "`domain`" + ` is an optional string. If provided, results will match that domain/advertisedURI.
+     - ` + "`domains`" + ` is an optional string array. If provided, results will match **any** of those domains. It can be combined with ` + "`domain`" + `, in which case all listed domains are accepted.
+     - ` + "`hostPattern`" + ` is an optional string matched against the host of each advertised URI, regardless of its scheme, port or case. ` + "`\"api.example.com\"`" + ` matches that host exactly, while ` + "`\"*.example.com\"`" + ` matches every subdomain of example.com (at any depth) but not example.com itself.
+     - ` + "`schemes`" + ` is an optional string array of URI schemes without separator (e.g. ` + "`\"https+bsvauth\"`" + `, ` + "`\"wss\"`" + `). If provided, results will match any of those schemes.
      - ` + "`service`" + ` is an optional string. If provided, results will match services with that name (typically prefixed ` + "`ls_`" + `).
+     - ` + "`services`" + ` is an optional string array. If provided, results will match **any** of those services. It can be combined with ` + "`service`" + `, in which case all listed services are accepted.
+     - ` + "`createdAfter`" + ` and ` + "`createdBefore`" + ` are optional RFC 3339 timestamps (e.g. ` + "`\"2024-01-01T00:00:00Z\"`" + `) restricting results to records created in the window ` + "`[createdAfter, createdBefore)`" + `: ` + "`createdAfter`" + ` is inclusive and ` + "`createdBefore`" + ` exclusive. If both are provided, ` + "`createdAfter`" + ` must be earlier than ` + "`createdBefore`" + `.
+     - ` + "`minConfirmations`" + ` is an optional non-negative number. If provided, results will only match records whose advertisement transaction has been mined with at least that many confirmations, counting the block it was mined in as the first; ` + "`1`" + ` matches every mined record and ` + "`0`" + ` disables the filter. The service must have a chain tracker configured to serve this filter.
+     - ` + "`aggregate`" + ` is an optional string. If provided, the number of matching records per service, domain or identity key is returned instead of the records themselves (see **Aggregate Queries** below).
+     - ` + "`includeRecords`" + ` is an optional boolean. If true, the full SLAP records (identity key, domain, service, block height and hash, and creation time) are returned as a ` + "`freeform`" + ` answer instead of outpoints for the engine to hydrate with BEEF. This is intended for dashboards and debug tooling.
+     - ` + "`cursor`" + ` is an optional opaque string used to walk all matching records page by page. Start with an empty string and pass the ` + "`nextCursor`" + ` of each answer to get the following page; ` + "`limit`" + ` is required with a cursor and sets the page size. Pages are ordered by creation time, txid and output index, so records admitted or spent between calls never shift the remaining pages. Queries with a cursor are answered with a ` + "`freeform`" + ` page ` + "`{ utxos, records, nextCursor }`" + ` (` + "`records`" + ` replaces ` + "`utxos`" + ` when ` + "`includeRecords`" + ` is set), and ` + "`nextCursor`" + ` is omitted once the last page was returned.
 
 ### Examples
 
@@ -92,14 +112,82 @@ When you call ` + "`lookup(question)`" + ` on the SLAP Lookup Service, you must
    }, 10000)
    ` + "```" + `
 
+5. **Find authenticated hosts under a domain for a service**:
+   ` + "```" + `go
+   results, err := resolver.Query(ctx, &lookup.LookupQuestion{
+       Service: "ls_slap",
+       Query: map[string]interface{}{
+           "hostPattern": "*.example.com",
+           "schemes":     []string{"https+bsvauth", "https+bsvauth+smf"},
+           "service":     "ls_treasury",
+       },
+   }, 10000)
+   ` + "```" + `
+
+6. **Find hosts whose advertisements are mined**:
+   ` + "```" + `go
+   results, err := resolver.Query(ctx, &lookup.LookupQuestion{
+       Service: "ls_slap",
+       Query: map[string]interface{}{
+           "service":          "ls_treasury",
+           "minConfirmations": 6,
+       },
+   }, 10000)
+   ` + "```" + `
+
+---
+
+## Answer Format
+
+By default the lookup service returns a ` + "`formula`" + ` answer listing the outpoint of every matching SLAP token. The overlay engine hydrates these formulas into an ` + "`output-list`" + ` answer carrying the BEEF and output index of each token, which is the format lookup resolvers (including ` + "`lookup.LookupResolver`" + ` and the TypeScript ` + "`LookupResolver`" + `) consume.
+
+Legacy clients that expect bare ` + "`{ txid, outputIndex }`" + ` references can still be served by enabling freeform answers on the service with ` + "`SetFreeformAnswers(true)`" + `, in which case a ` + "`freeform`" + ` answer is returned instead.
+
+---
+
+## Aggregate Queries
+
+Network health dashboards usually need to know how many hosts serve each service rather than the hosts themselves. Setting ` + "`aggregate`" + ` counts the matching records in the storage, which groups them in the database without transferring them, and returns a ` + "`freeform`" + ` answer:
+
+` + "```" + `json
+{
+  "aggregate": "countByService",
+  "counts": [
+    { "key": "ls_treasury", "count": 12 },
+    { "key": "ls_identity", "count": 3 }
+  ]
+}
+` + "```" + `
+
+- ` + "`countByService`" + ` counts records per service name, ` + "`countByDomain`" + ` per advertised domain and ` + "`countByIdentityKey`" + ` per advertiser identity key.
+- Counts are ordered by descending count, ties broken by ascending key.
+- All filters of the query (domains, host pattern, schemes, services, identity key, creation time and confirmations) are applied before counting; ` + "`findAll: true`" + ` counts every record.
+- ` + "`limit`" + `, ` + "`skip`" + `, ` + "`sortOrder`" + `, ` + "`cursor`" + ` and ` + "`includeRecords`" + ` are ignored.
+
+For example, to count the hosts serving each service:
+
+` + "```" + `go
+results, err := resolver.Query(ctx, &lookup.LookupQuestion{
+    Service: "ls_slap",
+    Query: map[string]interface{}{
+        "aggregate": "countByService",
+    },
+}, 10000)
+` + "```" + `
+
 ---
 
 ## Gotchas and Tips
 
 - **Service Prefix**: The SLAP manager expects services to start with ` + "`ls_`" + `. If you see no results, ensure you used the correct prefix.
-- **Strict Matching**: Domain matching requires an exact string match. If you have a different protocol (https vs https+bsvauth vs https+bsvauth+smf), be sure to store/lookup accordingly.
+- **Strict Matching**: Domain matching requires an exact string match. If you have a different protocol (https vs https+bsvauth vs https+bsvauth+smf), be sure to store/lookup accordingly, or use ` + "`hostPattern`" + ` and ` + "`schemes`" + ` to match on the parsed URI instead.
+- **Incremental Sync**: A sync job can pass the time of its previous run as ` + "`createdAfter`" + ` to fetch only advertisements that appeared since then. Because ` + "`createdAfter`" + ` is inclusive, records created exactly at that instant are returned again rather than missed. Records admitted earlier and spent since are not reported.
+- **Host Fields**: The scheme and host are derived from the advertised URI when a record is stored. Records stored by older versions lack them and never match ` + "`hostPattern`" + ` or ` + "`schemes`" + ` until the storage's ` + "`BackfillHostFields`" + ` migration has been run.
+- **Confirmations**: Records are stored as unconfirmed and learn their block height when the overlay engine reports it; the block hash is only recorded if the configured chain tracker can resolve it. After a chain reorganization, call the service's ` + "`ResetBlockHeights`" + ` with the height of the first orphaned block so the affected records count as unconfirmed until they are mined again. Records stored by older versions have no block height and only match ` + "`minConfirmations`" + ` once the engine reports one.
 - **Partial Queries**: If you only provide ` + "`service`" + `, domain-based filtering is not applied, and vice versa.
+- **Multiple Services and Domains**: Use ` + "`services`" + ` and ` + "`domains`" + ` to find hosts for **any** of several services or domains in a single query, e.g. ` + "`{ services: ['ls_a', 'ls_b', 'ls_c'] }`" + `.
 - **Single Service**: Unlike SHIP's topics array, SLAP queries filter by a single service name.
+- **Results Per Identity**: A service configured with ` + "`SetMaxResultsPerIdentity`" + ` keeps only the first records of each identity key, so answers may hold fewer records than the requested ` + "`limit`" + `. Aggregate counts are not capped.
 
 ---
 
@@ -8,15 +8,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
 
 	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/types"
+	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/utils"
 	"github.com/bsv-blockchain/go-overlay-services/pkg/core/engine"
 	"github.com/bsv-blockchain/go-sdk/chainhash"
 	"github.com/bsv-blockchain/go-sdk/overlay"
 	"github.com/bsv-blockchain/go-sdk/overlay/lookup"
 	"github.com/bsv-blockchain/go-sdk/transaction"
-	"github.com/bsv-blockchain/go-sdk/transaction/template/pushdrop"
+	"github.com/bsv-blockchain/go-sdk/transaction/chaintracker"
 )
 
 // Constants for SLAP service configuration
@@ -31,17 +33,26 @@ const (
 
 // Static error variables for err113 compliance
 var (
-	errPushDropDecodeFailed      = errors.New("failed to decode PushDrop locking script")
-	errInvalidPushDropFields     = errors.New("invalid PushDrop result: expected at least 4 fields")
-	errValidQueryMustBeProvided  = errors.New("a valid query must be provided")
-	errLookupServiceNotSupported = errors.New("lookup service not supported")
-	errInvalidStringQuery        = errors.New("invalid string query: only 'findAll' is supported")
-	errQueryDomainInvalid        = errors.New("query.domain must be a string if provided")
-	errQueryTopicsInvalid        = errors.New("query.topics must be an array of strings if provided")
-	errQueryIdentityKeyInvalid   = errors.New("query.identityKey must be a string if provided")
-	errQueryLimitInvalid         = errors.New("query.limit must be a positive number if provided")
-	errQuerySkipInvalid          = errors.New("query.skip must be a non-negative number if provided")
-	errQuerySortOrderInvalid     = errors.New("query.sortOrder must be 'asc' or 'desc' if provided")
+	errValidQueryMustBeProvided         = errors.New("a valid query must be provided")
+	errLookupServiceNotSupported        = errors.New("lookup service not supported")
+	errInvalidStringQuery               = errors.New("invalid string query: only 'findAll' is supported")
+	errQueryDomainInvalid               = errors.New("query.domain must be a string if provided")
+	errQueryDomainsInvalid              = errors.New("query.domains must be an array of non-empty strings if provided")
+	errQueryServicesInvalid             = errors.New("query.services must be an array of non-empty strings if provided")
+	errQueryTopicsInvalid               = errors.New("query.topics must be an array of strings if provided")
+	errQueryIdentityKeyInvalid          = errors.New("query.identityKey must be a string if provided")
+	errQueryLimitInvalid                = errors.New("query.limit must be a positive number if provided")
+	errQuerySkipInvalid                 = errors.New("query.skip must be a non-negative number if provided")
+	errQuerySortOrderInvalid            = errors.New("query.sortOrder must be 'asc' or 'desc' if provided")
+	errInvalidUTXOReference             = errors.New("invalid UTXO reference in storage")
+	errQueryCursorInvalid               = errors.New("query.cursor must be a cursor returned as nextCursor if provided")
+	errQueryCursorWithoutLimit          = errors.New("query.cursor requires a positive query.limit setting the page size")
+	errQueryHostPatternInvalid          = errors.New("query.hostPattern must be a host name or a '*.' wildcard of one if provided")
+	errQuerySchemesInvalid              = errors.New("query.schemes must be an array of advertisable URI schemes if provided")
+	errQueryCreatedRangeInvalid         = errors.New("query.createdAfter must be before query.createdBefore if both are provided")
+	errQueryAggregateInvalid            = errors.New("query.aggregate must be 'countByService', 'countByDomain' or 'countByIdentityKey' if provided")
+	errQueryMinConfirmationsInvalid     = errors.New("query.minConfirmations must be a non-negative number if provided")
+	errQueryMinConfirmationsUnsupported = errors.New("query.minConfirmations is not supported without a chain tracker")
 )
 
 // LookupService implements the BSV overlay LookupService interface for SLAP protocol.
@@ -50,6 +61,13 @@ var (
 type LookupService struct {
 	// storage is the SLAP storage implementation
 	storage StorageInterface
+	// freeformAnswers makes Lookup return bare UTXO references instead of formulas
+	freeformAnswers bool
+	// chainTracker provides the chain tip for minConfirmations queries and, if it implements
+	// types.BlockHashResolver, the block hashes of confirmed records
+	chainTracker chaintracker.ChainTracker
+	// maxResultsPerIdentity caps the records of a single identity key in lookup answers, 0 disables the cap
+	maxResultsPerIdentity int
 }
 
 // Compile-time verification that LookupService implements engine.LookupService
@@ -62,48 +80,66 @@ func NewLookupService(storage StorageInterface) *LookupService {
 	}
 }
 
+// SetFreeformAnswers configures whether Lookup returns freeform answers carrying bare UTXO references.
+// By default Lookup returns formula answers, which the overlay engine hydrates into output-list
+// answers with BEEF as expected by lookup resolvers. Freeform answers are only intended for legacy
+// clients that consume the UTXO references directly.
+func (s *LookupService) SetFreeformAnswers(enabled bool) {
+	s.freeformAnswers = enabled
+}
+
+// SetChainTracker configures the chain tracker used to evaluate minConfirmations queries against
+// the current chain height. If the chain tracker also implements types.BlockHashResolver, the block
+// hash of each record is stored alongside its block height. Without a chain tracker, records only
+// store their block height and minConfirmations queries are rejected.
+func (s *LookupService) SetChainTracker(tracker chaintracker.ChainTracker) {
+	s.chainTracker = tracker
+}
+
+// SetMaxResultsPerIdentity caps the number of records of a single identity key in lookup answers,
+// so that one identity advertising many records cannot dominate the results. Only the first records
+// of each identity key in the order of the answer are kept. The cap applies after the limit and skip of
+// the query, so capped answers may hold fewer records than the limit, and within each page of a
+// cursor-paginated query. Aggregate answers are not capped. A zero or negative limit disables the cap.
+func (s *LookupService) SetMaxResultsPerIdentity(limit int) {
+	s.maxResultsPerIdentity = limit
+}
+
 // OutputAdmittedByTopic handles an output being admitted by topic.
 // This method processes SLAP advertisements encoded in locking scripts using PushDrop format.
-// It validates the protocol identifier and stores the SLAP record if valid.
+// It validates the token with utils.ParseAdvertisementToken and stores the SLAP record if valid.
+// Tokens of other protocols are ignored, while invalid SLAP tokens are rejected with an error.
 //
 // Expected PushDrop fields:
 //   - fields[0]: Protocol identifier (must be "SLAP")
 //   - fields[1]: Identity key in hex format
-//   - fields[2]: Domain string
-//   - fields[3]: Service name supported
+//   - fields[2]: Domain string (must be an advertisable URI)
+//   - fields[3]: Service name supported (must start with "ls_")
+//   - fields[4]: Signature linked to the identity key
 func (s *LookupService) OutputAdmittedByTopic(ctx context.Context, payload *engine.OutputAdmittedByTopic) error {
 	// Only process SLAP topic
 	if payload.Topic != Topic {
 		return nil // Silently ignore non-SLAP topics
 	}
 
-	// Use the locking script from payload
-	scriptObj := payload.LockingScript
-
-	// Decode the PushDrop locking script
-	result := pushdrop.Decode(scriptObj)
-	if result == nil {
-		return errPushDropDecodeFailed
+	// Decode and validate the token with the same rules as the topic manager, so a lookup service
+	// wired to a different or misconfigured topic manager does not index invalid advertisements
+	token, err := utils.ParseAdvertisementToken(ctx, payload.LockingScript, overlay.ProtocolSLAP)
+	if errors.Is(err, utils.ErrTokenProtocol) {
+		return nil // Silently ignore non-SLAP protocols
 	}
-
-	// Validate that we have the expected number of fields
-	if len(result.Fields) < 4 {
-		return fmt.Errorf("%w: got %d", errInvalidPushDropFields, len(result.Fields))
+	if err != nil {
+		return fmt.Errorf("invalid SLAP advertisement: %w", err)
 	}
 
-	// Extract and validate fields
-	slapIdentifier := string(result.Fields[0])
-	if slapIdentifier != Identifier {
-		return nil // Silently ignore non-SLAP protocols
+	// Store the SLAP record. The engine may replay admissions after a restart or resync,
+	// in which case the record is already present and storing it is a no-op.
+	txid := recordTxid(&payload.Outpoint.Txid)
+	if _, err := s.storage.StoreSLAPRecord(ctx, txid, int(payload.Outpoint.Index), token.IdentityKey, token.Domain, token.TopicOrService); err != nil {
+		return err
 	}
 
-	identityKey := hex.EncodeToString(result.Fields[1])
-	domain := string(result.Fields[2])
-	serviceSupported := string(result.Fields[3])
-
-	// Store the SLAP record
-	txid := hex.EncodeToString(payload.Outpoint.Txid[:])
-	return s.storage.StoreSLAPRecord(ctx, txid, int(payload.Outpoint.Index), identityKey, domain, serviceSupported)
+	return nil
 }
 
 // OutputSpent handles an output being spent.
@@ -115,7 +151,7 @@ func (s *LookupService) OutputSpent(ctx context.Context, payload *engine.OutputS
 	}
 
 	// Delete the SLAP record
-	txid := hex.EncodeToString(payload.Outpoint.Txid[:])
+	txid := recordTxid(&payload.Outpoint.Txid)
 	return s.storage.DeleteSLAPRecord(ctx, txid, int(payload.Outpoint.Index))
 }
 
@@ -123,7 +159,7 @@ func (s *LookupService) OutputSpent(ctx context.Context, payload *engine.OutputS
 // This method removes the corresponding SLAP record when the UTXO is evicted from the mempool.
 func (s *LookupService) OutputEvicted(ctx context.Context, outpoint *transaction.Outpoint) error {
 	// Delete the SLAP record
-	txid := hex.EncodeToString(outpoint.Txid[:])
+	txid := recordTxid(&outpoint.Txid)
 	return s.storage.DeleteSLAPRecord(ctx, txid, int(outpoint.Index))
 }
 
@@ -135,12 +171,39 @@ func (s *LookupService) OutputNoLongerRetainedInHistory(_ context.Context, _ *tr
 	return nil
 }
 
+// recordTxid encodes a transaction ID the way SLAP records are keyed in storage: the hex of its bytes
+// in internal order, not the reversed display hex of chainhash.Hash.String()
+func recordTxid(txid *chainhash.Hash) string {
+	return hex.EncodeToString(txid[:])
+}
+
 // OutputBlockHeightUpdated handles block height updates for transactions.
 // Called when the block height of a transaction is updated (e.g., when a transaction is included in a block).
-// For SLAP discovery services, this is typically a no-op as they don't track block heights.
-func (s *LookupService) OutputBlockHeightUpdated(_ context.Context, _ *chainhash.Hash, _ uint32, _ uint64) error {
-	// Discovery services don't handle block height updates, so we ignore it
-	return nil
+// The block height, and the block hash if the chain tracker can resolve it, is stored on all SLAP
+// records of the transaction. A block height of 0 marks the records as unconfirmed again.
+func (s *LookupService) OutputBlockHeightUpdated(ctx context.Context, txid *chainhash.Hash, blockHeight uint32, _ uint64) error {
+	var blockHash string
+	if resolver, ok := s.chainTracker.(types.BlockHashResolver); ok && blockHeight > 0 {
+		hash, err := resolver.BlockHash(ctx, blockHeight)
+		if err != nil {
+			return fmt.Errorf("failed to resolve block hash at height %d: %w", blockHeight, err)
+		}
+		if hash != nil {
+			blockHash = hash.String()
+		}
+	}
+
+	return s.storage.UpdateSLAPRecordBlock(ctx, recordTxid(txid), blockHeight, blockHash)
+}
+
+// ResetBlockHeights marks all SLAP records mined at or above fromHeight as unconfirmed and returns the
+// number of records reset. The overlay engine does not notify lookup services of chain reorganizations,
+// so the node running the service must call it itself, with the height of the first orphaned block,
+// whenever its block header source reports a reorganization. Until then, records mined in orphaned
+// blocks keep counting as confirmed. They regain their block height once the engine reports it for
+// the new chain.
+func (s *LookupService) ResetBlockHeights(ctx context.Context, fromHeight uint32) (int64, error) {
+	return s.storage.ResetSLAPRecordBlocks(ctx, fromHeight)
 }
 
 // Lookup performs a lookup query and returns matching results.
@@ -149,7 +212,11 @@ func (s *LookupService) OutputBlockHeightUpdated(_ context.Context, _ *chainhash
 //
 // Supported query formats:
 //   - String "findAll": Returns all SLAP records
-//   - Object with SLAPQuery fields: Filters by domain, service, identityKey with pagination
+//   - Object with SLAPQuery fields: Filters by domain(s), hostPattern, schemes, service(s), identityKey, createdAfter/createdBefore,
+//     minConfirmations with pagination
+//   - Object with includeRecords set: Returns the full SLAP records as a freeform answer
+//   - Object with aggregate set: Returns a types.AggregateResult with record counts as a freeform answer
+//   - Object with cursor set: Returns a types.SLAPPage with the cursor of the next page as a freeform answer
 func (s *LookupService) Lookup(ctx context.Context, question *lookup.LookupQuestion) (*lookup.LookupAnswer, error) {
 	// Validate required fields
 	if len(question.Query) == 0 {
@@ -169,11 +236,17 @@ func (s *LookupService) Lookup(ctx context.Context, question *lookup.LookupQuest
 	// Handle legacy "findAll" string query
 	if queryStr, ok := queryInterface.(string); ok {
 		if queryStr == "findAll" {
-			utxos, err := s.storage.FindAll(ctx, nil, nil, nil)
+			var utxos []types.UTXOReference
+			var err error
+			if s.maxResultsPerIdentity > 0 {
+				utxos, err = s.findCappedUTXOs(ctx, types.SLAPQuery{})
+			} else {
+				utxos, err = s.storage.FindAll(ctx, nil, nil, nil)
+			}
 			if err != nil {
 				return nil, err
 			}
-			return s.convertUTXOsToLookupAnswer(utxos), nil
+			return s.convertUTXOsToLookupAnswer(utxos)
 		}
 		return nil, fmt.Errorf("%w: got '%s'", errInvalidStringQuery, queryStr)
 	}
@@ -184,11 +257,35 @@ func (s *LookupService) Lookup(ctx context.Context, question *lookup.LookupQuest
 		return nil, fmt.Errorf("invalid query format: %w", err)
 	}
 
+	// Resolve the chain tip minConfirmations is evaluated against
+	if err := s.resolveChainTip(ctx, queryObj); err != nil {
+		return nil, err
+	}
+
+	// Handle aggregate queries
+	if queryObj.Aggregate != nil {
+		return s.lookupAggregate(ctx, queryObj)
+	}
+
+	// Handle cursor-paginated queries
+	if queryObj.Cursor != nil {
+		return s.lookupPage(ctx, queryObj)
+	}
+
+	// Handle queries requesting the full records
+	if queryObj.IncludeRecords != nil && *queryObj.IncludeRecords {
+		return s.lookupRecords(ctx, queryObj)
+	}
+
 	var utxos []types.UTXOReference
-	// Handle findAll with pagination
-	if queryObj.FindAll != nil && *queryObj.FindAll {
+	switch {
+	case s.maxResultsPerIdentity > 0:
+		// Capping needs the identity key of each record, so the records are fetched in full
+		utxos, err = s.findCappedUTXOs(ctx, recordsQuery(queryObj))
+	case queryObj.FindAll != nil && *queryObj.FindAll:
+		// Handle findAll with pagination
 		utxos, err = s.storage.FindAll(ctx, queryObj.Limit, queryObj.Skip, queryObj.SortOrder)
-	} else {
+	default:
 		// Handle specific query with filters
 		utxos, err = s.storage.FindRecord(ctx, *queryObj)
 	}
@@ -197,7 +294,144 @@ func (s *LookupService) Lookup(ctx context.Context, question *lookup.LookupQuest
 		return nil, err
 	}
 
-	return s.convertUTXOsToLookupAnswer(utxos), nil
+	return s.convertUTXOsToLookupAnswer(utxos)
+}
+
+// lookupRecords answers a query that requested the full SLAP records.
+// Formula answers can only carry outpoints, so the records are returned as a freeform answer;
+// each record includes the txid and outputIndex of its outpoint.
+func (s *LookupService) lookupRecords(ctx context.Context, query *types.SLAPQuery) (*lookup.LookupAnswer, error) {
+	records, err := s.storage.FindSLAPRecords(ctx, recordsQuery(query))
+	if err != nil {
+		return nil, err
+	}
+
+	return &lookup.LookupAnswer{
+		Type:   lookup.AnswerTypeFreeform,
+		Result: capSLAPRecordsPerIdentity(records, s.maxResultsPerIdentity),
+	}, nil
+}
+
+// findCappedUTXOs returns the outpoints of the records matching the query, keeping at most
+// maxResultsPerIdentity records per identity key
+func (s *LookupService) findCappedUTXOs(ctx context.Context, query types.SLAPQuery) ([]types.UTXOReference, error) {
+	records, err := s.storage.FindSLAPRecords(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return slapRecordsToUTXOReferences(capSLAPRecordsPerIdentity(records, s.maxResultsPerIdentity)), nil
+}
+
+// lookupAggregate answers a query that requested record counts grouped by a field.
+// The counts are computed by the storage and returned as a freeform answer carrying a
+// types.AggregateResult; pagination, sorting and the cursor of the query are ignored.
+func (s *LookupService) lookupAggregate(ctx context.Context, query *types.SLAPQuery) (*lookup.LookupAnswer, error) {
+	countQuery := recordsQuery(query)
+	countQuery.Cursor = nil
+
+	counts, err := s.storage.CountSLAPRecords(ctx, countQuery, *query.Aggregate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lookup.LookupAnswer{
+		Type: lookup.AnswerTypeFreeform,
+		Result: types.AggregateResult{
+			Aggregate: *query.Aggregate,
+			Counts:    counts,
+		},
+	}, nil
+}
+
+// lookupPage answers a query with a cursor with one page of results.
+// The engine rebuilds formula answers from their outpoints alone, which would drop the cursor
+// of the next page, so pages are returned as a freeform answer carrying a types.SLAPPage.
+// A next cursor is only returned when the page was filled up to the query limit.
+func (s *LookupService) lookupPage(ctx context.Context, query *types.SLAPQuery) (*lookup.LookupAnswer, error) {
+	records, err := s.storage.FindSLAPRecords(ctx, recordsQuery(query))
+	if err != nil {
+		return nil, err
+	}
+
+	// The cursor of the next page continues after the last record, even if the cap drops it
+	pageRecords := capSLAPRecordsPerIdentity(records, s.maxResultsPerIdentity)
+
+	page := types.SLAPPage{}
+	if query.IncludeRecords != nil && *query.IncludeRecords {
+		page.Records = pageRecords
+	} else {
+		page.UTXOs = make([]types.UTXOReference, 0, len(pageRecords))
+		for _, record := range pageRecords {
+			page.UTXOs = append(page.UTXOs, types.UTXOReference{
+				Txid:        record.Txid,
+				OutputIndex: record.OutputIndex,
+			})
+		}
+	}
+
+	if query.Limit != nil && *query.Limit > 0 && len(records) == *query.Limit {
+		last := records[len(records)-1]
+		page.NextCursor = types.RecordCursor{
+			CreatedAt:   last.CreatedAt,
+			Txid:        last.Txid,
+			OutputIndex: last.OutputIndex,
+		}.Encode()
+	}
+
+	return &lookup.LookupAnswer{
+		Type:   lookup.AnswerTypeFreeform,
+		Result: page,
+	}, nil
+}
+
+// capSLAPRecordsPerIdentity keeps the first limit records of each identity key, in order.
+// A zero or negative limit keeps all records.
+func capSLAPRecordsPerIdentity(records []types.SLAPRecord, limit int) []types.SLAPRecord {
+	if limit <= 0 {
+		return records
+	}
+
+	counts := make(map[string]int)
+	capped := make([]types.SLAPRecord, 0, len(records))
+	for _, record := range records {
+		if counts[record.IdentityKey] < limit {
+			counts[record.IdentityKey]++
+			capped = append(capped, record)
+		}
+	}
+	return capped
+}
+
+// resolveChainTip sets the chain tip of a query filtering by minConfirmations to the
+// current height of the chain tracker.
+func (s *LookupService) resolveChainTip(ctx context.Context, query *types.SLAPQuery) error {
+	if query.MinConfirmations == nil || *query.MinConfirmations == 0 {
+		return nil
+	}
+
+	height, err := s.chainTracker.CurrentHeight(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get current chain height: %w", err)
+	}
+	query.ChainTip = &height
+
+	return nil
+}
+
+// recordsQuery returns the storage query for a lookup query answered with full records.
+// A findAll query ignores the filters and only keeps pagination, sorting and the cursor.
+func recordsQuery(query *types.SLAPQuery) types.SLAPQuery {
+	if query.FindAll == nil || !*query.FindAll {
+		return *query
+	}
+
+	return types.SLAPQuery{
+		Limit:     query.Limit,
+		Skip:      query.Skip,
+		SortOrder: query.SortOrder,
+		Cursor:    query.Cursor,
+	}
 }
 
 // parseQueryObject parses and validates a query object
@@ -239,6 +473,34 @@ func (s *LookupService) validateQuery(query *types.SLAPQuery) error {
 		}
 	}
 
+	// Validate domains parameter
+	for i, domain := range query.Domains {
+		if domain == "" {
+			return fmt.Errorf("%w: empty domain at index %d", errQueryDomainsInvalid, i)
+		}
+	}
+
+	// Validate hostPattern parameter
+	if query.HostPattern != nil {
+		if _, err := utils.ParseHostPattern(*query.HostPattern); err != nil {
+			return fmt.Errorf("%w: %w", errQueryHostPatternInvalid, err)
+		}
+	}
+
+	// Validate schemes parameter
+	for i, scheme := range query.Schemes {
+		if !utils.IsAdvertisableScheme(scheme) {
+			return fmt.Errorf("%w: unsupported scheme %q at index %d", errQuerySchemesInvalid, scheme, i)
+		}
+	}
+
+	// Validate services parameter
+	for i, service := range query.Services {
+		if service == "" {
+			return fmt.Errorf("%w: empty service at index %d", errQueryServicesInvalid, i)
+		}
+	}
+
 	// Validate identityKey parameter
 	if query.IdentityKey != nil {
 		if reflect.TypeOf(query.IdentityKey).Kind() != reflect.Ptr ||
@@ -247,6 +509,30 @@ func (s *LookupService) validateQuery(query *types.SLAPQuery) error {
 		}
 	}
 
+	// Validate creation time range parameters
+	if query.CreatedAfter != nil && query.CreatedBefore != nil {
+		if !query.CreatedAfter.Before(*query.CreatedBefore) {
+			return errQueryCreatedRangeInvalid
+		}
+	}
+
+	// Validate aggregate parameter
+	if query.Aggregate != nil {
+		if _, _, err := aggregateField(*query.Aggregate); err != nil {
+			return fmt.Errorf("%w: %w", errQueryAggregateInvalid, err)
+		}
+	}
+
+	// Validate minConfirmations parameter
+	if query.MinConfirmations != nil {
+		if *query.MinConfirmations < 0 {
+			return errQueryMinConfirmationsInvalid
+		}
+		if *query.MinConfirmations > 0 && s.chainTracker == nil {
+			return errQueryMinConfirmationsUnsupported
+		}
+	}
+
 	// Validate pagination parameters
 	if query.Limit != nil {
 		if *query.Limit < 0 {
@@ -267,6 +553,17 @@ func (s *LookupService) validateQuery(query *types.SLAPQuery) error {
 		}
 	}
 
+	// Validate cursor parameter
+	if query.Cursor != nil && *query.Cursor != "" {
+		if _, err := types.DecodeRecordCursor(*query.Cursor); err != nil {
+			return fmt.Errorf("%w: %w", errQueryCursorInvalid, err)
+		}
+	}
+	// Pages need a size, aggregate answers ignore the cursor
+	if query.Cursor != nil && query.Aggregate == nil && (query.Limit == nil || *query.Limit == 0) {
+		return errQueryCursorWithoutLimit
+	}
+
 	return nil
 }
 
@@ -287,11 +584,46 @@ func (s *LookupService) GetMetaData() *overlay.MetaData {
 	}
 }
 
-// convertUTXOsToLookupAnswer converts a slice of UTXO references to a LookupAnswer
-func (s *LookupService) convertUTXOsToLookupAnswer(utxos []types.UTXOReference) *lookup.LookupAnswer {
-	// For discovery services, we return the UTXOs as freeform result
+// convertUTXOsToLookupAnswer converts a slice of UTXO references to a LookupAnswer.
+// It returns a formula answer with one outpoint per UTXO for the engine to hydrate with BEEF,
+// or a freeform answer with the UTXO references themselves when freeform answers are enabled.
+func (s *LookupService) convertUTXOsToLookupAnswer(utxos []types.UTXOReference) (*lookup.LookupAnswer, error) {
+	if s.freeformAnswers {
+		return &lookup.LookupAnswer{
+			Type:   lookup.AnswerTypeFreeform,
+			Result: utxos,
+		}, nil
+	}
+
+	formulas := make([]lookup.LookupFormula, 0, len(utxos))
+	for _, utxo := range utxos {
+		outpoint, err := utxoToOutpoint(utxo)
+		if err != nil {
+			return nil, err
+		}
+		formulas = append(formulas, lookup.LookupFormula{Outpoint: outpoint})
+	}
+
 	return &lookup.LookupAnswer{
-		Type:   lookup.AnswerTypeFreeform,
-		Result: utxos,
+		Type:     lookup.AnswerTypeFormula,
+		Formulas: formulas,
+	}, nil
+}
+
+// utxoToOutpoint converts a stored UTXO reference back into a transaction outpoint.
+// Stored txids are the hex encoding of the outpoint's txid bytes, see OutputAdmittedByTopic.
+func utxoToOutpoint(utxo types.UTXOReference) (*transaction.Outpoint, error) {
+	txidBytes, err := hex.DecodeString(utxo.Txid)
+	if err != nil || len(txidBytes) != chainhash.HashSize {
+		return nil, fmt.Errorf("%w: txid '%s' is not a 32-byte hex string", errInvalidUTXOReference, utxo.Txid)
 	}
+
+	if utxo.OutputIndex < 0 || int64(utxo.OutputIndex) > math.MaxUint32 {
+		return nil, fmt.Errorf("%w: output index %d is out of range", errInvalidUTXOReference, utxo.OutputIndex)
+	}
+
+	outpoint := &transaction.Outpoint{Index: uint32(utxo.OutputIndex)}
+	copy(outpoint.Txid[:], txidBytes)
+
+	return outpoint, nil
 }
@@ -4,6 +4,7 @@ package slap
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -12,14 +13,25 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/types"
+	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/utils"
+)
+
+// Static error variables for err113 compliance
+var (
+	errUnsupportedAggregate = errors.New("unsupported aggregate mode for SLAP records")
+	errChainTipRequired     = errors.New("filtering SLAP records by confirmations requires the chain tip height")
 )
 
 // StorageInterface defines the interface for SLAP storage operations.
 type StorageInterface interface {
-	StoreSLAPRecord(ctx context.Context, txid string, outputIndex int, identityKey, domain, service string) error
+	StoreSLAPRecord(ctx context.Context, txid string, outputIndex int, identityKey, domain, service string) (bool, error)
 	DeleteSLAPRecord(ctx context.Context, txid string, outputIndex int) error
+	UpdateSLAPRecordBlock(ctx context.Context, txid string, blockHeight uint32, blockHash string) error
+	ResetSLAPRecordBlocks(ctx context.Context, fromHeight uint32) (int64, error)
 	FindRecord(ctx context.Context, query types.SLAPQuery) ([]types.UTXOReference, error)
+	FindSLAPRecords(ctx context.Context, query types.SLAPQuery) ([]types.SLAPRecord, error)
 	FindAll(ctx context.Context, limit, skip *int, sortOrder *types.SortOrder) ([]types.UTXOReference, error)
+	CountSLAPRecords(ctx context.Context, query types.SLAPQuery, groupBy types.AggregateMode) ([]types.AggregateCount, error)
 	EnsureIndexes(ctx context.Context) error
 }
 
@@ -29,29 +41,59 @@ type StorageInterface interface {
 type Storage struct {
 	db          *mongo.Database
 	slapRecords *mongo.Collection
+	// now returns the timestamp assigned to newly stored records
+	now func() time.Time
 }
 
+// Compile-time verification that Storage implements StorageInterface
+var _ StorageInterface = (*Storage)(nil)
+
 // NewStorage constructs a new Storage instance with the provided MongoDB database.
 // The storage uses a collection named "slapRecords" to store SLAP protocol records.
 func NewStorage(db *mongo.Database) *Storage {
 	return &Storage{
 		db:          db,
 		slapRecords: db.Collection("slapRecords"),
+		now:         time.Now,
 	}
 }
 
 // EnsureIndexes creates the necessary indexes for the SLAP records collection.
 // This method should be called once during application initialization to optimize
-// query performance. It creates a compound index on domain and service fields.
+// query performance. It creates a compound index on domain and service fields, a
+// unique index on txid and outputIndex, an index matching the pagination order, indexes
+// on the normalized reversedHost and scheme fields, and an index on blockHeight. Collections created before the unique index
+// existed may hold duplicates; call RemoveDuplicateRecords first to clean them up.
 func (s *Storage) EnsureIndexes(ctx context.Context) error {
-	indexModel := mongo.IndexModel{
-		Keys: bson.D{
-			{Key: "domain", Value: 1},
-			{Key: "service", Value: 1},
+	indexModels := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "domain", Value: 1},
+				{Key: "service", Value: 1},
+			},
+		},
+		{
+			Keys: bson.D{
+				{Key: "txid", Value: 1},
+				{Key: "outputIndex", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: recordSort(nil),
+		},
+		{
+			Keys: bson.D{{Key: "reversedHost", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "scheme", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "blockHeight", Value: 1}},
 		},
 	}
 
-	_, err := s.slapRecords.Indexes().CreateOne(ctx, indexModel)
+	_, err := s.slapRecords.Indexes().CreateMany(ctx, indexModels)
 	if err != nil {
 		return fmt.Errorf("failed to create indexes for SLAP records: %w", err)
 	}
@@ -60,24 +102,132 @@ func (s *Storage) EnsureIndexes(ctx context.Context) error {
 }
 
 // StoreSLAPRecord stores a new SLAP record in the database.
-// The record includes transaction information, identity key, domain, service,
-// and an automatically generated creation timestamp.
-func (s *Storage) StoreSLAPRecord(ctx context.Context, txid string, outputIndex int, identityKey, domain, service string) error {
+// The record includes transaction information, identity key, domain, service, the
+// normalized scheme and host of the domain, and an automatically generated creation timestamp.
+// Storing is idempotent: if a record for the same txid and outputIndex already exists it is
+// left untouched (including its creation timestamp) and false is returned. It returns true
+// when the record was newly inserted.
+func (s *Storage) StoreSLAPRecord(ctx context.Context, txid string, outputIndex int, identityKey, domain, service string) (bool, error) {
+	uri, _ := utils.ParseAdvertisableURI(domain)
 	record := types.SLAPRecord{
-		Txid:        txid,
-		OutputIndex: outputIndex,
-		IdentityKey: identityKey,
-		Domain:      domain,
-		Service:     service,
-		CreatedAt:   time.Now(),
+		Txid:         txid,
+		OutputIndex:  outputIndex,
+		IdentityKey:  identityKey,
+		Domain:       domain,
+		Service:      service,
+		Scheme:       uri.Scheme,
+		Host:         uri.Host,
+		ReversedHost: uri.ReversedHost(),
+		CreatedAt:    s.now(),
+	}
+
+	filter := bson.M{
+		"txid":        txid,
+		"outputIndex": outputIndex,
 	}
 
-	_, err := s.slapRecords.InsertOne(ctx, record)
+	result, err := s.slapRecords.UpdateOne(ctx, filter, bson.M{"$setOnInsert": record}, options.Update().SetUpsert(true))
 	if err != nil {
-		return fmt.Errorf("failed to store SLAP record: %w", err)
+		// A concurrent upsert of the same outpoint won the race against the unique index
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to store SLAP record: %w", err)
 	}
 
-	return nil
+	return result.UpsertedCount > 0, nil
+}
+
+// RemoveDuplicateRecords is a migration helper that removes duplicate SLAP records sharing
+// the same txid and outputIndex, keeping the oldest record of each outpoint.
+// Collections populated before the unique outpoint index was introduced may contain such
+// duplicates, which prevent EnsureIndexes from creating the index. It returns the number
+// of records removed.
+func (s *Storage) RemoveDuplicateRecords(ctx context.Context) (int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$sort", Value: bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"txid": "$txid", "outputIndex": "$outputIndex"},
+			"ids":   bson.M{"$push": "$_id"},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+	}
+
+	cursor, err := s.slapRecords.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return 0, fmt.Errorf("failed to find duplicate SLAP records: %w", err)
+	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	var removed int64
+	for cursor.Next(ctx) {
+		var group struct {
+			IDs []interface{} `bson:"ids"`
+		}
+
+		if err := cursor.Decode(&group); err != nil {
+			return removed, fmt.Errorf("failed to decode duplicate SLAP records: %w", err)
+		}
+
+		// Keep the oldest record and delete the rest
+		result, err := s.slapRecords.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": group.IDs[1:]}})
+		if err != nil {
+			return removed, fmt.Errorf("failed to remove duplicate SLAP records: %w", err)
+		}
+		removed += result.DeletedCount
+	}
+
+	if err := cursor.Err(); err != nil {
+		return removed, fmt.Errorf("cursor error while finding duplicate SLAP records: %w", err)
+	}
+
+	return removed, nil
+}
+
+// BackfillHostFields is a migration helper that derives the scheme, host and reversedHost fields
+// of SLAP records stored before these fields were introduced. Records without them never match
+// hostPattern or schemes queries. It returns the number of records updated.
+func (s *Storage) BackfillHostFields(ctx context.Context) (int64, error) {
+	findOpts := options.Find().SetProjection(bson.M{"domain": 1})
+	cursor, err := s.slapRecords.Find(ctx, bson.M{"scheme": bson.M{"$exists": false}}, findOpts)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find SLAP records without host fields: %w", err)
+	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	var updated int64
+	for cursor.Next(ctx) {
+		var record struct {
+			ID     interface{} `bson:"_id"`
+			Domain string      `bson:"domain"`
+		}
+
+		if err := cursor.Decode(&record); err != nil {
+			return updated, fmt.Errorf("failed to decode SLAP record: %w", err)
+		}
+
+		uri, _ := utils.ParseAdvertisableURI(record.Domain)
+		result, err := s.slapRecords.UpdateOne(ctx, bson.M{"_id": record.ID}, bson.M{"$set": bson.M{
+			"scheme":       uri.Scheme,
+			"host":         uri.Host,
+			"reversedHost": uri.ReversedHost(),
+		}})
+		if err != nil {
+			return updated, fmt.Errorf("failed to backfill SLAP record host fields: %w", err)
+		}
+		updated += result.ModifiedCount
+	}
+
+	if err := cursor.Err(); err != nil {
+		return updated, fmt.Errorf("cursor error while backfilling SLAP records: %w", err)
+	}
+
+	return updated, nil
 }
 
 // DeleteSLAPRecord deletes a SLAP record from the database based on transaction ID and output index.
@@ -96,25 +246,47 @@ func (s *Storage) DeleteSLAPRecord(ctx context.Context, txid string, outputIndex
 	return nil
 }
 
-// FindRecord finds SLAP records based on the provided query parameters.
-// It supports filtering by domain, service, and identity key, with pagination and sorting options.
-// Returns only UTXO references (txid and outputIndex) as projection for efficient querying.
-func (s *Storage) FindRecord(ctx context.Context, query types.SLAPQuery) ([]types.UTXOReference, error) {
-	mongoQuery := bson.M{}
+// UpdateSLAPRecordBlock records the block height and hash of all SLAP records of a transaction.
+// A block height of 0 marks the records as unconfirmed again, e.g. after their block was orphaned.
+func (s *Storage) UpdateSLAPRecordBlock(ctx context.Context, txid string, blockHeight uint32, blockHash string) error {
+	update := bson.M{"$set": bson.M{
+		"blockHeight": blockHeight,
+		"blockHash":   blockHash,
+	}}
 
-	// Add domain filter if provided
-	if query.Domain != nil {
-		mongoQuery["domain"] = *query.Domain
+	_, err := s.slapRecords.UpdateMany(ctx, bson.M{"txid": txid}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update SLAP record block: %w", err)
 	}
 
-	// Add service filter if provided
-	if query.Service != nil {
-		mongoQuery["service"] = *query.Service
+	return nil
+}
+
+// ResetSLAPRecordBlocks marks all SLAP records mined at or above fromHeight as unconfirmed.
+// It is used when a chain reorganization orphans those blocks; the records regain their block
+// height once the engine reports it for the new chain. It returns the number of records reset.
+func (s *Storage) ResetSLAPRecordBlocks(ctx context.Context, fromHeight uint32) (int64, error) {
+	filter := bson.M{"blockHeight": bson.M{"$gte": max(fromHeight, 1)}}
+	update := bson.M{"$set": bson.M{
+		"blockHeight": uint32(0),
+		"blockHash":   "",
+	}}
+
+	result, err := s.slapRecords.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reset SLAP record blocks: %w", err)
 	}
 
-	// Add identity key filter if provided
-	if query.IdentityKey != nil {
-		mongoQuery["identityKey"] = *query.IdentityKey
+	return result.ModifiedCount, nil
+}
+
+// FindRecord finds SLAP records based on the provided query parameters.
+// It supports filtering by domain, host pattern, schemes, service, identity key, creation time, and confirmations, with pagination and sorting options.
+// Returns only UTXO references (txid and outputIndex) as projection for efficient querying.
+func (s *Storage) FindRecord(ctx context.Context, query types.SLAPQuery) ([]types.UTXOReference, error) {
+	mongoQuery, err := slapQueryFilter(query)
+	if err != nil {
+		return nil, err
 	}
 
 	// Set up the find options
@@ -128,11 +300,7 @@ func (s *Storage) FindRecord(ctx context.Context, query types.SLAPQuery) ([]type
 	})
 
 	// Set sort order (default to descending by createdAt)
-	sortOrder := -1 // descending
-	if query.SortOrder != nil && *query.SortOrder == types.SortOrderAsc {
-		sortOrder = 1 // ascending
-	}
-	findOpts.SetSort(bson.M{"createdAt": sortOrder})
+	findOpts.SetSort(recordSort(query.SortOrder))
 
 	// Apply pagination
 	if query.Skip != nil && *query.Skip > 0 {
@@ -177,6 +345,257 @@ func (s *Storage) FindRecord(ctx context.Context, query types.SLAPQuery) ([]type
 	return results, nil
 }
 
+// FindSLAPRecords finds full SLAP records based on the provided query parameters.
+// It applies the same filtering, pagination and sorting as FindRecord, but returns the complete
+// records (identity key, domain, service and creation time) instead of only UTXO references.
+// The FindAll flag of the query is not interpreted; a query without filters matches all records.
+func (s *Storage) FindSLAPRecords(ctx context.Context, query types.SLAPQuery) ([]types.SLAPRecord, error) {
+	findOpts := options.Find()
+
+	// Set sort order (default to descending by createdAt)
+	findOpts.SetSort(recordSort(query.SortOrder))
+
+	// Apply pagination
+	if query.Skip != nil && *query.Skip > 0 {
+		findOpts.SetSkip(int64(*query.Skip))
+	}
+
+	if query.Limit != nil && *query.Limit > 0 {
+		findOpts.SetLimit(int64(*query.Limit))
+	}
+
+	mongoQuery, err := slapQueryFilter(query)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := s.slapRecords.Find(ctx, mongoQuery, findOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find SLAP records: %w", err)
+	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	var results []types.SLAPRecord
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode SLAP records: %w", err)
+	}
+
+	return results, nil
+}
+
+// CountSLAPRecords counts the SLAP records matching the query, grouped by the field selected by groupBy.
+// The grouping runs in the database, so no records are transferred. Limit, skip and sort order of the
+// query are not interpreted, and neither is its FindAll flag; a query without filters counts all records.
+// Counts are ordered by descending count, ties broken by ascending key.
+func (s *Storage) CountSLAPRecords(ctx context.Context, query types.SLAPQuery, groupBy types.AggregateMode) ([]types.AggregateCount, error) {
+	field, _, err := aggregateField(groupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	mongoQuery, err := slapQueryFilter(query)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: mongoQuery}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$" + field,
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+
+	cursor, err := s.slapRecords.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count SLAP records: %w", err)
+	}
+	defer func() {
+		_ = cursor.Close(ctx)
+	}()
+
+	results := make([]types.AggregateCount, 0)
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode SLAP record counts: %w", err)
+	}
+
+	return results, nil
+}
+
+// slapQueryFilter builds the MongoDB filter for the domains, host pattern, schemes, services,
+// identity key, creation time, confirmations and cursor of a query.
+func slapQueryFilter(query types.SLAPQuery) (bson.M, error) {
+	mongoQuery := bson.M{}
+
+	// Add domain filter using $in operator if any domains are provided
+	if domains := queryDomains(query); len(domains) > 0 {
+		mongoQuery["domain"] = bson.M{"$in": domains}
+	}
+
+	// Add host filter on the indexed reversed host if a host pattern is provided
+	pattern, err := queryHostPattern(query)
+	if err != nil {
+		return nil, err
+	}
+	if pattern != nil {
+		if pattern.Wildcard {
+			lower, upper := pattern.ReversedRange()
+			mongoQuery["reversedHost"] = bson.M{"$gte": lower, "$lt": upper}
+		} else {
+			mongoQuery["reversedHost"] = utils.ReverseHost(pattern.Host)
+		}
+	}
+
+	// Add schemes filter using $in operator if provided
+	if len(query.Schemes) > 0 {
+		mongoQuery["scheme"] = bson.M{"$in": query.Schemes}
+	}
+
+	// Add service filter using $in operator if any services are provided
+	if services := queryServices(query); len(services) > 0 {
+		mongoQuery["service"] = bson.M{"$in": services}
+	}
+
+	// Add identity key filter if provided
+	if query.IdentityKey != nil {
+		mongoQuery["identityKey"] = *query.IdentityKey
+	}
+
+	// Add creation time range filter if provided
+	if query.CreatedAfter != nil || query.CreatedBefore != nil {
+		createdAt := bson.M{}
+		if query.CreatedAfter != nil {
+			createdAt["$gte"] = *query.CreatedAfter
+		}
+		if query.CreatedBefore != nil {
+			createdAt["$lt"] = *query.CreatedBefore
+		}
+		mongoQuery["createdAt"] = createdAt
+	}
+
+	// Add confirmations filter on the block height if requested
+	maxHeight, confirmed, err := maxConfirmedHeight(query)
+	if err != nil {
+		return nil, err
+	}
+	if confirmed {
+		mongoQuery["blockHeight"] = bson.M{"$gte": 1, "$lte": maxHeight}
+	}
+
+	// Only match records after the cursor in the requested sort order
+	if query.Cursor != nil && *query.Cursor != "" {
+		cursor, err := types.DecodeRecordCursor(*query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		mongoQuery["$or"] = cursorFilter(cursor, query.SortOrder)
+	}
+
+	return mongoQuery, nil
+}
+
+// queryDomains returns the domains a query is restricted to, combining Domain and Domains.
+// An empty result means the query does not filter by domain.
+func queryDomains(query types.SLAPQuery) []string {
+	if query.Domain == nil {
+		return query.Domains
+	}
+
+	return append([]string{*query.Domain}, query.Domains...)
+}
+
+// queryServices returns the services a query is restricted to, combining Service and Services.
+// An empty result means the query does not filter by service.
+func queryServices(query types.SLAPQuery) []string {
+	if query.Service == nil {
+		return query.Services
+	}
+
+	return append([]string{*query.Service}, query.Services...)
+}
+
+// queryHostPattern parses the host pattern of a query.
+// A nil result means the query does not filter by host.
+func queryHostPattern(query types.SLAPQuery) (*utils.HostPattern, error) {
+	if query.HostPattern == nil {
+		return nil, nil
+	}
+
+	pattern, err := utils.ParseHostPattern(*query.HostPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pattern, nil
+}
+
+// maxConfirmedHeight returns the highest block height a record may be mined at to have the
+// minimum number of confirmations of the query, given its chain tip. A record mined at height h
+// has tip-h+1 confirmations. The second result is false if the query does not filter by confirmations.
+func maxConfirmedHeight(query types.SLAPQuery) (uint32, bool, error) {
+	if query.MinConfirmations == nil || *query.MinConfirmations <= 0 {
+		return 0, false, nil
+	}
+
+	if query.ChainTip == nil {
+		return 0, false, errChainTipRequired
+	}
+
+	// No record can have more confirmations than there are blocks
+	if uint64(*query.MinConfirmations) > uint64(*query.ChainTip) {
+		return 0, true, nil
+	}
+
+	return *query.ChainTip - uint32(*query.MinConfirmations) + 1, true, nil //nolint:gosec // bounded by ChainTip above
+}
+
+// aggregateField returns the MongoDB field and SQL column SLAP records are grouped by for an aggregate mode.
+func aggregateField(groupBy types.AggregateMode) (field, column string, err error) {
+	switch groupBy {
+	case types.AggregateCountByService:
+		return "service", "service", nil
+	case types.AggregateCountByDomain:
+		return "domain", "domain", nil
+	case types.AggregateCountByIdentityKey:
+		return "identityKey", "identity_key", nil
+	default:
+		return "", "", fmt.Errorf("%w: '%s'", errUnsupportedAggregate, groupBy)
+	}
+}
+
+// recordSort returns the sort specification ordering records by createdAt, txid and outputIndex,
+// descending unless ascending order is requested.
+func recordSort(sortOrder *types.SortOrder) bson.D {
+	direction := -1 // descending
+	if sortOrder != nil && *sortOrder == types.SortOrderAsc {
+		direction = 1 // ascending
+	}
+
+	return bson.D{
+		{Key: "createdAt", Value: direction},
+		{Key: "txid", Value: direction},
+		{Key: "outputIndex", Value: direction},
+	}
+}
+
+// cursorFilter returns the $or clauses matching records that sort after the cursor
+// in the (createdAt, txid, outputIndex) ordering used by recordSort.
+func cursorFilter(cursor *types.RecordCursor, sortOrder *types.SortOrder) bson.A {
+	operator := "$lt"
+	if sortOrder != nil && *sortOrder == types.SortOrderAsc {
+		operator = "$gt"
+	}
+
+	return bson.A{
+		bson.M{"createdAt": bson.M{operator: cursor.CreatedAt}},
+		bson.M{"createdAt": cursor.CreatedAt, "txid": bson.M{operator: cursor.Txid}},
+		bson.M{"createdAt": cursor.CreatedAt, "txid": cursor.Txid, "outputIndex": bson.M{operator: cursor.OutputIndex}},
+	}
+}
+
 // FindAll returns all SLAP records in the database with optional pagination and sorting.
 // This method ignores all filtering criteria and returns all available records.
 // Returns only UTXO references (txid and outputIndex) as projection for efficient querying.
@@ -192,11 +611,7 @@ func (s *Storage) FindAll(ctx context.Context, limit, skip *int, sortOrder *type
 	})
 
 	// Set sort order (default to descending by createdAt)
-	mongoSortOrder := -1 // descending
-	if sortOrder != nil && *sortOrder == types.SortOrderAsc {
-		mongoSortOrder = 1 // ascending
-	}
-	findOpts.SetSort(bson.M{"createdAt": mongoSortOrder})
+	findOpts.SetSort(recordSort(sortOrder))
 
 	// Apply pagination
 	if skip != nil && *skip > 0 {
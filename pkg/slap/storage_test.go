@@ -3,6 +3,7 @@ package slap
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -523,3 +524,19 @@ func intPtr(i int) *int {
 func sortOrderPtr(s types.SortOrder) *types.SortOrder {
 	return &s
 }
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func aggregatePtr(a types.AggregateMode) *types.AggregateMode {
+	return &a
+}
+
+func uint32Ptr(u uint32) *uint32 {
+	return &u
+}
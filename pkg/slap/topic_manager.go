@@ -6,16 +6,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"log/slog"
-	"strings"
+	"math"
 	"sync"
 	"time"
 
+	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/types"
 	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/utils"
 	"github.com/bsv-blockchain/go-sdk/overlay"
+	"github.com/bsv-blockchain/go-sdk/script"
 	"github.com/bsv-blockchain/go-sdk/transaction"
-	"github.com/bsv-blockchain/go-sdk/transaction/template/pushdrop"
 )
 
 // Static error variables for err113 compliance
@@ -76,17 +76,30 @@ type TopicManager struct {
 	storage StorageInterface
 	// lookupService provides access to SLAP lookup operations (optional integration)
 	lookupService *LookupService
+	// logger receives the admission logs, the default slog logger if nil
+	logger *slog.Logger
+	// admissionObserver receives the admission reports (optional)
+	admissionObserver types.AdmissionObserver
+	// policies are the admission rules applied after the built-in checks (optional)
+	policies utils.AdmissionPolicies
+	// quotas limits the active records admitted per identity key, identity key and service, and domain
+	quotas types.AdvertisementQuotas
+	// verificationConcurrency bounds the outputs verified in parallel, 0 for runtime.GOMAXPROCS(0)
+	verificationConcurrency int
 }
 
 // NewTopicManager creates a new SLAP topic manager instance.
 // This constructor initializes the topic manager with the required dependencies
 // for managing overlay network service subscriptions and message routing.
-func NewTopicManager(storage StorageInterface, lookupService *LookupService) *TopicManager {
+// The optional admission policies are run in order on the SLAP advertisements passing the built-in
+// checks, and an advertisement is only admitted if every policy admits it.
+func NewTopicManager(storage StorageInterface, lookupService *LookupService, policies ...utils.AdmissionPolicy) *TopicManager {
 	return &TopicManager{
 		subscriptions: make(map[string]*ServiceSubscription),
 		handlers:      make(map[string]ServiceMessageHandler),
 		storage:       storage,
 		lookupService: lookupService,
+		policies:      policies,
 	}
 }
 
@@ -373,100 +386,215 @@ func (tm *TopicManager) GetAvailableServices() []string {
 	return services
 }
 
+// SetLogger sets the logger receiving the admission logs of IdentifyAdmissibleOutputs.
+// A nil logger restores the default slog logger.
+func (tm *TopicManager) SetLogger(logger *slog.Logger) {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+	tm.logger = logger
+}
+
+// SetAdmissionObserver sets an optional hook receiving the admission report of every transaction
+// evaluated by IdentifyAdmissibleOutputs, telling why each output was admitted or rejected.
+// A nil observer removes the hook.
+func (tm *TopicManager) SetAdmissionObserver(observer types.AdmissionObserver) {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+	tm.admissionObserver = observer
+}
+
+// SetAdvertisementQuotas sets the quotas limiting the active SLAP records admitted per identity key,
+// identity key and service, and domain. The records are counted in the storage of the topic manager when
+// admitting outputs, and outputs exceeding a quota are rejected with types.AdmissionRejectionQuota.
+func (tm *TopicManager) SetAdvertisementQuotas(quotas types.AdvertisementQuotas) {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+	tm.quotas = quotas
+}
+
+// SetVerificationConcurrency bounds the number of outputs of a transaction whose signatures are
+// verified in parallel by IdentifyAdmissibleOutputs. A zero or negative concurrency, the default,
+// uses runtime.GOMAXPROCS(0) goroutines, while a concurrency of 1 verifies the outputs serially.
+func (tm *TopicManager) SetVerificationConcurrency(concurrency int) {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+	tm.verificationConcurrency = concurrency
+}
+
 // IdentifyAdmissibleOutputs implements the engine.TopicManager interface
 // For SLAP, this identifies outputs that should be admitted to the overlay
 func (tm *TopicManager) IdentifyAdmissibleOutputs(ctx context.Context, beef []byte, previousCoins map[uint32]*transaction.TransactionOutput) (overlay.AdmittanceInstructions, error) {
-	outputsToAdmit := []uint32{}
+	tm.mutex.RLock()
+	logger, observer, quotas, concurrency := tm.logger, tm.admissionObserver, tm.quotas, tm.verificationConcurrency
+	tm.mutex.RUnlock()
+
+	report, err := tm.evaluateAdmission(ctx, beef, previousCoins, quotas, concurrency)
+	if err != nil {
+		return overlay.AdmittanceInstructions{}, err
+	}
+
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	logAdmission(ctx, logger, report)
+	if observer != nil {
+		observer(ctx, report)
+	}
+
+	return overlay.AdmittanceInstructions{
+		OutputsToAdmit: report.Admitted(),
+		CoinsToRetain:  []uint32{},
+	}, nil
+}
+
+// evaluateAdmission decides for each output of the transaction whether it is a valid SLAP advertisement
+// admitted by the policies and within the quotas. The signatures of the outputs are verified with at most
+// concurrency goroutines. It only fails if the records cannot be counted.
+func (tm *TopicManager) evaluateAdmission(ctx context.Context, beef []byte, previousCoins map[uint32]*transaction.TransactionOutput, quotas types.AdvertisementQuotas, concurrency int) (*types.AdmissionReport, error) {
+	report := &types.AdmissionReport{
+		Topic:         Topic,
+		Decisions:     []types.AdmissionDecision{},
+		PreviousCoins: len(previousCoins),
+	}
 
 	// Parse transaction from BEEF format
 	parsedTransaction, err := transaction.NewTransactionFromBEEF(beef)
 	if err != nil {
-		// Only log error if no outputs were admitted and no previous coins consumed
-		if len(outputsToAdmit) == 0 && len(previousCoins) == 0 {
-			log.Printf("🤚 Error identifying admissible outputs: %v", err)
+		report.Reason = types.AdmissionRejectionInvalidBEEF
+		report.Err = err
+		return report, nil
+	}
+	report.Txid = parsedTransaction.TxID().String()
+
+	// Discount the advertisements spent by the transaction from the quotas
+	var quotaEnforcer *utils.QuotaEnforcer
+	if quotas != (types.AdvertisementQuotas{}) {
+		quotaEnforcer = utils.NewQuotaEnforcer(quotas, quotaCounter{storage: tm.storage})
+		for _, coin := range previousCoins {
+			if coin == nil {
+				continue
+			}
+			if token, err := utils.ParseAdvertisementToken(ctx, coin.LockingScript, overlay.ProtocolSLAP); err == nil {
+				quotaEnforcer.Release(token)
+			}
 		}
-		return overlay.AdmittanceInstructions{
-			OutputsToAdmit: outputsToAdmit,
-			CoinsToRetain:  []uint32{},
-		}, nil
 	}
 
-	// Check each output for SLAP token validity
+	// Decode and validate the tokens of all outputs, verifying their signatures in parallel
+	lockingScripts := make([]*script.Script, len(parsedTransaction.Outputs))
 	for i, output := range parsedTransaction.Outputs {
-		// Decode PushDrop token
-		result := pushdrop.Decode(output.LockingScript)
-		if result == nil {
-			continue // It's common for other outputs to be invalid; no need to log an error here
-		}
+		lockingScripts[i] = output.LockingScript
+	}
+	tokens, tokenErrs := utils.ParseAdvertisementTokens(ctx, lockingScripts, overlay.ProtocolSLAP, concurrency)
 
-		// SLAP tokens must have exactly 5 fields
-		if len(result.Fields) != 5 {
-			continue
+	// Check each output for SLAP token validity, applying the policies and quotas in output order
+	for i, token := range tokens {
+		if i > math.MaxUint32 {
+			break
 		}
-
-		// Check SLAP identifier (first field)
-		slapIdentifier := utils.UTFBytesToString(result.Fields[0])
-		if slapIdentifier != "SLAP" {
-			continue
+		decision := types.AdmissionDecision{OutputIndex: uint32(i)}
+		err := tokenErrs[i]
+		if err == nil {
+			err = tm.policies.Admit(ctx, token)
 		}
-
-		// Check advertised URI (third field)
-		advertisedURI := utils.UTFBytesToString(result.Fields[2])
-		if !utils.IsAdvertisableURI(advertisedURI) {
-			continue
+		if err == nil && quotaEnforcer != nil {
+			if err = tm.admitWithinQuotas(ctx, quotaEnforcer, recordTxid(parsedTransaction.TxID()), i, token); err != nil && !errors.Is(err, utils.ErrQuotaExceeded) {
+				return nil, err
+			}
 		}
-
-		// Check topic name (fourth field)
-		topic := utils.UTFBytesToString(result.Fields[3])
-		if !utils.IsValidTopicOrServiceName(topic) {
-			continue
+		if err != nil {
+			decision.Reason = utils.TokenRejectionReason(err)
+			decision.Err = err
+		} else {
+			decision.Admitted = true
 		}
+		report.Decisions = append(report.Decisions, decision)
+	}
+
+	return report, nil
+}
 
-		// SLAP only accepts "ls_" (lookup service) advertisements
-		if !strings.HasPrefix(topic, "ls_") {
-			continue
+// admitWithinQuotas counts the token of an output against the quotas, unless the output is already
+// stored, as when an admitted transaction is resubmitted. Its record is then part of the stored counts
+// already, and rejecting it would drop an advertisement that is within the quotas.
+func (tm *TopicManager) admitWithinQuotas(ctx context.Context, quotaEnforcer *utils.QuotaEnforcer, txid string, outputIndex int, token *utils.AdvertisementToken) error {
+	records, err := tm.storage.FindSLAPRecords(ctx, types.SLAPQuery{IdentityKey: &token.IdentityKey, Domain: &token.Domain, Service: &token.TopicOrService})
+	if err != nil {
+		return fmt.Errorf("failed to find stored SLAP records: %w", err)
+	}
+	for _, record := range records {
+		if record.Txid == txid && record.OutputIndex == outputIndex {
+			return nil
 		}
+	}
+	return quotaEnforcer.Admit(ctx, token)
+}
 
-		// Check token signature linkage
-		lockingPublicKey := result.LockingPublicKey.ToDERHex()
-		tokenFields := make(utils.TokenFields, len(result.Fields))
-		copy(tokenFields, result.Fields)
+// quotaCounter counts the active SLAP records in storage against the advertisement quotas
+type quotaCounter struct {
+	storage StorageInterface
+}
 
-		// For now, use mock wallet - in production this should be the real wallet
-		if valid, err := utils.IsTokenSignatureCorrectlyLinked(ctx, lockingPublicKey, tokenFields); err == nil && valid {
-			if i >= 0 && i <= 0xFFFFFFFF {
-				outputsToAdmit = append(outputsToAdmit, uint32(i))
-			}
-		} else if err == nil && !valid {
-			slog.Info("Invalid token signature linkage", "outputIndex", i, "txid", parsedTransaction.TxID())
-		}
+// Compile-time verification that quotaCounter implements utils.QuotaCounter
+var _ utils.QuotaCounter = quotaCounter{}
+
+// CountByIdentityKey counts the SLAP records of the identity key
+func (c quotaCounter) CountByIdentityKey(ctx context.Context, identityKey string) (int64, error) {
+	return c.count(ctx, types.SLAPQuery{IdentityKey: &identityKey}, types.AggregateCountByIdentityKey)
+}
+
+// CountByIdentityKeyAndTopicOrService counts the SLAP records of the identity key for the service
+func (c quotaCounter) CountByIdentityKeyAndTopicOrService(ctx context.Context, identityKey, service string) (int64, error) {
+	return c.count(ctx, types.SLAPQuery{IdentityKey: &identityKey, Services: []string{service}}, types.AggregateCountByIdentityKey)
+}
+
+// CountByDomain counts the SLAP records advertising the domain
+func (c quotaCounter) CountByDomain(ctx context.Context, domain string) (int64, error) {
+	return c.count(ctx, types.SLAPQuery{Domain: &domain}, types.AggregateCountByDomain)
+}
+
+// count sums the record counts of the query
+func (c quotaCounter) count(ctx context.Context, query types.SLAPQuery, groupBy types.AggregateMode) (int64, error) {
+	counts, err := c.storage.CountSLAPRecords(ctx, query, groupBy)
+	if err != nil {
+		return 0, err
 	}
 
-	// Friendly logging with slappy emojis
-	if len(outputsToAdmit) > 0 {
-		if len(outputsToAdmit) == 1 {
-			log.Printf("👏 Admitted %d SLAP output!", len(outputsToAdmit))
-		} else {
-			log.Printf("👏 Admitted %d SLAP outputs!", len(outputsToAdmit))
-		}
+	var total int64
+	for _, count := range counts {
+		total += count.Count
 	}
+	return total, nil
+}
 
-	if len(previousCoins) > 0 {
-		if len(previousCoins) == 1 {
-			log.Printf("✋ Consumed %d previous SLAP coin!", len(previousCoins))
-		} else {
-			log.Printf("✋ Consumed %d previous SLAP coins!", len(previousCoins))
-		}
+// logAdmission logs the admission report. It is common for other outputs not to be SLAP tokens,
+// so those rejections are only logged at debug level, while rejected SLAP tokens are logged at info level.
+func logAdmission(ctx context.Context, logger *slog.Logger, report *types.AdmissionReport) {
+	if report.Reason != "" {
+		logger.WarnContext(ctx, "🤚 Error identifying admissible outputs", "topic", report.Topic, "reason", report.Reason, "error", report.Err)
+		return
 	}
 
-	if len(outputsToAdmit) == 0 && len(previousCoins) == 0 {
-		log.Printf("😕 No SLAP outputs admitted and no previous SLAP coins consumed.")
+	for _, decision := range report.Rejected() {
+		level := slog.LevelInfo
+		if decision.Reason == types.AdmissionRejectionNotPushDrop || decision.Reason == types.AdmissionRejectionProtocol {
+			level = slog.LevelDebug
+		}
+		logger.Log(ctx, level, "Rejected SLAP output", "txid", report.Txid, "outputIndex", decision.OutputIndex, "reason", decision.Reason, "error", decision.Err)
 	}
 
-	return overlay.AdmittanceInstructions{
-		OutputsToAdmit: outputsToAdmit,
-		CoinsToRetain:  []uint32{},
-	}, nil
+	// Friendly logging with slappy emojis
+	admitted := len(report.Admitted())
+	if admitted > 0 {
+		logger.InfoContext(ctx, "👏 Admitted SLAP outputs!", "txid", report.Txid, "count", admitted)
+	}
+	if report.PreviousCoins > 0 {
+		logger.InfoContext(ctx, "✋ Consumed previous SLAP coins!", "txid", report.Txid, "count", report.PreviousCoins)
+	}
+	if admitted == 0 && report.PreviousCoins == 0 {
+		logger.InfoContext(ctx, "😕 No SLAP outputs admitted and no previous SLAP coins consumed.", "txid", report.Txid)
+	}
 }
 
 // IdentifyNeededInputs implements the engine.TopicManager interface
@@ -64,6 +64,9 @@ If any step fails, the SLAP output is rejected.
 4. **Signature**:
    - Must be in DER format.
    - Must match the identity key.
+5. **Admission Policies**:
+   - Operators may configure additional admission policies (such as identity key allowlists or domain suffix filters), which every output passing the checks above must also satisfy.
+   - Operators may also limit the number of active records per identity key, per identity key and service, and per domain. Outputs exceeding a quota are not admitted, while resubmitting an already admitted output is not counted twice.
 
 ---
 
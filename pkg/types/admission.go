@@ -0,0 +1,97 @@
+package types
+
+// Admission decision reports of the SHIP and SLAP topic managers.
+
+import "context"
+
+// AdmissionRejectionReason tells why a topic manager did not admit an output
+type AdmissionRejectionReason string
+
+// Reasons for a topic manager not to admit an output
+const (
+	// AdmissionRejectionInvalidBEEF means the transaction could not be parsed from its BEEF
+	AdmissionRejectionInvalidBEEF AdmissionRejectionReason = "invalidBeef"
+	// AdmissionRejectionNotPushDrop means the output is not a PushDrop token
+	AdmissionRejectionNotPushDrop AdmissionRejectionReason = "notPushDrop"
+	// AdmissionRejectionProtocol means the token is not of the protocol of the topic manager
+	AdmissionRejectionProtocol AdmissionRejectionReason = "protocol"
+	// AdmissionRejectionFieldCount means the token does not have exactly 5 fields
+	AdmissionRejectionFieldCount AdmissionRejectionReason = "fieldCount"
+	// AdmissionRejectionURI means the advertised URI is not advertisable
+	AdmissionRejectionURI AdmissionRejectionReason = "uri"
+	// AdmissionRejectionTopicOrService means the topic or service name is invalid or has the wrong prefix
+	AdmissionRejectionTopicOrService AdmissionRejectionReason = "topicOrService"
+	// AdmissionRejectionSignature means the signature is not linked to the identity key
+	AdmissionRejectionSignature AdmissionRejectionReason = "signature"
+	// AdmissionRejectionPolicy means the token is valid but an admission policy of the topic manager rejected it
+	AdmissionRejectionPolicy AdmissionRejectionReason = "policy"
+	// AdmissionRejectionQuota means the token is valid but would exceed an advertisement quota
+	AdmissionRejectionQuota AdmissionRejectionReason = "quota"
+)
+
+// AdmissionDecision records whether a topic manager admitted an output of a transaction
+type AdmissionDecision struct {
+	// OutputIndex is the index of the output in the transaction
+	OutputIndex uint32 `json:"outputIndex"`
+	// Admitted tells whether the output was admitted
+	Admitted bool `json:"admitted"`
+	// Reason is why the output was rejected, empty if it was admitted
+	Reason AdmissionRejectionReason `json:"reason,omitempty"`
+	// Err details the rejection, nil if the output was admitted
+	Err error `json:"-"`
+}
+
+// AdmissionReport records the decisions of a topic manager about the outputs of a transaction
+type AdmissionReport struct {
+	// Topic is the topic of the topic manager, e.g. "tm_ship"
+	Topic string `json:"topic"`
+	// Txid is the id of the transaction, empty if its BEEF could not be parsed
+	Txid string `json:"txid,omitempty"`
+	// Reason is AdmissionRejectionInvalidBEEF if the whole transaction was rejected, empty otherwise
+	Reason AdmissionRejectionReason `json:"reason,omitempty"`
+	// Err details why the whole transaction was rejected
+	Err error `json:"-"`
+	// Decisions holds one decision per output of the transaction
+	Decisions []AdmissionDecision `json:"decisions"`
+	// PreviousCoins is the number of previous coins consumed by the transaction
+	PreviousCoins int `json:"previousCoins"`
+}
+
+// Admitted returns the indexes of the admitted outputs
+func (r *AdmissionReport) Admitted() []uint32 {
+	admitted := []uint32{}
+	for _, decision := range r.Decisions {
+		if decision.Admitted {
+			admitted = append(admitted, decision.OutputIndex)
+		}
+	}
+	return admitted
+}
+
+// Rejected returns the decisions about the outputs that were not admitted
+func (r *AdmissionReport) Rejected() []AdmissionDecision {
+	var rejected []AdmissionDecision
+	for _, decision := range r.Decisions {
+		if !decision.Admitted {
+			rejected = append(rejected, decision)
+		}
+	}
+	return rejected
+}
+
+// AdvertisementQuotas limits the number of active records a topic manager admits, to keep a single
+// identity key from flooding the overlay with advertisements. Records count as active until their
+// output is spent. A zero or negative limit disables the quota.
+type AdvertisementQuotas struct {
+	// MaxRecordsPerIdentity limits the active records of an identity key
+	MaxRecordsPerIdentity int `json:"maxRecordsPerIdentity,omitempty"`
+	// MaxRecordsPerIdentityTopic limits the active records of an identity key for a single topic (SHIP)
+	// or service (SLAP)
+	MaxRecordsPerIdentityTopic int `json:"maxRecordsPerIdentityTopic,omitempty"`
+	// MaxRecordsPerDomain limits the active records advertising a domain
+	MaxRecordsPerDomain int `json:"maxRecordsPerDomain,omitempty"`
+}
+
+// AdmissionObserver receives the admission report of every transaction a topic manager evaluates.
+// It is called synchronously, so it should return quickly.
+type AdmissionObserver func(ctx context.Context, report *AdmissionReport)
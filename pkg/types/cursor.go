@@ -0,0 +1,71 @@
+package types
+
+// Opaque cursors for deterministic pagination of SHIP and SLAP records.
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// RecordCursor identifies the position of a record in the (createdAt, txid, outputIndex) ordering
+// used to page through SHIP and SLAP records. Clients only ever see it in its encoded, opaque form.
+type RecordCursor struct {
+	// CreatedAt is the creation timestamp of the record
+	CreatedAt time.Time `json:"createdAt"`
+	// Txid is the transaction ID of the record
+	Txid string `json:"txid"`
+	// OutputIndex is the output index of the record
+	OutputIndex int `json:"outputIndex"`
+}
+
+// Encode returns the opaque string form of the cursor.
+func (c RecordCursor) Encode() string {
+	// Marshaling a struct of a time, string and int cannot fail
+	data, _ := json.Marshal(RecordCursor{
+		CreatedAt:   c.CreatedAt.UTC(),
+		Txid:        c.Txid,
+		OutputIndex: c.OutputIndex,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Compare orders two cursors by createdAt, then txid, then outputIndex.
+// It returns -1 if c sorts before other, 1 if it sorts after and 0 if both are equal.
+func (c RecordCursor) Compare(other RecordCursor) int {
+	if result := c.CreatedAt.Compare(other.CreatedAt); result != 0 {
+		return result
+	}
+	if result := strings.Compare(c.Txid, other.Txid); result != 0 {
+		return result
+	}
+	return cmp.Compare(c.OutputIndex, other.OutputIndex)
+}
+
+// DecodeRecordCursor decodes a cursor previously produced by RecordCursor.Encode.
+// The returned timestamp is in UTC.
+func DecodeRecordCursor(cursor string) (*RecordCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+
+	var decoded RecordCursor
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+
+	if decoded.CreatedAt.IsZero() || decoded.Txid == "" || decoded.OutputIndex < 0 {
+		return nil, fmt.Errorf("%w: missing record position", ErrInvalidCursor)
+	}
+
+	decoded.CreatedAt = decoded.CreatedAt.UTC()
+	return &decoded, nil
+}
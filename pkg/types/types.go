@@ -3,7 +3,10 @@
 package types
 
 import (
+	"context"
 	"time"
+
+	"github.com/bsv-blockchain/go-sdk/chainhash"
 )
 
 // UTXOReference represents a reference to a specific UTXO (Unspent Transaction Output).
@@ -28,6 +31,16 @@ type SHIPRecord struct {
 	Domain string `json:"domain" bson:"domain"`
 	// Topic is the specific topic or service type being advertised
 	Topic string `json:"topic" bson:"topic"`
+	// Scheme is the normalized URI scheme of the domain, e.g. "https+bsvauth" (empty if it could not be parsed)
+	Scheme string `json:"scheme" bson:"scheme"`
+	// Host is the normalized host of the domain, lowercased and without port (empty if it has none)
+	Host string `json:"host" bson:"host"`
+	// ReversedHost is Host with its labels reversed, indexed to match subdomains by prefix
+	ReversedHost string `json:"-" bson:"reversedHost"`
+	// BlockHeight is the height of the block the record's transaction was mined in; 0 while unconfirmed
+	BlockHeight uint32 `json:"blockHeight" bson:"blockHeight"`
+	// BlockHash is the hash of that block, if known; empty while unconfirmed
+	BlockHash string `json:"blockHash,omitempty" bson:"blockHash"`
 	// CreatedAt is the timestamp when the record was created
 	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
 }
@@ -45,6 +58,16 @@ type SLAPRecord struct {
 	Domain string `json:"domain" bson:"domain"`
 	// Service is the specific service being advertised
 	Service string `json:"service" bson:"service"`
+	// Scheme is the normalized URI scheme of the domain, e.g. "https+bsvauth" (empty if it could not be parsed)
+	Scheme string `json:"scheme" bson:"scheme"`
+	// Host is the normalized host of the domain, lowercased and without port (empty if it has none)
+	Host string `json:"host" bson:"host"`
+	// ReversedHost is Host with its labels reversed, indexed to match subdomains by prefix
+	ReversedHost string `json:"-" bson:"reversedHost"`
+	// BlockHeight is the height of the block the record's transaction was mined in; 0 while unconfirmed
+	BlockHeight uint32 `json:"blockHeight" bson:"blockHeight"`
+	// BlockHash is the hash of that block, if known; empty while unconfirmed
+	BlockHash string `json:"blockHash,omitempty" bson:"blockHash"`
 	// CreatedAt is the timestamp when the record was created
 	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
 }
@@ -59,6 +82,20 @@ const (
 	SortOrderDesc SortOrder = "desc"
 )
 
+// AggregateMode selects the field matching records are grouped and counted by in an aggregate query
+type AggregateMode string
+
+const (
+	// AggregateCountByTopic counts SHIP records per topic
+	AggregateCountByTopic AggregateMode = "countByTopic"
+	// AggregateCountByService counts SLAP records per service
+	AggregateCountByService AggregateMode = "countByService"
+	// AggregateCountByDomain counts records per advertised domain
+	AggregateCountByDomain AggregateMode = "countByDomain"
+	// AggregateCountByIdentityKey counts records per identity key
+	AggregateCountByIdentityKey AggregateMode = "countByIdentityKey"
+)
+
 // SHIPQuery represents query parameters for searching SHIP records.
 // All fields are optional and can be used to filter and paginate results.
 type SHIPQuery struct {
@@ -66,6 +103,25 @@ type SHIPQuery struct {
 	FindAll *bool `json:"findAll,omitempty" bson:"findAll,omitempty"`
 	// Domain filters records by domain
 	Domain *string `json:"domain,omitempty" bson:"domain,omitempty"`
+	// Domains filters records by any of several domains (combined with Domain if both are set)
+	Domains []string `json:"domains,omitempty" bson:"domains,omitempty"`
+	// HostPattern filters records by the host of their domain: "host.name" matches that host exactly,
+	// "*.host.name" matches any of its subdomains
+	HostPattern *string `json:"hostPattern,omitempty" bson:"hostPattern,omitempty"`
+	// Schemes filters records by any of several URI schemes of their domain, e.g. "https+bsvauth"
+	Schemes []string `json:"schemes,omitempty" bson:"schemes,omitempty"`
+	// CreatedAfter filters records created at or after this time (inclusive)
+	CreatedAfter *time.Time `json:"createdAfter,omitempty" bson:"createdAfter,omitempty"`
+	// CreatedBefore filters records created before this time (exclusive)
+	CreatedBefore *time.Time `json:"createdBefore,omitempty" bson:"createdBefore,omitempty"`
+	// Aggregate requests the number of matching records grouped by a field instead of the records
+	Aggregate *AggregateMode `json:"aggregate,omitempty" bson:"aggregate,omitempty"`
+	// MinConfirmations filters records whose transaction has at least this many confirmations;
+	// 1 matches all mined records
+	MinConfirmations *int `json:"minConfirmations,omitempty" bson:"minConfirmations,omitempty"`
+	// ChainTip is the current chain height MinConfirmations is evaluated against.
+	// It is not part of the lookup query; the lookup service sets it from its chain tracker.
+	ChainTip *uint32 `json:"-" bson:"-"`
 	// Topics filters records by topic names
 	Topics []string `json:"topics,omitempty" bson:"topics,omitempty"`
 	// IdentityKey filters records by identity key
@@ -76,6 +132,11 @@ type SHIPQuery struct {
 	Skip *int `json:"skip,omitempty" bson:"skip,omitempty"`
 	// SortOrder specifies the sort order for results
 	SortOrder *SortOrder `json:"sortOrder,omitempty" bson:"sortOrder,omitempty"`
+	// IncludeRecords requests the full SHIPRecord documents instead of only the matching outpoints
+	IncludeRecords *bool `json:"includeRecords,omitempty" bson:"includeRecords,omitempty"`
+	// Cursor continues a previous page from its NextCursor; an empty cursor starts at the first record.
+	// Queries with a cursor are answered with a page that carries the cursor for the following page.
+	Cursor *string `json:"cursor,omitempty" bson:"cursor,omitempty"`
 }
 
 // SLAPQuery represents query parameters for searching SLAP records.
@@ -85,8 +146,29 @@ type SLAPQuery struct {
 	FindAll *bool `json:"findAll,omitempty" bson:"findAll,omitempty"`
 	// Domain filters records by domain
 	Domain *string `json:"domain,omitempty" bson:"domain,omitempty"`
+	// Domains filters records by any of several domains (combined with Domain if both are set)
+	Domains []string `json:"domains,omitempty" bson:"domains,omitempty"`
+	// HostPattern filters records by the host of their domain: "host.name" matches that host exactly,
+	// "*.host.name" matches any of its subdomains
+	HostPattern *string `json:"hostPattern,omitempty" bson:"hostPattern,omitempty"`
+	// Schemes filters records by any of several URI schemes of their domain, e.g. "https+bsvauth"
+	Schemes []string `json:"schemes,omitempty" bson:"schemes,omitempty"`
+	// CreatedAfter filters records created at or after this time (inclusive)
+	CreatedAfter *time.Time `json:"createdAfter,omitempty" bson:"createdAfter,omitempty"`
+	// CreatedBefore filters records created before this time (exclusive)
+	CreatedBefore *time.Time `json:"createdBefore,omitempty" bson:"createdBefore,omitempty"`
+	// Aggregate requests the number of matching records grouped by a field instead of the records
+	Aggregate *AggregateMode `json:"aggregate,omitempty" bson:"aggregate,omitempty"`
+	// MinConfirmations filters records whose transaction has at least this many confirmations;
+	// 1 matches all mined records
+	MinConfirmations *int `json:"minConfirmations,omitempty" bson:"minConfirmations,omitempty"`
+	// ChainTip is the current chain height MinConfirmations is evaluated against.
+	// It is not part of the lookup query; the lookup service sets it from its chain tracker.
+	ChainTip *uint32 `json:"-" bson:"-"`
 	// Service filters records by service name
 	Service *string `json:"service,omitempty" bson:"service,omitempty"`
+	// Services filters records by any of several service names (combined with Service if both are set)
+	Services []string `json:"services,omitempty" bson:"services,omitempty"`
 	// IdentityKey filters records by identity key
 	IdentityKey *string `json:"identityKey,omitempty" bson:"identityKey,omitempty"`
 	// Limit specifies the maximum number of records to return
@@ -95,6 +177,56 @@ type SLAPQuery struct {
 	Skip *int `json:"skip,omitempty" bson:"skip,omitempty"`
 	// SortOrder specifies the sort order for results
 	SortOrder *SortOrder `json:"sortOrder,omitempty" bson:"sortOrder,omitempty"`
+	// IncludeRecords requests the full SLAPRecord documents instead of only the matching outpoints
+	IncludeRecords *bool `json:"includeRecords,omitempty" bson:"includeRecords,omitempty"`
+	// Cursor continues a previous page from its NextCursor; an empty cursor starts at the first record.
+	// Queries with a cursor are answered with a page that carries the cursor for the following page.
+	Cursor *string `json:"cursor,omitempty" bson:"cursor,omitempty"`
+}
+
+// SHIPPage is a page of SHIP lookup results returned for queries with a cursor.
+type SHIPPage struct {
+	// UTXOs holds the UTXO references on this page unless full records were requested
+	UTXOs []UTXOReference `json:"utxos,omitempty"`
+	// Records holds the full records on this page when IncludeRecords was set
+	Records []SHIPRecord `json:"records,omitempty"`
+	// NextCursor is the cursor for the following page; it is empty once the last page was returned
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// SLAPPage is a page of SLAP lookup results returned for queries with a cursor.
+type SLAPPage struct {
+	// UTXOs holds the UTXO references on this page unless full records were requested
+	UTXOs []UTXOReference `json:"utxos,omitempty"`
+	// Records holds the full records on this page when IncludeRecords was set
+	Records []SLAPRecord `json:"records,omitempty"`
+	// NextCursor is the cursor for the following page; it is empty once the last page was returned
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// AggregateCount is the number of records sharing one value of the grouped field
+type AggregateCount struct {
+	// Key is the value of the grouped field, e.g. a topic name
+	Key string `json:"key" bson:"_id"`
+	// Count is the number of matching records with that value
+	Count int64 `json:"count" bson:"count"`
+}
+
+// AggregateResult is returned for aggregate queries.
+// Counts are ordered by descending count, ties broken by ascending key.
+type AggregateResult struct {
+	// Aggregate is the aggregate mode that produced the counts
+	Aggregate AggregateMode `json:"aggregate"`
+	// Counts holds one entry per distinct value of the grouped field
+	Counts []AggregateCount `json:"counts"`
+}
+
+// BlockHashResolver resolves the hash of the block at a given height.
+// The overlay engine only reports block heights to lookup services; chain trackers that also
+// implement this interface let the SHIP and SLAP lookup services record block hashes.
+type BlockHashResolver interface {
+	// BlockHash returns the hash of the block at the given height on the current chain
+	BlockHash(ctx context.Context, height uint32) (*chainhash.Hash, error)
 }
 
 // Script represents a locking script that can be decoded
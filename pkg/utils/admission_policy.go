@@ -0,0 +1,130 @@
+package utils
+
+// Admission policies applied by the topic managers to valid advertisement tokens.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors returned by the admission policies. They all wrap ErrAdmissionPolicyRejected, which
+// AdmissionPolicies also wraps around the errors of custom policies.
+var (
+	// ErrAdmissionPolicyRejected is wrapped by every admission policy rejection
+	ErrAdmissionPolicyRejected = errors.New("rejected by admission policy")
+	// ErrIdentityKeyNotAllowed means the identity key is not in the allowlist
+	ErrIdentityKeyNotAllowed = fmt.Errorf("%w: identity key is not allowed", ErrAdmissionPolicyRejected)
+	// ErrIdentityKeyDenied means the identity key is in the denylist
+	ErrIdentityKeyDenied = fmt.Errorf("%w: identity key is denied", ErrAdmissionPolicyRejected)
+	// ErrTopicOrServiceNotAllowed means the topic or service is not in the allowlist
+	ErrTopicOrServiceNotAllowed = fmt.Errorf("%w: topic or service is not allowed", ErrAdmissionPolicyRejected)
+	// ErrDomainNotAllowed means the host of the advertised URI has none of the allowed suffixes
+	ErrDomainNotAllowed = fmt.Errorf("%w: domain is not allowed", ErrAdmissionPolicyRejected)
+)
+
+// AdmissionPolicy is an additional admission rule of a topic manager. Topic managers only run their
+// policies on tokens that passed the built-in checks of ParseAdvertisementToken.
+type AdmissionPolicy interface {
+	// Admit returns nil to admit the token, or an error telling why it is rejected
+	Admit(ctx context.Context, token *AdvertisementToken) error
+}
+
+// AdmissionPolicyFunc adapts a function to the AdmissionPolicy interface
+type AdmissionPolicyFunc func(ctx context.Context, token *AdvertisementToken) error
+
+// Admit calls the function
+func (f AdmissionPolicyFunc) Admit(ctx context.Context, token *AdvertisementToken) error {
+	return f(ctx, token)
+}
+
+// AdmissionPolicies is a chain of admission policies admitting a token only if every policy admits it
+type AdmissionPolicies []AdmissionPolicy
+
+// Admit runs the policies in order and returns the rejection of the first policy rejecting the token,
+// wrapped with ErrAdmissionPolicyRejected. Nil policies are skipped.
+func (p AdmissionPolicies) Admit(ctx context.Context, token *AdvertisementToken) error {
+	for _, policy := range p {
+		if policy == nil {
+			continue
+		}
+		if err := policy.Admit(ctx, token); err != nil {
+			if errors.Is(err, ErrAdmissionPolicyRejected) {
+				return err
+			}
+			return fmt.Errorf("%w: %w", ErrAdmissionPolicyRejected, err)
+		}
+	}
+	return nil
+}
+
+// IdentityKeyAllowlist returns a policy admitting only the tokens of the hex-encoded identity keys
+func IdentityKeyAllowlist(identityKeys ...string) AdmissionPolicy {
+	allowed := identityKeySet(identityKeys)
+	return AdmissionPolicyFunc(func(_ context.Context, token *AdvertisementToken) error {
+		if _, ok := allowed[strings.ToLower(token.IdentityKey)]; !ok {
+			return fmt.Errorf("%w: %s", ErrIdentityKeyNotAllowed, token.IdentityKey)
+		}
+		return nil
+	})
+}
+
+// IdentityKeyDenylist returns a policy rejecting the tokens of the hex-encoded identity keys
+func IdentityKeyDenylist(identityKeys ...string) AdmissionPolicy {
+	denied := identityKeySet(identityKeys)
+	return AdmissionPolicyFunc(func(_ context.Context, token *AdvertisementToken) error {
+		if _, ok := denied[strings.ToLower(token.IdentityKey)]; ok {
+			return fmt.Errorf("%w: %s", ErrIdentityKeyDenied, token.IdentityKey)
+		}
+		return nil
+	})
+}
+
+// TopicOrServiceAllowlist returns a policy admitting only the tokens advertising one of the topics
+// or services, e.g. "tm_meter" for SHIP or "ls_meter" for SLAP
+func TopicOrServiceAllowlist(names ...string) AdmissionPolicy {
+	allowed := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		allowed[name] = struct{}{}
+	}
+	return AdmissionPolicyFunc(func(_ context.Context, token *AdvertisementToken) error {
+		if _, ok := allowed[token.TopicOrService]; !ok {
+			return fmt.Errorf("%w: %q", ErrTopicOrServiceNotAllowed, token.TopicOrService)
+		}
+		return nil
+	})
+}
+
+// DomainSuffixFilter returns a policy admitting only the tokens whose advertised URI has a host equal
+// to one of the suffixes or a subdomain of it, e.g. "example.com" admits "https://example.com" and
+// "https://api.example.com" but not "https://badexample.com". Matching is case-insensitive.
+// URIs without a host, such as JS8 Call URIs, are rejected.
+func DomainSuffixFilter(suffixes ...string) AdmissionPolicy {
+	normalized := make([]string, 0, len(suffixes))
+	for _, suffix := range suffixes {
+		if suffix = strings.TrimPrefix(normalizeHost(strings.TrimSpace(suffix)), "."); suffix != "" {
+			normalized = append(normalized, suffix)
+		}
+	}
+	return AdmissionPolicyFunc(func(_ context.Context, token *AdvertisementToken) error {
+		uri, ok := ParseAdvertisableURI(token.Domain)
+		if ok && uri.Host != "" {
+			for _, suffix := range normalized {
+				if uri.Host == suffix || strings.HasSuffix(uri.Host, "."+suffix) {
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("%w: %q", ErrDomainNotAllowed, token.Domain)
+	})
+}
+
+// identityKeySet returns the set of the lowercased hex-encoded identity keys
+func identityKeySet(identityKeys []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(identityKeys))
+	for _, identityKey := range identityKeys {
+		set[strings.ToLower(strings.TrimSpace(identityKey))] = struct{}{}
+	}
+	return set
+}
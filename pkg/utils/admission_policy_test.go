@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bsv-blockchain/go-sdk/overlay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTestPolicy = errors.New("test policy rejection")
+
+func TestAdmissionPolicies(t *testing.T) {
+	const (
+		identityKey = "02b4632d08485ff1df2db55b9dafd23347d1c47a457072a1e87be26896549a8737"
+		otherKey    = "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+	)
+	token := func(domain, topicOrService string) *AdvertisementToken {
+		return &AdvertisementToken{
+			Protocol:       overlay.ProtocolSHIP,
+			IdentityKey:    identityKey,
+			Domain:         domain,
+			TopicOrService: topicOrService,
+		}
+	}
+	rejectAll := AdmissionPolicyFunc(func(context.Context, *AdvertisementToken) error { return errTestPolicy })
+
+	tests := []struct {
+		name        string
+		policy      AdmissionPolicy
+		token       *AdvertisementToken
+		expectedErr error
+	}{
+		{name: "Allowlisted identity key", policy: IdentityKeyAllowlist(otherKey, identityKey), token: token("https://example.com", "tm_meter")},
+		{name: "Allowlist ignores case", policy: IdentityKeyAllowlist("02B4632D08485FF1DF2DB55B9DAFD23347D1C47A457072A1E87BE26896549A8737"), token: token("https://example.com", "tm_meter")},
+		{name: "Identity key not allowlisted", policy: IdentityKeyAllowlist(otherKey), token: token("https://example.com", "tm_meter"), expectedErr: ErrIdentityKeyNotAllowed},
+		{name: "Empty allowlist", policy: IdentityKeyAllowlist(), token: token("https://example.com", "tm_meter"), expectedErr: ErrIdentityKeyNotAllowed},
+		{name: "Denylisted identity key", policy: IdentityKeyDenylist(identityKey), token: token("https://example.com", "tm_meter"), expectedErr: ErrIdentityKeyDenied},
+		{name: "Identity key not denylisted", policy: IdentityKeyDenylist(otherKey), token: token("https://example.com", "tm_meter")},
+		{name: "Allowed topic", policy: TopicOrServiceAllowlist("tm_meter", "tm_ship"), token: token("https://example.com", "tm_meter")},
+		{name: "Topic not allowed", policy: TopicOrServiceAllowlist("tm_ship"), token: token("https://example.com", "tm_meter"), expectedErr: ErrTopicOrServiceNotAllowed},
+		{name: "Domain equal to the suffix", policy: DomainSuffixFilter("example.com"), token: token("https://Example.com/", "tm_meter")},
+		{name: "Subdomain of the suffix", policy: DomainSuffixFilter(".example.com"), token: token("https+bsvauth+smf://api.example.com:8080", "tm_meter")},
+		{name: "Domain only sharing the suffix characters", policy: DomainSuffixFilter("example.com"), token: token("https://badexample.com", "tm_meter"), expectedErr: ErrDomainNotAllowed},
+		{name: "Domain without a host", policy: DomainSuffixFilter("example.com"), token: token("js8c+bsvauth+smf:?lat=40&long=130&freq=40meters&radius=1000miles", "tm_meter"), expectedErr: ErrDomainNotAllowed},
+		{name: "Empty chain", policy: AdmissionPolicies{}, token: token("https://example.com", "tm_meter")},
+		{name: "Chain admitting", policy: AdmissionPolicies{nil, IdentityKeyDenylist(otherKey), DomainSuffixFilter("example.com")}, token: token("https://example.com", "tm_meter")},
+		{name: "Chain stops at the first rejection", policy: AdmissionPolicies{TopicOrServiceAllowlist("tm_ship"), rejectAll}, token: token("https://example.com", "tm_meter"), expectedErr: ErrTopicOrServiceNotAllowed},
+		{name: "Chain wraps custom rejections", policy: AdmissionPolicies{IdentityKeyAllowlist(identityKey), rejectAll}, token: token("https://example.com", "tm_meter"), expectedErr: errTestPolicy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := AdmissionPolicies{tt.policy}.Admit(context.Background(), tt.token)
+			if tt.expectedErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tt.expectedErr)
+			require.ErrorIs(t, err, ErrAdmissionPolicyRejected)
+			assert.Equal(t, "policy", string(TokenRejectionReason(err)))
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package utils
+
+// Enforcement of the advertisement quotas of the topic managers.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/types"
+)
+
+// Errors returned by QuotaEnforcer.Admit for tokens exceeding a quota. They all wrap ErrQuotaExceeded,
+// while failures to count the records do not.
+var (
+	// ErrQuotaExceeded is wrapped by every advertisement quota rejection
+	ErrQuotaExceeded = errors.New("advertisement quota exceeded")
+	// ErrIdentityQuotaExceeded means the identity key has too many active records
+	ErrIdentityQuotaExceeded = fmt.Errorf("%w: too many active records for the identity key", ErrQuotaExceeded)
+	// ErrIdentityTopicQuotaExceeded means the identity key has too many active records for the topic or service
+	ErrIdentityTopicQuotaExceeded = fmt.Errorf("%w: too many active records for the identity key and topic or service", ErrQuotaExceeded)
+	// ErrDomainQuotaExceeded means the domain has too many active records
+	ErrDomainQuotaExceeded = fmt.Errorf("%w: too many active records for the domain", ErrQuotaExceeded)
+)
+
+// QuotaCounter counts the active records of a topic manager, usually from its storage
+type QuotaCounter interface {
+	// CountByIdentityKey counts the active records of the identity key
+	CountByIdentityKey(ctx context.Context, identityKey string) (int64, error)
+	// CountByIdentityKeyAndTopicOrService counts the active records of the identity key for the topic or service
+	CountByIdentityKeyAndTopicOrService(ctx context.Context, identityKey, topicOrService string) (int64, error)
+	// CountByDomain counts the active records advertising the domain
+	CountByDomain(ctx context.Context, domain string) (int64, error)
+}
+
+// identityTopic identifies the records of an identity key for a topic or service
+type identityTopic struct {
+	identityKey    string
+	topicOrService string
+}
+
+// QuotaEnforcer enforces advertisement quotas on the tokens of a single transaction.
+//
+// The stored records are counted with the QuotaCounter, to which the enforcer adds the tokens it
+// admitted so far and from which it subtracts the released tokens, i.e. the advertisements spent by
+// the transaction. A transaction renewing its advertisements is therefore not rejected for the
+// records it replaces, and a transaction with many outputs cannot exceed a quota at once.
+type QuotaEnforcer struct {
+	quotas  types.AdvertisementQuotas
+	counter QuotaCounter
+	// pending records, per quota key, the admitted tokens minus the released tokens
+	identities     map[string]int64
+	identityTopics map[identityTopic]int64
+	domains        map[string]int64
+}
+
+// NewQuotaEnforcer creates an enforcer of the quotas for a transaction, counting the stored records with the counter
+func NewQuotaEnforcer(quotas types.AdvertisementQuotas, counter QuotaCounter) *QuotaEnforcer {
+	return &QuotaEnforcer{
+		quotas:         quotas,
+		counter:        counter,
+		identities:     make(map[string]int64),
+		identityTopics: make(map[identityTopic]int64),
+		domains:        make(map[string]int64),
+	}
+}
+
+// Release discounts a stored advertisement that the transaction spends
+func (e *QuotaEnforcer) Release(token *AdvertisementToken) {
+	e.identities[token.IdentityKey]--
+	e.identityTopics[identityTopic{token.IdentityKey, token.TopicOrService}]--
+	e.domains[token.Domain]--
+}
+
+// Admit returns an error wrapping ErrQuotaExceeded if admitting the token exceeds a quota, and
+// otherwise counts the token against the quotas of the following tokens. Failures to count the
+// stored records are returned as is.
+func (e *QuotaEnforcer) Admit(ctx context.Context, token *AdvertisementToken) error {
+	key := identityTopic{token.IdentityKey, token.TopicOrService}
+
+	exceeded, err := exceedsQuota(e.quotas.MaxRecordsPerIdentity, e.identities[token.IdentityKey], func() (int64, error) {
+		return e.counter.CountByIdentityKey(ctx, token.IdentityKey)
+	})
+	if err != nil || exceeded {
+		return quotaError(err, ErrIdentityQuotaExceeded, e.quotas.MaxRecordsPerIdentity)
+	}
+
+	exceeded, err = exceedsQuota(e.quotas.MaxRecordsPerIdentityTopic, e.identityTopics[key], func() (int64, error) {
+		return e.counter.CountByIdentityKeyAndTopicOrService(ctx, token.IdentityKey, token.TopicOrService)
+	})
+	if err != nil || exceeded {
+		return quotaError(err, ErrIdentityTopicQuotaExceeded, e.quotas.MaxRecordsPerIdentityTopic)
+	}
+
+	exceeded, err = exceedsQuota(e.quotas.MaxRecordsPerDomain, e.domains[token.Domain], func() (int64, error) {
+		return e.counter.CountByDomain(ctx, token.Domain)
+	})
+	if err != nil || exceeded {
+		return quotaError(err, ErrDomainQuotaExceeded, e.quotas.MaxRecordsPerDomain)
+	}
+
+	e.identities[token.IdentityKey]++
+	e.identityTopics[key]++
+	e.domains[token.Domain]++
+	return nil
+}
+
+// exceedsQuota reports whether one more record exceeds the limit, given the stored records and the
+// records pending in the transaction. The stored records are only counted if the limit is enabled.
+func exceedsQuota(limit int, pending int64, count func() (int64, error)) (bool, error) {
+	if limit <= 0 {
+		return false, nil
+	}
+
+	stored, err := count()
+	if err != nil {
+		return false, err
+	}
+	return stored+pending+1 > int64(limit), nil
+}
+
+// quotaError returns the counting failure, or else the quota rejection
+func quotaError(err, exceeded error, limit int) error {
+	if err != nil {
+		return fmt.Errorf("failed to count active records: %w", err)
+	}
+	return fmt.Errorf("%w (limit %d)", exceeded, limit)
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTestCount = errors.New("count failed")
+
+// testQuotaCounter counts the records of fixed test tokens
+type testQuotaCounter struct {
+	records []*AdvertisementToken
+	err     error
+	calls   int
+}
+
+func (c *testQuotaCounter) count(match func(*AdvertisementToken) bool) (int64, error) {
+	c.calls++
+	if c.err != nil {
+		return 0, c.err
+	}
+	var count int64
+	for _, record := range c.records {
+		if match(record) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (c *testQuotaCounter) CountByIdentityKey(_ context.Context, identityKey string) (int64, error) {
+	return c.count(func(record *AdvertisementToken) bool { return record.IdentityKey == identityKey })
+}
+
+func (c *testQuotaCounter) CountByIdentityKeyAndTopicOrService(_ context.Context, identityKey, topicOrService string) (int64, error) {
+	return c.count(func(record *AdvertisementToken) bool {
+		return record.IdentityKey == identityKey && record.TopicOrService == topicOrService
+	})
+}
+
+func (c *testQuotaCounter) CountByDomain(_ context.Context, domain string) (int64, error) {
+	return c.count(func(record *AdvertisementToken) bool { return record.Domain == domain })
+}
+
+func TestQuotaEnforcer(t *testing.T) {
+	ctx := context.Background()
+	token := func(identityKey, domain, topicOrService string) *AdvertisementToken {
+		return &AdvertisementToken{IdentityKey: identityKey, Domain: domain, TopicOrService: topicOrService}
+	}
+	stored := []*AdvertisementToken{
+		token("01", "https://a.com", "tm_meter"),
+		token("01", "https://b.com", "tm_meter"),
+		token("02", "https://a.com", "tm_bridge"),
+	}
+
+	tests := []struct {
+		name        string
+		quotas      types.AdvertisementQuotas
+		token       *AdvertisementToken
+		expectedErr error
+	}{
+		{name: "No quotas", token: token("01", "https://a.com", "tm_meter")},
+		{name: "Within identity quota", quotas: types.AdvertisementQuotas{MaxRecordsPerIdentity: 3}, token: token("01", "https://c.com", "tm_other")},
+		{name: "Identity quota exceeded", quotas: types.AdvertisementQuotas{MaxRecordsPerIdentity: 2}, token: token("01", "https://c.com", "tm_other"), expectedErr: ErrIdentityQuotaExceeded},
+		{name: "Within identity and topic quota", quotas: types.AdvertisementQuotas{MaxRecordsPerIdentityTopic: 2}, token: token("01", "https://c.com", "tm_other")},
+		{name: "Identity and topic quota exceeded", quotas: types.AdvertisementQuotas{MaxRecordsPerIdentityTopic: 2}, token: token("01", "https://c.com", "tm_meter"), expectedErr: ErrIdentityTopicQuotaExceeded},
+		{name: "Within domain quota", quotas: types.AdvertisementQuotas{MaxRecordsPerDomain: 2}, token: token("03", "https://b.com", "tm_meter")},
+		{name: "Domain quota exceeded", quotas: types.AdvertisementQuotas{MaxRecordsPerDomain: 2}, token: token("03", "https://a.com", "tm_meter"), expectedErr: ErrDomainQuotaExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := &testQuotaCounter{records: stored}
+			err := NewQuotaEnforcer(tt.quotas, counter).Admit(ctx, tt.token)
+			if tt.expectedErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tt.expectedErr)
+			require.ErrorIs(t, err, ErrQuotaExceeded)
+			assert.Equal(t, types.AdmissionRejectionQuota, TokenRejectionReason(err))
+		})
+	}
+
+	t.Run("counts the admitted tokens of the transaction", func(t *testing.T) {
+		enforcer := NewQuotaEnforcer(types.AdvertisementQuotas{MaxRecordsPerIdentity: 3}, &testQuotaCounter{records: stored})
+		require.NoError(t, enforcer.Admit(ctx, token("01", "https://c.com", "tm_other")))
+		require.ErrorIs(t, enforcer.Admit(ctx, token("01", "https://d.com", "tm_other")), ErrIdentityQuotaExceeded)
+		require.NoError(t, enforcer.Admit(ctx, token("02", "https://d.com", "tm_other")))
+	})
+
+	t.Run("discounts the released tokens", func(t *testing.T) {
+		enforcer := NewQuotaEnforcer(types.AdvertisementQuotas{MaxRecordsPerIdentityTopic: 2, MaxRecordsPerDomain: 2}, &testQuotaCounter{records: stored})
+		enforcer.Release(stored[0])
+		require.NoError(t, enforcer.Admit(ctx, token("01", "https://a.com", "tm_meter")))
+		require.ErrorIs(t, enforcer.Admit(ctx, token("01", "https://c.com", "tm_meter")), ErrIdentityTopicQuotaExceeded)
+	})
+
+	t.Run("does not count without quotas", func(t *testing.T) {
+		counter := &testQuotaCounter{records: stored}
+		require.NoError(t, NewQuotaEnforcer(types.AdvertisementQuotas{}, counter).Admit(ctx, stored[0]))
+		assert.Zero(t, counter.calls)
+	})
+
+	t.Run("returns counting failures", func(t *testing.T) {
+		counter := &testQuotaCounter{err: errTestCount}
+		err := NewQuotaEnforcer(types.AdvertisementQuotas{MaxRecordsPerDomain: 1}, counter).Admit(ctx, stored[0])
+		require.ErrorIs(t, err, errTestCount)
+		assert.NotErrorIs(t, err, ErrQuotaExceeded)
+	})
+}
@@ -0,0 +1,271 @@
+package utils
+
+// Encoding, decoding and validation of SHIP and SLAP advertisement tokens.
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/bsv-blockchain/go-overlay-discovery-services/pkg/types"
+	"github.com/bsv-blockchain/go-sdk/overlay"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction/template/pushdrop"
+	"github.com/bsv-blockchain/go-sdk/wallet"
+)
+
+const (
+	// advertisementTokenFieldCount is the number of PushDrop fields of a SHIP or SLAP advertisement token:
+	// protocol, identity key, advertised URI, topic or service name, and signature.
+	advertisementTokenFieldCount = 5
+	// AdvertisementTokenKeyID is the BRC-43 key ID the advertisement tokens are signed and locked with
+	AdvertisementTokenKeyID = "1"
+)
+
+// Errors returned by ParseAdvertisementToken. They all wrap ErrInvalidAdvertisementToken, so callers
+// can either reject any invalid token or tell the reasons apart with errors.Is.
+var (
+	// ErrInvalidAdvertisementToken is wrapped by every advertisement token validation error
+	ErrInvalidAdvertisementToken = errors.New("invalid advertisement token")
+	// ErrTokenNotPushDrop means the locking script is not a PushDrop script
+	ErrTokenNotPushDrop = fmt.Errorf("%w: not a PushDrop locking script", ErrInvalidAdvertisementToken)
+	// ErrTokenProtocol means the token is not a SHIP or SLAP token, or not of the expected protocol
+	ErrTokenProtocol = fmt.Errorf("%w: unexpected protocol", ErrInvalidAdvertisementToken)
+	// ErrTokenFieldCount means the token does not have exactly 5 fields
+	ErrTokenFieldCount = fmt.Errorf("%w: expected %d fields", ErrInvalidAdvertisementToken, advertisementTokenFieldCount)
+	// ErrTokenURI means the advertised URI is not advertisable according to BRC-101
+	ErrTokenURI = fmt.Errorf("%w: advertised URI is not advertisable", ErrInvalidAdvertisementToken)
+	// ErrTokenTopicOrService means the topic or service name is invalid, or has the prefix of the other protocol
+	ErrTokenTopicOrService = fmt.Errorf("%w: invalid topic or service name", ErrInvalidAdvertisementToken)
+	// ErrTokenSignature means the signature is invalid or not linked to the identity key and locking key
+	ErrTokenSignature = fmt.Errorf("%w: signature is not linked to the identity key", ErrInvalidAdvertisementToken)
+)
+
+// AdvertisementToken is a validated SHIP or SLAP advertisement decoded from a PushDrop locking script
+type AdvertisementToken struct {
+	// Protocol is the advertisement protocol, SHIP or SLAP
+	Protocol overlay.Protocol
+	// IdentityKey is the hex-encoded identity key of the advertiser
+	IdentityKey string
+	// Domain is the advertised URI
+	Domain string
+	// TopicOrService is the advertised topic ("tm_" prefix) or lookup service ("ls_" prefix)
+	TopicOrService string
+	// LockingPublicKey is the public key locking the token
+	LockingPublicKey *ec.PublicKey
+	// Fields are the raw PushDrop fields, the signature being the last one
+	Fields TokenFields
+	// LockingScript is the PushDrop locking script of the token
+	LockingScript *script.Script
+}
+
+// BuildAdvertisementToken creates a SHIP or SLAP advertisement token for the identity of the wallet.
+//
+// The token holds the protocol, the wallet identity key, the advertised URI, the topic or service name
+// and a BRC-48 signature over those fields, and is locked with the key the wallet derives for the
+// anyone counterparty, so that ParseAdvertisementToken links it to the identity key. The inputs are
+// validated like ParseAdvertisementToken validates the tokens, so the built token is always admissible.
+//
+// Parameters:
+//   - ctx: Context for the wallet calls
+//   - w: The wallet of the advertiser, deriving the locking key and signing the fields
+//   - protocol: The advertisement protocol, SHIP or SLAP
+//   - uri: The advertisable URI of the advertised host
+//   - topicOrService: The advertised topic ("tm_" prefix) or lookup service ("ls_" prefix)
+//
+// Returns:
+//   - *AdvertisementToken: the token, with its locking script
+//   - error: an error wrapping ErrInvalidAdvertisementToken for invalid inputs, or the wallet error
+func BuildAdvertisementToken(ctx context.Context, w wallet.Interface, protocol overlay.Protocol, uri, topicOrService string) (*AdvertisementToken, error) {
+	if protocol != overlay.ProtocolSHIP && protocol != overlay.ProtocolSLAP {
+		return nil, fmt.Errorf("%w: %q", ErrTokenProtocol, protocol)
+	}
+	if err := validateAdvertisement(protocol, uri, topicOrService); err != nil {
+		return nil, err
+	}
+
+	identityKey, err := w.GetPublicKey(ctx, wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet identity key: %w", err)
+	}
+
+	pd := pushdrop.PushDrop{Wallet: w}
+	lockingScript, err := pd.Lock(
+		ctx,
+		[][]byte{
+			[]byte(protocol),
+			identityKey.PublicKey.Compressed(),
+			[]byte(uri),
+			[]byte(topicOrService),
+		},
+		wallet.Protocol{
+			SecurityLevel: wallet.SecurityLevelEveryAppAndCounterparty,
+			Protocol:      string(protocol.ID()),
+		},
+		AdvertisementTokenKeyID,
+		wallet.Counterparty{Type: wallet.CounterpartyTypeAnyone},
+		true, // forSelf
+		true, // includeSignature
+		pushdrop.LockBefore,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create locking script: %w", err)
+	}
+
+	return ParseAdvertisementToken(ctx, lockingScript, protocol)
+}
+
+// ParseAdvertisementToken decodes a SHIP or SLAP advertisement token from a PushDrop locking script and
+// validates it the way the topic managers admit advertisements.
+//
+// The token must have exactly 5 fields, an advertisable URI, a valid topic or service name with the
+// prefix of its protocol ("tm_" for SHIP, "ls_" for SLAP), and a signature linked to the identity key
+// and the locking key.
+//
+// Parameters:
+//   - ctx: Context for the signature verification
+//   - lockingScript: The locking script of the advertisement output
+//   - protocol: The expected protocol, or an empty protocol to accept both SHIP and SLAP tokens
+//
+// Returns:
+//   - *AdvertisementToken: the decoded token
+//   - error: an error wrapping ErrInvalidAdvertisementToken if the token is not valid
+func ParseAdvertisementToken(ctx context.Context, lockingScript *script.Script, protocol overlay.Protocol) (*AdvertisementToken, error) {
+	if lockingScript == nil {
+		return nil, ErrTokenNotPushDrop
+	}
+
+	result := pushdrop.Decode(lockingScript)
+	if result == nil || result.LockingPublicKey == nil || len(result.Fields) == 0 {
+		return nil, ErrTokenNotPushDrop
+	}
+
+	tokenProtocol := overlay.Protocol(UTFBytesToString(result.Fields[0]))
+	if tokenProtocol != overlay.ProtocolSHIP && tokenProtocol != overlay.ProtocolSLAP {
+		return nil, fmt.Errorf("%w: %q", ErrTokenProtocol, tokenProtocol)
+	}
+	if protocol != "" && tokenProtocol != protocol {
+		return nil, fmt.Errorf("%w: %s, expected %s", ErrTokenProtocol, tokenProtocol, protocol)
+	}
+
+	if len(result.Fields) != advertisementTokenFieldCount {
+		return nil, fmt.Errorf("%w, got %d", ErrTokenFieldCount, len(result.Fields))
+	}
+
+	domain := UTFBytesToString(result.Fields[2])
+	topicOrService := UTFBytesToString(result.Fields[3])
+	if err := validateAdvertisement(tokenProtocol, domain, topicOrService); err != nil {
+		return nil, err
+	}
+
+	fields := make(TokenFields, len(result.Fields))
+	copy(fields, result.Fields)
+
+	valid, err := IsTokenSignatureCorrectlyLinked(ctx, result.LockingPublicKey.ToDERHex(), fields)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTokenSignature, err)
+	}
+	if !valid {
+		return nil, ErrTokenSignature
+	}
+
+	return &AdvertisementToken{
+		Protocol:         tokenProtocol,
+		IdentityKey:      hex.EncodeToString(result.Fields[1]),
+		Domain:           domain,
+		TopicOrService:   topicOrService,
+		LockingPublicKey: result.LockingPublicKey,
+		Fields:           fields,
+		LockingScript:    lockingScript,
+	}, nil
+}
+
+// validateAdvertisement checks that the URI is advertisable and that the topic or service name is
+// valid and has the prefix of the protocol, "tm_" for SHIP and "ls_" for SLAP
+func validateAdvertisement(protocol overlay.Protocol, uri, topicOrService string) error {
+	if !IsAdvertisableURI(uri) {
+		return fmt.Errorf("%w: %q", ErrTokenURI, uri)
+	}
+
+	prefix := "tm_"
+	if protocol == overlay.ProtocolSLAP {
+		prefix = "ls_"
+	}
+	if !IsValidTopicOrServiceName(topicOrService) || !strings.HasPrefix(topicOrService, prefix) {
+		return fmt.Errorf("%w: %q for %s", ErrTokenTopicOrService, topicOrService, protocol)
+	}
+	return nil
+}
+
+// ParseAdvertisementTokens parses the advertisement tokens of several locking scripts with
+// ParseAdvertisementToken, verifying their signatures in parallel with at most concurrency goroutines.
+// A zero or negative concurrency uses runtime.GOMAXPROCS(0) goroutines, while a concurrency of 1
+// parses the scripts serially.
+//
+// Returns:
+//   - []*AdvertisementToken: the decoded tokens, nil for invalid ones, in the order of the scripts
+//   - []error: the validation errors, nil for valid tokens, in the order of the scripts
+func ParseAdvertisementTokens(ctx context.Context, lockingScripts []*script.Script, protocol overlay.Protocol, concurrency int) ([]*AdvertisementToken, []error) {
+	tokens := make([]*AdvertisementToken, len(lockingScripts))
+	errs := make([]error, len(lockingScripts))
+
+	if concurrency <= 0 {
+		concurrency = runtime.GOMAXPROCS(0)
+	}
+	workers := min(concurrency, len(lockingScripts))
+	if workers <= 1 {
+		for i, lockingScript := range lockingScripts {
+			tokens[i], errs[i] = ParseAdvertisementToken(ctx, lockingScript, protocol)
+		}
+		return tokens, errs
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				tokens[i], errs[i] = ParseAdvertisementToken(ctx, lockingScripts[i], protocol)
+			}
+		}()
+	}
+	for i := range lockingScripts {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return tokens, errs
+}
+
+// TokenRejectionReason maps an error returned by ParseAdvertisementToken, by an admission policy or
+// by a QuotaEnforcer to the reason a topic manager reports for not admitting the token. It returns an empty reason for a nil error.
+func TokenRejectionReason(err error) types.AdmissionRejectionReason {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrQuotaExceeded):
+		return types.AdmissionRejectionQuota
+	case errors.Is(err, ErrAdmissionPolicyRejected):
+		return types.AdmissionRejectionPolicy
+	case errors.Is(err, ErrTokenProtocol):
+		return types.AdmissionRejectionProtocol
+	case errors.Is(err, ErrTokenFieldCount):
+		return types.AdmissionRejectionFieldCount
+	case errors.Is(err, ErrTokenURI):
+		return types.AdmissionRejectionURI
+	case errors.Is(err, ErrTokenTopicOrService):
+		return types.AdmissionRejectionTopicOrService
+	case errors.Is(err, ErrTokenSignature):
+		return types.AdmissionRejectionSignature
+	default:
+		return types.AdmissionRejectionNotPushDrop
+	}
+}
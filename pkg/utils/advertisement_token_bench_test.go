@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bsv-blockchain/go-sdk/overlay"
+	"github.com/bsv-blockchain/go-sdk/transaction/template/pushdrop"
+)
+
+func BenchmarkIsTokenSignatureCorrectlyLinked(b *testing.B) {
+	ctx := context.Background()
+	lockingScript := lockTestAdvertisementScripts(b, 1)[0]
+	decoded := pushdrop.Decode(lockingScript)
+	lockingPublicKey := decoded.LockingPublicKey.ToDERHex()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		valid, err := IsTokenSignatureCorrectlyLinked(ctx, lockingPublicKey, decoded.Fields)
+		if err != nil || !valid {
+			b.Fatalf("expected a valid signature, got %v, %v", valid, err)
+		}
+	}
+}
+
+// BenchmarkParseAdvertisementTokens compares serial parsing with the default parallel parsing of the
+// advertisement tokens of a batch advertisement transaction
+func BenchmarkParseAdvertisementTokens(b *testing.B) {
+	ctx := context.Background()
+	lockingScripts := lockTestAdvertisementScripts(b, 48)
+
+	for _, bm := range []struct {
+		name        string
+		concurrency int
+	}{
+		{"serial", 1},
+		{"parallel", 0},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				_, errs := ParseAdvertisementTokens(ctx, lockingScripts, overlay.ProtocolSHIP, bm.concurrency)
+				for _, err := range errs {
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
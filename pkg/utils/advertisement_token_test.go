@@ -0,0 +1,232 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bsv-blockchain/go-sdk/overlay"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction/template/pushdrop"
+	"github.com/bsv-blockchain/go-sdk/wallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTestWallet = errors.New("wallet failed")
+
+// lockTestAdvertisementToken locks the fields in a PushDrop advertisement token signed by the wallet
+// with the protocol of the advertisement
+func lockTestAdvertisementToken(t testing.TB, w wallet.Interface, protocol overlay.Protocol, fields [][]byte) *script.Script {
+	t.Helper()
+
+	pd := pushdrop.PushDrop{Wallet: w}
+	lockingScript, err := pd.Lock(
+		context.Background(),
+		fields,
+		wallet.Protocol{SecurityLevel: wallet.SecurityLevelEveryAppAndCounterparty, Protocol: string(protocol.ID())},
+		AdvertisementTokenKeyID,
+		wallet.Counterparty{Type: wallet.CounterpartyTypeAnyone},
+		true,
+		true,
+		pushdrop.LockBefore,
+	)
+	require.NoError(t, err)
+	return lockingScript
+}
+
+// lockTestAdvertisementScripts builds count SHIP advertisement tokens for distinct topics and returns their locking scripts
+func lockTestAdvertisementScripts(t testing.TB, count int) []*script.Script {
+	t.Helper()
+
+	signerKey, err := ec.PrivateKeyFromHex("0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+	require.NoError(t, err)
+	signerWallet := wallet.NewTestWallet(t, signerKey)
+
+	lockingScripts := make([]*script.Script, count)
+	for i := range lockingScripts {
+		token, err := BuildAdvertisementToken(context.Background(), signerWallet, overlay.ProtocolSHIP, "https://example.com", fmt.Sprintf("tm_topic_%c", 'a'+i%26))
+		require.NoError(t, err)
+		lockingScripts[i] = token.LockingScript
+	}
+	return lockingScripts
+}
+
+func TestBuildAdvertisementToken(t *testing.T) {
+	ctx := context.Background()
+
+	signerKey, err := ec.PrivateKeyFromHex("0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+	require.NoError(t, err)
+	signerWallet := wallet.NewTestWallet(t, signerKey)
+	identityKey := signerKey.PubKey().ToDERHex()
+
+	t.Run("builds tokens round-tripping through the verifier", func(t *testing.T) {
+		for _, tc := range []struct {
+			protocol       overlay.Protocol
+			uri            string
+			topicOrService string
+		}{
+			{overlay.ProtocolSHIP, "https://domain.com", "tm_meter"},
+			{overlay.ProtocolSLAP, "https+bsvauth+smf://domain.com:8080", "ls_meter"},
+		} {
+			token, err := BuildAdvertisementToken(ctx, signerWallet, tc.protocol, tc.uri, tc.topicOrService)
+			require.NoError(t, err)
+			assert.Equal(t, tc.protocol, token.Protocol)
+			assert.Equal(t, identityKey, token.IdentityKey)
+			assert.Equal(t, tc.uri, token.Domain)
+			assert.Equal(t, tc.topicOrService, token.TopicOrService)
+			require.NotNil(t, token.LockingScript)
+
+			valid, err := IsTokenSignatureCorrectlyLinked(ctx, token.LockingPublicKey.ToDERHex(), token.Fields)
+			require.NoError(t, err)
+			assert.True(t, valid)
+
+			parsed, err := ParseAdvertisementToken(ctx, token.LockingScript, tc.protocol)
+			require.NoError(t, err)
+			assert.Equal(t, token, parsed)
+		}
+	})
+
+	t.Run("rejects invalid advertisements", func(t *testing.T) {
+		tests := []struct {
+			name           string
+			protocol       overlay.Protocol
+			uri            string
+			topicOrService string
+			expectedErr    error
+		}{
+			{name: "Empty protocol", uri: "https://domain.com", topicOrService: "tm_meter", expectedErr: ErrTokenProtocol},
+			{name: "Unknown protocol", protocol: "FOO", uri: "https://domain.com", topicOrService: "tm_meter", expectedErr: ErrTokenProtocol},
+			{name: "Non-advertisable URI", protocol: overlay.ProtocolSHIP, uri: "http://domain.com", topicOrService: "tm_meter", expectedErr: ErrTokenURI},
+			{name: "Invalid topic name", protocol: overlay.ProtocolSHIP, uri: "https://domain.com", topicOrService: "tm_Meter", expectedErr: ErrTokenTopicOrService},
+			{name: "SHIP topic for SLAP", protocol: overlay.ProtocolSLAP, uri: "https://domain.com", topicOrService: "tm_meter", expectedErr: ErrTokenTopicOrService},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				token, err := BuildAdvertisementToken(ctx, signerWallet, tt.protocol, tt.uri, tt.topicOrService)
+				require.ErrorIs(t, err, tt.expectedErr)
+				require.ErrorIs(t, err, ErrInvalidAdvertisementToken)
+				assert.Nil(t, token)
+			})
+		}
+	})
+
+	t.Run("returns wallet errors", func(t *testing.T) {
+		failingWallet := wallet.NewTestWallet(t, signerKey)
+		failingWallet.OnGetPublicKey().ReturnError(errTestWallet)
+
+		_, err := BuildAdvertisementToken(ctx, failingWallet, overlay.ProtocolSHIP, "https://domain.com", "tm_meter")
+		require.ErrorIs(t, err, errTestWallet)
+	})
+}
+
+func TestParseAdvertisementToken(t *testing.T) {
+	ctx := context.Background()
+
+	signerKey, err := ec.PrivateKeyFromHex("0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+	require.NoError(t, err)
+	signerWallet := wallet.NewTestWallet(t, signerKey)
+	identityKeyResult, err := signerWallet.GetPublicKey(ctx, wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+	identityKey := identityKeyResult.PublicKey.Compressed()
+
+	token := func(protocol overlay.Protocol, domain, topicOrService string) *script.Script {
+		return lockTestAdvertisementToken(t, signerWallet, protocol, [][]byte{[]byte(protocol), identityKey, []byte(domain), []byte(topicOrService)})
+	}
+
+	t.Run("parses valid SHIP and SLAP tokens", func(t *testing.T) {
+		for _, tc := range []struct {
+			protocol       overlay.Protocol
+			topicOrService string
+		}{
+			{overlay.ProtocolSHIP, "tm_meter"},
+			{overlay.ProtocolSLAP, "ls_meter"},
+		} {
+			lockingScript := token(tc.protocol, "https://domain.com", tc.topicOrService)
+
+			for _, expected := range []overlay.Protocol{"", tc.protocol} {
+				parsed, err := ParseAdvertisementToken(ctx, lockingScript, expected)
+				require.NoError(t, err)
+				assert.Equal(t, tc.protocol, parsed.Protocol)
+				assert.Equal(t, identityKeyResult.PublicKey.ToDERHex(), parsed.IdentityKey)
+				assert.Equal(t, "https://domain.com", parsed.Domain)
+				assert.Equal(t, tc.topicOrService, parsed.TopicOrService)
+				assert.NotNil(t, parsed.LockingPublicKey)
+				assert.Len(t, parsed.Fields, 5)
+			}
+		}
+	})
+
+	t.Run("rejects invalid tokens", func(t *testing.T) {
+		otherKey, err := ec.PrivateKeyFromHex("fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210")
+		require.NoError(t, err)
+		impersonation := lockTestAdvertisementToken(t, wallet.NewTestWallet(t, otherKey), overlay.ProtocolSHIP,
+			[][]byte{[]byte(overlay.ProtocolSHIP), identityKey, []byte("https://domain.com"), []byte("tm_meter")})
+		unknownProtocol := lockTestAdvertisementToken(t, signerWallet, overlay.ProtocolSHIP,
+			[][]byte{[]byte("FOO"), identityKey, []byte("https://domain.com"), []byte("tm_meter")})
+
+		tests := []struct {
+			name          string
+			lockingScript *script.Script
+			protocol      overlay.Protocol
+			expectedErr   error
+		}{
+			{name: "Nil script", expectedErr: ErrTokenNotPushDrop},
+			{name: "Not a PushDrop script", lockingScript: script.NewFromBytes([]byte{script.OpTRUE}), expectedErr: ErrTokenNotPushDrop},
+			{name: "Unknown protocol", lockingScript: unknownProtocol, expectedErr: ErrTokenProtocol},
+			{name: "Unexpected protocol", lockingScript: token(overlay.ProtocolSLAP, "https://domain.com", "ls_meter"), protocol: overlay.ProtocolSHIP, expectedErr: ErrTokenProtocol},
+			{name: "Non-advertisable URI", lockingScript: token(overlay.ProtocolSHIP, "http://domain.com", "tm_meter"), expectedErr: ErrTokenURI},
+			{name: "Invalid topic name", lockingScript: token(overlay.ProtocolSHIP, "https://domain.com", "tm_Meter"), expectedErr: ErrTokenTopicOrService},
+			{name: "SLAP service in a SHIP token", lockingScript: token(overlay.ProtocolSHIP, "https://domain.com", "ls_meter"), expectedErr: ErrTokenTopicOrService},
+			{name: "SHIP topic in a SLAP token", lockingScript: token(overlay.ProtocolSLAP, "https://domain.com", "tm_meter"), expectedErr: ErrTokenTopicOrService},
+			{name: "Signature of another identity", lockingScript: impersonation, expectedErr: ErrTokenSignature},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				parsed, err := ParseAdvertisementToken(ctx, tt.lockingScript, tt.protocol)
+				require.ErrorIs(t, err, tt.expectedErr)
+				require.ErrorIs(t, err, ErrInvalidAdvertisementToken)
+				assert.Nil(t, parsed)
+			})
+		}
+	})
+
+	t.Run("rejects tokens without exactly 5 fields", func(t *testing.T) {
+		fourFields := lockTestAdvertisementToken(t, signerWallet, overlay.ProtocolSHIP,
+			[][]byte{[]byte(overlay.ProtocolSHIP), identityKey, []byte("https://domain.com")})
+
+		_, err := ParseAdvertisementToken(ctx, fourFields, "")
+		require.ErrorIs(t, err, ErrTokenFieldCount)
+		assert.Contains(t, err.Error(), "got 4")
+	})
+}
+
+func TestParseAdvertisementTokens(t *testing.T) {
+	ctx := context.Background()
+	valid := lockTestAdvertisementScripts(t, 5)
+	lockingScripts := []*script.Script{valid[0], nil, valid[1], script.NewFromBytes([]byte{script.OpTRUE}), valid[2], valid[3], valid[4]}
+
+	for _, concurrency := range []int{1, 3, 0, 100} {
+		t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+			tokens, errs := ParseAdvertisementTokens(ctx, lockingScripts, overlay.ProtocolSHIP, concurrency)
+			require.Len(t, tokens, len(lockingScripts))
+			require.Len(t, errs, len(lockingScripts))
+
+			for i, lockingScript := range lockingScripts {
+				expected, expectedErr := ParseAdvertisementToken(ctx, lockingScript, overlay.ProtocolSHIP)
+				assert.Equal(t, expected, tokens[i], "output %d", i)
+				assert.Equal(t, expectedErr, errs[i], "output %d", i)
+			}
+		})
+	}
+
+	t.Run("no scripts", func(t *testing.T) {
+		tokens, errs := ParseAdvertisementTokens(ctx, nil, overlay.ProtocolSHIP, 0)
+		assert.Empty(t, tokens)
+		assert.Empty(t, errs)
+	})
+}
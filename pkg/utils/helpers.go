@@ -18,6 +18,10 @@ var (
 	errMissingIdentityKeyField = errors.New("missing identity key field")
 )
 
+// anyoneWallet verifies token signatures and derives the expected locking keys. Its key deriver only
+// holds the anyone key, so a single instance is shared by all verifications, including concurrent ones.
+var anyoneWallet, _ = wallet.NewWallet(nil)
+
 // TokenFields represents the fields of a PushDrop token for SHIP or SLAP advertisement
 type TokenFields [][]byte
 
@@ -28,10 +32,11 @@ type TokenFields [][]byte
 // 1. The signature over the token data is valid for the claimed identity key
 // 2. The locking public key matches the correct derived child key
 //
+// It is safe for concurrent use.
+//
 // Parameters:
 //   - lockingPublicKey: The public key used in the output's locking script (hex string)
 //   - fields: The fields of the PushDrop token for the SHIP or SLAP advertisement
-//   - wallet: Implementation of WalletInterface for cryptographic operations
 //
 // Returns:
 //   - bool: true if the token's signature is properly linked to the claimed identity key
@@ -86,7 +91,7 @@ func IsTokenSignatureCorrectlyLinked(ctx context.Context, lockingPublicKey strin
 			SecurityLevel: wallet.SecurityLevelEveryAppAndCounterparty,
 			Protocol:      protocolID,
 		},
-		KeyID: "1",
+		KeyID: AdvertisementTokenKeyID,
 	}
 
 	verifyReq := wallet.VerifySignatureArgs{
@@ -94,7 +99,6 @@ func IsTokenSignatureCorrectlyLinked(ctx context.Context, lockingPublicKey strin
 		Data:           data,
 		Signature:      sig,
 	}
-	anyoneWallet, _ := wallet.NewWallet(nil)
 	verifyResult, err := anyoneWallet.VerifySignature(ctx, verifyReq, "")
 	if err != nil {
 		return false, fmt.Errorf("signature verification failed: %w", err)
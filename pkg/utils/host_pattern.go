@@ -0,0 +1,65 @@
+package utils
+
+// Host patterns for matching the hosts of advertised URIs.
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidHostPattern is returned when a host pattern cannot be parsed.
+var ErrInvalidHostPattern = errors.New("invalid host pattern")
+
+// hostLabelRegex validates a single label of a host pattern after lowercasing.
+var hostLabelRegex = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// HostPattern is a parsed pattern matching the hosts of advertised URIs.
+//
+// A pattern is either a host name such as "api.example.com", which matches that host
+// exactly, or a wildcard such as "*.example.com", which matches every subdomain of
+// example.com at any depth but not example.com itself. Matching is case-insensitive
+// and ignores a trailing root label dot, like the host returned by ParseAdvertisableURI.
+type HostPattern struct {
+	// Host is the normalized host name, without the wildcard label
+	Host string
+	// Wildcard reports whether the pattern matches the subdomains of Host instead of Host itself
+	Wildcard bool
+}
+
+// ParseHostPattern parses a host pattern of the form "host.name" or "*.host.name".
+func ParseHostPattern(pattern string) (HostPattern, error) {
+	host, wildcard := strings.CutPrefix(normalizeHost(strings.TrimSpace(pattern)), "*.")
+	if host == "" {
+		return HostPattern{}, fmt.Errorf("%w: %q", ErrInvalidHostPattern, pattern)
+	}
+
+	for _, label := range strings.Split(host, ".") {
+		if !hostLabelRegex.MatchString(label) {
+			return HostPattern{}, fmt.Errorf("%w: %q", ErrInvalidHostPattern, pattern)
+		}
+	}
+
+	return HostPattern{Host: host, Wildcard: wildcard}, nil
+}
+
+// Matches reports whether the normalized host matches the pattern.
+func (p HostPattern) Matches(host string) bool {
+	if p.Wildcard {
+		return strings.HasSuffix(host, "."+p.Host)
+	}
+
+	return host == p.Host
+}
+
+// ReversedRange returns the half-open range [lower, upper) of reversed hosts (see ReverseHost)
+// matched by a wildcard pattern. For "*.example.com" this is ["com.example.", "com.example/"),
+// so storage backends can match subdomains with an indexed range query instead of a regex scan.
+// Exact patterns match the single reversed host ReverseHost(p.Host) instead.
+func (p HostPattern) ReversedRange() (lower, upper string) {
+	reversed := ReverseHost(p.Host)
+
+	// '/' is the byte following '.', so the range covers exactly the hosts prefixed with "<reversed>."
+	return reversed + ".", reversed + "/"
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseHostPattern(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		expected HostPattern
+		valid    bool
+	}{
+		{"exact host", "api.example.com", HostPattern{Host: "api.example.com"}, true},
+		{"wildcard", "*.example.com", HostPattern{Host: "example.com", Wildcard: true}, true},
+		{"normalized", " *.Example.COM. ", HostPattern{Host: "example.com", Wildcard: true}, true},
+		{"single label", "example", HostPattern{Host: "example"}, true},
+		{"empty", "", HostPattern{}, false},
+		{"bare wildcard", "*", HostPattern{}, false},
+		{"wildcard without host", "*.", HostPattern{}, false},
+		{"inner wildcard", "api.*.example.com", HostPattern{}, false},
+		{"partial wildcard", "*example.com", HostPattern{}, false},
+		{"empty label", "api..example.com", HostPattern{}, false},
+		{"scheme", "https://example.com", HostPattern{}, false},
+		{"port", "example.com:8080", HostPattern{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseHostPattern(tt.pattern)
+			if tt.valid {
+				if err != nil || result != tt.expected {
+					t.Errorf("ParseHostPattern(%q) = %+v, %v, expected %+v", tt.pattern, result, err, tt.expected)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidHostPattern) {
+				t.Errorf("ParseHostPattern(%q) error = %v, expected ErrInvalidHostPattern", tt.pattern, err)
+			}
+		})
+	}
+}
+
+func TestHostPatternMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		host     string
+		expected bool
+	}{
+		{"exact match", "example.com", "example.com", true},
+		{"exact does not match subdomain", "example.com", "api.example.com", false},
+		{"wildcard matches subdomain", "*.example.com", "api.example.com", true},
+		{"wildcard matches nested subdomain", "*.example.com", "eu.api.example.com", true},
+		{"wildcard does not match apex", "*.example.com", "example.com", false},
+		{"wildcard does not match label suffix", "*.example.com", "badexample.com", false},
+		{"empty host", "*.example.com", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern, err := ParseHostPattern(tt.pattern)
+			if err != nil {
+				t.Fatalf("ParseHostPattern(%q) failed: %v", tt.pattern, err)
+			}
+			if result := pattern.Matches(tt.host); result != tt.expected {
+				t.Errorf("%q.Matches(%q) = %v, expected %v", tt.pattern, tt.host, result, tt.expected)
+			}
+
+			// The reversed range used by storage backends must agree with Matches
+			if pattern.Wildcard {
+				lower, upper := pattern.ReversedRange()
+				reversed := ReverseHost(tt.host)
+				if inRange := reversed >= lower && reversed < upper; inRange != tt.expected {
+					t.Errorf("%q reversed range [%q, %q) contains %q = %v, expected %v", tt.pattern, lower, upper, reversed, inRange, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestReverseHost(t *testing.T) {
+	tests := map[string]string{
+		"api.example.com": "com.example.api",
+		"example.com":     "com.example",
+		"localhost":       "localhost",
+		"":                "",
+	}
+
+	for host, expected := range tests {
+		if result := ReverseHost(host); result != expected {
+			t.Errorf("ReverseHost(%q) = %q, expected %q", host, result, expected)
+		}
+	}
+}
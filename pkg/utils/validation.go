@@ -21,6 +21,32 @@ var (
 	numberRegex = regexp.MustCompile(`(\d+(?:\.\d+)?)`)
 )
 
+// advertisableSchemes lists the URI schemes accepted by IsAdvertisableURI, in matching order.
+var advertisableSchemes = []string{
+	"https",
+	"https+bsvauth",
+	"https+bsvauth+smf",
+	"https+bsvauth+scrypt-offchain",
+	"https+rtt",
+	"wss",
+	"js8c+bsvauth+smf",
+}
+
+// AdvertisableURI holds the normalized components of an advertisable URI.
+type AdvertisableURI struct {
+	// Scheme is the URI scheme without its separator, e.g. "https+bsvauth"
+	Scheme string
+	// Host is the lowercased host name without port or trailing dot; it is empty for JS8 Call URIs
+	Host string
+}
+
+// ReversedHost returns the host with its labels in reverse order, e.g. "com.example.api"
+// for "api.example.com". Storing hosts in this form turns subdomain matching into a
+// prefix range that can be served from an index.
+func (u AdvertisableURI) ReversedHost() string {
+	return ReverseHost(u.Host)
+}
+
 // IsAdvertisableURI checks if the provided URI is advertisable, with a recognized URI prefix.
 // Applies scheme-specific validation rules as defined by the BRC-101 overlay advertisement spec.
 //
@@ -36,79 +62,128 @@ var (
 // Returns:
 //   - bool: true if the URI is valid and advertisable, false otherwise
 func IsAdvertisableURI(uri string) bool {
+	_, ok := ParseAdvertisableURI(uri)
+	return ok
+}
+
+// ParseAdvertisableURI validates the URI with the same rules as IsAdvertisableURI and returns
+// its normalized scheme and host.
+//
+// Parameters:
+//   - uri: The URI string to parse
+//
+// Returns:
+//   - AdvertisableURI: the normalized scheme and host (zero value if the URI is not advertisable)
+//   - bool: true if the URI is valid and advertisable, false otherwise
+func ParseAdvertisableURI(uri string) (AdvertisableURI, bool) {
 	if uri == "" || strings.TrimSpace(uri) == "" {
-		return false
+		return AdvertisableURI{}, false
 	}
 
-	// HTTPS-based schemes - disallow localhost
-	if strings.HasPrefix(uri, "https://") {
-		return validateCustomHTTPSURI(uri, "https://")
-	} else if strings.HasPrefix(uri, "https+bsvauth://") {
-		// Plain auth over HTTPS, but no payment can be collected
-		return validateCustomHTTPSURI(uri, "https+bsvauth://")
-	} else if strings.HasPrefix(uri, "https+bsvauth+smf://") {
-		// Auth and payment over HTTPS
-		return validateCustomHTTPSURI(uri, "https+bsvauth+smf://")
-	} else if strings.HasPrefix(uri, "https+bsvauth+scrypt-offchain://") {
-		// A protocol allowing you to also supply sCrypt off-chain values to the topical admissibility checking context
-		return validateCustomHTTPSURI(uri, "https+bsvauth+scrypt-offchain://")
-	} else if strings.HasPrefix(uri, "https+rtt://") {
-		// A protocol allowing overlays that deal with real-time transactions (non-finals)
-		return validateCustomHTTPSURI(uri, "https+rtt://")
-	} else if strings.HasPrefix(uri, "wss://") {
+	var (
+		host string
+		ok   bool
+	)
+	scheme := advertisableScheme(uri)
+	switch scheme {
+	case "https", "https+bsvauth", "https+bsvauth+smf", "https+bsvauth+scrypt-offchain", "https+rtt":
+		// HTTPS-based schemes - disallow localhost.
+		// https+bsvauth: plain auth over HTTPS, but no payment can be collected.
+		// https+bsvauth+smf: auth and payment over HTTPS.
+		// https+bsvauth+scrypt-offchain: also supplies sCrypt off-chain values to the topical admissibility checking context.
+		// https+rtt: overlays that deal with real-time transactions (non-finals).
+		host, ok = parseCustomHTTPSURI(uri, scheme+"://")
+	case "wss":
 		// WSS for real-time event-listening lookups
-		return validateWSSURI(uri)
-	} else if strings.HasPrefix(uri, "js8c+bsvauth+smf:") {
-		// JS8 Call-based advertisement
-		return validateJS8CallURI(uri)
+		host, ok = parseWSSURI(uri)
+	case "js8c+bsvauth+smf":
+		// JS8 Call-based advertisement, which has no host
+		ok = validateJS8CallURI(uri)
 	}
 
 	// If none of the known prefixes match, the URI is not advertisable
-	return false
+	if !ok {
+		return AdvertisableURI{}, false
+	}
+
+	return AdvertisableURI{Scheme: scheme, Host: normalizeHost(host)}, true
+}
+
+// IsAdvertisableScheme reports whether the scheme (without separator, e.g. "https+bsvauth")
+// is one of the schemes accepted by IsAdvertisableURI.
+func IsAdvertisableScheme(scheme string) bool {
+	return slices.Contains(advertisableSchemes, scheme)
+}
+
+// advertisableScheme returns the advertisable scheme the URI starts with, or an empty string.
+func advertisableScheme(uri string) string {
+	for _, scheme := range advertisableSchemes {
+		separator := "://"
+		if scheme == "js8c+bsvauth+smf" {
+			separator = ":"
+		}
+		if strings.HasPrefix(uri, scheme+separator) {
+			return scheme
+		}
+	}
+
+	return ""
+}
+
+// normalizeHost lowercases the host and strips a trailing root label dot.
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// ReverseHost returns the host with its dot-separated labels in reverse order.
+func ReverseHost(host string) string {
+	labels := strings.Split(host, ".")
+	slices.Reverse(labels)
+	return strings.Join(labels, ".")
 }
 
-// validateCustomHTTPSURI validates a URL by substituting its scheme if needed.
+// parseCustomHTTPSURI validates a URL by substituting its scheme if needed, and returns its hostname.
 // This helper function handles custom HTTPS-based schemes by replacing them with "https://"
 // for URL parsing, then validates the hostname and path.
-func validateCustomHTTPSURI(uri, prefix string) bool {
+func parseCustomHTTPSURI(uri, prefix string) (string, bool) {
 	// Replace the custom scheme with "https://" for parsing
 	modifiedURI := strings.Replace(uri, prefix, "https://", 1)
 
 	parsedURL, err := url.Parse(modifiedURI)
 	if err != nil {
-		return false
+		return "", false
 	}
 
 	// Disallow localhost
 	if strings.ToLower(parsedURL.Hostname()) == "localhost" {
-		return false
+		return "", false
 	}
 
 	// Path must be root path only
 	if !slices.Contains([]string{"/", ""}, parsedURL.Path) {
-		return false
+		return "", false
 	}
 
-	return true
+	return parsedURL.Hostname(), true
 }
 
-// validateWSSURI validates WebSocket Secure URIs for real-time lookup streaming.
-func validateWSSURI(uri string) bool {
+// parseWSSURI validates WebSocket Secure URIs for real-time lookup streaming, and returns their hostname.
+func parseWSSURI(uri string) (string, bool) {
 	parsedURL, err := url.Parse(uri)
 	if err != nil {
-		return false
+		return "", false
 	}
 
 	if parsedURL.Scheme != "wss" {
-		return false
+		return "", false
 	}
 
 	// Disallow localhost
 	if strings.ToLower(parsedURL.Hostname()) == "localhost" {
-		return false
+		return "", false
 	}
 
-	return true
+	return parsedURL.Hostname(), true
 }
 
 // validateJS8CallURI validates JS8 Call-based advertisement URIs.
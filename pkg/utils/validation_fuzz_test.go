@@ -107,9 +107,9 @@ func FuzzIsValidTopicOrServiceName(f *testing.F) {
 	})
 }
 
-// FuzzValidateCustomHTTPSURI tests the validateCustomHTTPSURI function
+// FuzzParseCustomHTTPSURI tests the parseCustomHTTPSURI function
 // with random inputs to ensure robustness.
-func FuzzValidateCustomHTTPSURI(f *testing.F) {
+func FuzzParseCustomHTTPSURI(f *testing.F) {
 	// Seed corpus with valid examples
 	f.Add("custom://example.com/", "custom://")
 	f.Add("https://example.com/", "https://")
@@ -128,14 +128,14 @@ func FuzzValidateCustomHTTPSURI(f *testing.F) {
 
 	f.Fuzz(func(t *testing.T, uri, prefix string) {
 		// Function should not panic on any input
-		_ = validateCustomHTTPSURI(uri, prefix)
+		_, _ = parseCustomHTTPSURI(uri, prefix)
 		// We don't validate the result as this is an internal function
 		// The main goal is to ensure it doesn't panic
 	})
 }
 
-// FuzzValidateWSSURI tests the validateWSSURI function with random inputs.
-func FuzzValidateWSSURI(f *testing.F) {
+// FuzzParseWSSURI tests the parseWSSURI function with random inputs.
+func FuzzParseWSSURI(f *testing.F) {
 	// Seed corpus with valid examples
 	f.Add("wss://example.com")
 	f.Add("wss://example.com:443")
@@ -155,7 +155,7 @@ func FuzzValidateWSSURI(f *testing.F) {
 
 	f.Fuzz(func(t *testing.T, uri string) {
 		// Function should not panic on any input
-		_ = validateWSSURI(uri)
+		_, _ = parseWSSURI(uri)
 		// We don't validate the result as this is an internal function
 		// The main goal is to ensure it doesn't panic
 	})
@@ -77,6 +77,54 @@ func TestIsAdvertisableURI(t *testing.T) {
 	}
 }
 
+func TestParseAdvertisableURI(t *testing.T) {
+	tests := []struct {
+		name     string
+		uri      string
+		expected AdvertisableURI
+		ok       bool
+	}{
+		{"https", "https://example.com/", AdvertisableURI{Scheme: "https", Host: "example.com"}, true},
+		{"https+bsvauth", "https+bsvauth://api.example.com", AdvertisableURI{Scheme: "https+bsvauth", Host: "api.example.com"}, true},
+		{"https+bsvauth+smf", "https+bsvauth+smf://example.com/", AdvertisableURI{Scheme: "https+bsvauth+smf", Host: "example.com"}, true},
+		{"https+bsvauth+scrypt-offchain", "https+bsvauth+scrypt-offchain://example.com/", AdvertisableURI{Scheme: "https+bsvauth+scrypt-offchain", Host: "example.com"}, true},
+		{"https+rtt", "https+rtt://example.com/", AdvertisableURI{Scheme: "https+rtt", Host: "example.com"}, true},
+		{"wss", "wss://stream.example.com/events", AdvertisableURI{Scheme: "wss", Host: "stream.example.com"}, true},
+		{"js8c has no host", "js8c+bsvauth+smf:?lat=40.7128&long=-74.0060&freq=7.078&radius=100", AdvertisableURI{Scheme: "js8c+bsvauth+smf"}, true},
+		{"host is lowercased", "https://API.Example.COM/", AdvertisableURI{Scheme: "https", Host: "api.example.com"}, true},
+		{"port and trailing dot are dropped", "https://example.com.:8443/", AdvertisableURI{Scheme: "https", Host: "example.com"}, true},
+		{"localhost", "https://localhost/", AdvertisableURI{}, false},
+		{"path", "https+bsvauth://example.com/path", AdvertisableURI{}, false},
+		{"unsupported scheme", "http://example.com", AdvertisableURI{}, false},
+		{"empty", "", AdvertisableURI{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, ok := ParseAdvertisableURI(tt.uri)
+			if result != tt.expected || ok != tt.ok {
+				t.Errorf("ParseAdvertisableURI(%q) = %+v, %v, expected %+v, %v", tt.uri, result, ok, tt.expected, tt.ok)
+			}
+			if ok != IsAdvertisableURI(tt.uri) {
+				t.Errorf("ParseAdvertisableURI(%q) disagrees with IsAdvertisableURI", tt.uri)
+			}
+		})
+	}
+}
+
+func TestIsAdvertisableScheme(t *testing.T) {
+	for _, scheme := range []string{"https", "https+bsvauth", "https+bsvauth+smf", "https+bsvauth+scrypt-offchain", "https+rtt", "wss", "js8c+bsvauth+smf"} {
+		if !IsAdvertisableScheme(scheme) {
+			t.Errorf("IsAdvertisableScheme(%q) = false, expected true", scheme)
+		}
+	}
+	for _, scheme := range []string{"", "http", "https://", "HTTPS", "ws"} {
+		if IsAdvertisableScheme(scheme) {
+			t.Errorf("IsAdvertisableScheme(%q) = true, expected false", scheme)
+		}
+	}
+}
+
 func TestIsValidTopicOrServiceName(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -130,46 +178,48 @@ func TestIsValidTopicOrServiceName(t *testing.T) {
 	}
 }
 
-func TestValidateCustomHTTPSURI(t *testing.T) {
+func TestParseCustomHTTPSURI(t *testing.T) {
 	tests := []struct {
-		name     string
-		uri      string
-		prefix   string
-		expected bool
+		name         string
+		uri          string
+		prefix       string
+		expectedHost string
+		expected     bool
 	}{
-		{"valid custom scheme", "custom://example.com/", "custom://", true},
-		{"localhost blocked", "custom://localhost/", "custom://", false},
-		{"path not allowed", "custom://example.com/path", "custom://", false},
-		{"malformed URL", "custom://[invalid", "custom://", false},
+		{"valid custom scheme", "custom://example.com/", "custom://", "example.com", true},
+		{"localhost blocked", "custom://localhost/", "custom://", "", false},
+		{"path not allowed", "custom://example.com/path", "custom://", "", false},
+		{"malformed URL", "custom://[invalid", "custom://", "", false},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := validateCustomHTTPSURI(tt.uri, tt.prefix)
-			if result != tt.expected {
-				t.Errorf("validateCustomHTTPSURI(%q, %q) = %v, expected %v", tt.uri, tt.prefix, result, tt.expected)
+			host, result := parseCustomHTTPSURI(tt.uri, tt.prefix)
+			if result != tt.expected || host != tt.expectedHost {
+				t.Errorf("parseCustomHTTPSURI(%q, %q) = %q, %v, expected %q, %v", tt.uri, tt.prefix, host, result, tt.expectedHost, tt.expected)
 			}
 		})
 	}
 }
 
-func TestValidateWSSURI(t *testing.T) {
+func TestParseWSSURI(t *testing.T) {
 	tests := []struct {
-		name     string
-		uri      string
-		expected bool
+		name         string
+		uri          string
+		expectedHost string
+		expected     bool
 	}{
-		{"valid wss", "wss://example.com", true},
-		{"localhost blocked", "wss://localhost", false},
-		{"wrong scheme", "ws://example.com", false},
-		{"malformed URL", "wss://[invalid", false},
+		{"valid wss", "wss://example.com", "example.com", true},
+		{"localhost blocked", "wss://localhost", "", false},
+		{"wrong scheme", "ws://example.com", "", false},
+		{"malformed URL", "wss://[invalid", "", false},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := validateWSSURI(tt.uri)
-			if result != tt.expected {
-				t.Errorf("validateWSSURI(%q) = %v, expected %v", tt.uri, result, tt.expected)
+			host, result := parseWSSURI(tt.uri)
+			if result != tt.expected || host != tt.expectedHost {
+				t.Errorf("parseWSSURI(%q) = %q, %v, expected %q, %v", tt.uri, host, result, tt.expectedHost, tt.expected)
 			}
 		})
 	}